@@ -19,6 +19,7 @@
 package gonvme
 
 import (
+	"context"
 	"time"
 
 	"github.com/dell/gonvme/internal/logger"
@@ -41,6 +42,15 @@ type NVMEinterface interface {
 	// returns an array of NVMeFC Target instances
 	DiscoverNVMeFCTargets(address string, login bool) ([]NVMeTarget, error)
 
+	// DiscoverAllNVMeFCTargets discovers the targets behind every online NVMe discovery remote port
+	DiscoverAllNVMeFCTargets(login bool) ([]NVMeTarget, error)
+
+	// ListFCRemotePorts returns the Fibre Channel remote ports seen by the local HBAs
+	ListFCRemotePorts() ([]FCRemotePort, error)
+
+	// RescanFCHost rescans a local FC host for remote ports added by zoning changes
+	RescanFCHost(host string, opts RescanFCHostOptions) error
+
 	// GetInitiators get a list of NVMe initiators defined in a specified file
 	// To use the system default file of "/etc/nvme/hostnqn", provide a filename of ""
 	GetInitiators(filename string) ([]string, error)
@@ -48,6 +58,15 @@ type NVMEinterface interface {
 	// GetHostID get the NVMe host ID from the system default file "/etc/nvme/hostid"
 	GetHostID() (string, error)
 
+	// CLIVersion returns the version of nvme-cli
+	CLIVersion() (NVMeCLIVersion, error)
+
+	// Available returns an error when nvme-cli cannot be found or run
+	Available() error
+
+	// CheckPrerequisites reports the kernel modules, devices and host identity needed to connect over the transports
+	CheckPrerequisites(transports []string) (PrerequisiteReport, error)
+
 	// NVMeTCPConnect connects into a specified NVMeTCP target
 	NVMeTCPConnect(target NVMeTarget, duplicateConnect bool) error
 
@@ -57,33 +76,87 @@ type NVMEinterface interface {
 	// NVMeDisconnect disconnect from the specified NVMe target
 	NVMeDisconnect(target NVMeTarget) error
 
+	// DisconnectController disconnects a single controller, e.g. nvme3
+	DisconnectController(name string) error
+
+	// DisconnectPath disconnects the controllers of a subsystem reached through a portal and, optionally, a host address
+	DisconnectPath(nqn string, portal string, hostAddr string) ([]string, error)
+
+	// DisconnectAll disconnects every controller whose session matches the filter
+	DisconnectAll(filter SessionFilter) ([]string, error)
+
+	// SafeDisconnect disconnects from the specified NVMe target unless, depending on the policy, its namespaces are in use
+	SafeDisconnect(ctx context.Context, target NVMeTarget, policy DisconnectPolicy) error
+
 	// ListNVMeDeviceAndNamespace returns the NVME Device Paths and Namespace of each of the NVME device
 	ListNVMeDeviceAndNamespace() ([]DevicePathAndNamespace, error)
 
+	// GetInventory returns the hosts, subsystems, controllers and namespaces reported by nvme list
+	GetInventory() (Inventory, error)
+
 	// ListNVMeNamespaceID returns the namespace IDs for each NVME device path
 	ListNVMeNamespaceID(NVMeDeviceNamespace []DevicePathAndNamespace) (map[DevicePathAndNamespace][]string, error)
 
+	// ListNamespaces returns the namespace IDs reported by each controller along with per controller errors
+	ListNamespaces(controllers []string, opts ListNamespacesOptions) ([]ControllerNamespaces, error)
+
 	// GetNVMeDeviceData returns the information (nguid and namespace) of an NVME device path
 	GetNVMeDeviceData(path string) (string, string, error)
 
 	// GetSessions queries information about NVMe sessions
 	GetSessions() ([]NVMESession, error)
 
+	// GetController returns the sysfs attributes of an NVMe controller
+	GetController(name string) (Controller, error)
+
+	// ListControllers returns the sysfs attributes of every NVMe controller
+	ListControllers() ([]Controller, error)
+
+	// UpdateControllerTimeouts changes the fabrics timeouts of a live controller
+	UpdateControllerTimeouts(name string, timeouts ControllerTimeouts) (Controller, error)
+
+	// UpdateSubsystemTimeouts changes the fabrics timeouts of every controller of the subsystem NQN
+	UpdateSubsystemTimeouts(nqn string, timeouts ControllerTimeouts) error
+
+	// ResetController resets an NVMe controller
+	ResetController(name string) error
+
+	// TuneNamespaceQueue applies block layer settings to a namespace device
+	TuneNamespaceQueue(device string, settings NamespaceQueueSettings) error
+
+	// SetCoreMaxRetries sets the number of retries of failed I/Os of every NVMe namespace of the host
+	SetCoreMaxRetries(retries int) error
+
+	// Reconcile connects the missing desired paths and, optionally, disconnects the extra ones
+	Reconcile(ctx context.Context, desired []NVMeTarget, opts ReconcileOptions) (ReconcileReport, error)
+
 	// generic implementations
 	isMock() bool
 	getOptions() map[string]string
+	log() *logger.Entry
 
 	// DeviceRescan rescan the NVMe controller device
 	DeviceRescan(device string) error
+
+	// RescanSubsystem rescans every controller connected to the subsystem NQN
+	RescanSubsystem(nqn string) error
+
+	// RescanAll rescans every NVMe controller on the host
+	RescanAll() error
+
+	// RefreshNamespaceCapacity rescans the controllers of a namespace and waits for its new size to be visible
+	RefreshNamespaceCapacity(ctx context.Context, device string) (NamespaceCapacity, error)
 }
 
 // NVMeType is the base structure for each platform implementation
 type NVMeType struct {
 	mock    bool
 	options map[string]string
+	// Logger receives the logs of the client, the logger set with SetLogger when nil
+	Logger Logger
 }
 
-// SetLogger set custom logger for gonvme
+// SetLogger set custom logger for gonvme, used by the clients without Logger
 func SetLogger(customLogger Logger) {
 	logger.SetLogger(customLogger)
 }
@@ -94,7 +167,7 @@ func SetTracer(customTracer Tracer) {
 }
 
 func setTimeouts(prop *time.Duration, value time.Duration, defaultVal time.Duration) {
-	if value == 0 {
+	if value <= 0 {
 		*prop = defaultVal
 	} else {
 		*prop = value
@@ -0,0 +1,137 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NVMeBinary is the path of nvme-cli on the host, looked up in the usual paths and in $PATH when not set
+const NVMeBinary = "nvmeBinary"
+
+// defaultSearchPath is the PATH searched when the PATH of the host cannot be read
+const defaultSearchPath = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+
+// BinaryNotFoundError is returned when no nvme-cli executable is found on the host
+type BinaryNotFoundError struct {
+	// Root is the directory the root of the host is seen in
+	Root string
+	// Paths are the paths tried, on the host
+	Paths []string
+}
+
+func (e *BinaryNotFoundError) Error() string {
+	return fmt.Sprintf("nvme-cli not found in %s, tried %s", e.Root, strings.Join(e.Paths, ", "))
+}
+
+// NewNVMeWithError returns a new NVMe client like NewNVMe, or a *BinaryNotFoundError when nvme-cli
+// is not found on the host. NewNVMe returns a client in that case, whose Available reports the error.
+func NewNVMeWithError(opts map[string]string) (*NVMe, error) {
+	nvme := NewNVMe(opts)
+	if nvme.commandErr != nil {
+		return nil, nvme.commandErr
+	}
+	return nvme, nil
+}
+
+// resolveCommand returns the path of nvme-cli on the host: the NVMeBinary option when set and
+// otherwise the first executable of getPaths and of the directories of the host PATH, under the host root
+func (nvme *NVMe) resolveCommand() (string, error) {
+	var paths []string
+	if binary := nvme.options[NVMeBinary]; binary != "" {
+		paths = []string{binary}
+	} else {
+		seen := map[string]bool{}
+		candidates := getPaths()
+		for _, dir := range filepath.SplitList(nvme.searchPath()) {
+			if filepath.IsAbs(dir) {
+				candidates = append(candidates, filepath.Join(dir, "nvme"))
+			}
+		}
+		for _, path := range candidates {
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	log := nvme.log()
+	for _, path := range paths {
+		hostPath := nvme.hostPath(path)
+		info, err := os.Stat(hostPath)
+		switch {
+		case os.IsNotExist(err):
+			log.Debugf("Path %s does not exist", hostPath)
+		case err != nil:
+			log.Errorf("Unable to access path %s: %v", hostPath, err)
+		case info.IsDir():
+			log.Errorf("Path %s is a directory, not an executable", hostPath)
+		case info.Mode().Perm()&0o111 == 0:
+			log.Errorf("Path %s is not executable", hostPath)
+		default:
+			log.Infof("Path %s exists and is an executable", hostPath)
+			return path, nil
+		}
+	}
+	return "", &BinaryNotFoundError{Root: nvme.hostRoot(), Paths: paths}
+}
+
+// searchPath returns the PATH of the host. It is the PATH of this process when it runs on the host, and
+// otherwise the PATH of the host init process or of /etc/environment on the host, defaultSearchPath failing both.
+func (nvme *NVMe) searchPath() string {
+	if nvme.hostRoot() == "/" {
+		return os.Getenv("PATH")
+	}
+
+	// the init process of the host, or the process whose namespaces nvme-cli runs in
+	environ := nvme.hostPath("/proc/1/environ")
+	if nvme.options[ExecutorOption] == ExecutorNsenter {
+		environ = fmt.Sprintf("/proc/%d/environ", nvme.nsenterTarget())
+	}
+	if data, err := os.ReadFile(filepath.Clean(environ)); err == nil {
+		for _, variable := range strings.Split(string(data), "\x00") {
+			if value, ok := strings.CutPrefix(variable, "PATH="); ok {
+				return value
+			}
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Clean(nvme.hostPath("/etc/environment"))); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(line), "PATH="); ok {
+				return strings.Trim(value, "\"'")
+			}
+		}
+	}
+
+	nvme.log().Debugf("PATH of the host not found, using %s", defaultSearchPath)
+	return defaultSearchPath
+}
+
+// Available returns nil when nvme-cli can be run: a *BinaryNotFoundError when NewNVMe did not find it,
+// the error of nvme version when it does not run, through the executor, as expected
+func (nvme *NVMe) Available() error {
+	if nvme.commandErr != nil {
+		return nvme.commandErr
+	}
+	_, err := nvme.CLIVersion()
+	return err
+}
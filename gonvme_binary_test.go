@@ -0,0 +1,139 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// binaryRoot returns a host root holding the files, with their modes
+func binaryRoot(t *testing.T, files map[string]os.FileMode) string {
+	root := t.TempDir()
+	for name, mode := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, mode))
+	}
+	return root
+}
+
+func TestResolveCommand(t *testing.T) {
+	originalGetPaths := getPaths
+	defer func() { getPaths = originalGetPaths }()
+	getPaths = func() []string { return []string{"/sbin/nvme", "/usr/sbin/nvme"} }
+	// the PATH of this process is ignored under a chroot
+	t.Setenv("PATH", "/opt/nvme-cli")
+
+	tests := []struct {
+		name      string
+		files     map[string]os.FileMode
+		binary    string
+		want      string
+		wantPaths []string
+	}{
+		{"usual path", map[string]os.FileMode{"/usr/sbin/nvme": 0o755}, "", "/usr/sbin/nvme", nil},
+		{"path lookup", map[string]os.FileMode{"/usr/local/bin/nvme": 0o755}, "", "/usr/local/bin/nvme", nil},
+		{"option", map[string]os.FileMode{"/usr/sbin/nvme": 0o755, "/opt/nvme-cli/nvme": 0o755}, "/opt/nvme-cli/nvme", "/opt/nvme-cli/nvme", nil},
+		{"option not found", map[string]os.FileMode{"/usr/sbin/nvme": 0o755}, "/opt/nvme-cli/nvme", "", []string{"/opt/nvme-cli/nvme"}},
+		{"not executable", map[string]os.FileMode{"/sbin/nvme": 0o644}, "", "", []string{"/sbin/nvme", "/usr/sbin/nvme", "/usr/local/bin/nvme"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := binaryRoot(t, tt.files)
+			require.NoError(t, os.MkdirAll(filepath.Join(root, "etc"), 0o755))
+			require.NoError(t, os.WriteFile(filepath.Join(root, "etc", "environment"), []byte("PATH=\"/usr/local/bin:/usr/sbin:relative\"\n"), 0o644))
+			nvme := NewNVMe(map[string]string{ChrootDirectory: root, NVMeBinary: tt.binary})
+			if tt.wantPaths != nil {
+				var notFound *BinaryNotFoundError
+				require.ErrorAs(t, nvme.Available(), &notFound)
+				assert.Equal(t, root, notFound.Root)
+				assert.Equal(t, tt.wantPaths, notFound.Paths)
+				assert.Equal(t, "nvme", nvme.NVMeCommand)
+				return
+			}
+			assert.Equal(t, tt.want, nvme.NVMeCommand)
+			assert.NoError(t, nvme.commandErr)
+		})
+	}
+}
+
+func TestSearchPath(t *testing.T) {
+	t.Setenv("PATH", "/opt/nvme-cli")
+
+	root := binaryRoot(t, map[string]os.FileMode{"/proc/1/environ": 0o644, "/etc/environment": 0o644})
+	nvme := NewNVMe(map[string]string{ChrootDirectory: root})
+	assert.Equal(t, defaultSearchPath, nvme.searchPath())
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "etc", "environment"), []byte("LANG=C\nPATH='/usr/sbin:/usr/bin'\n"), 0o644))
+	assert.Equal(t, "/usr/sbin:/usr/bin", nvme.searchPath())
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, "proc", "1", "environ"), []byte("HOME=/\x00PATH=/sbin:/bin\x00TERM=linux"), 0o644))
+	assert.Equal(t, "/sbin:/bin", nvme.searchPath())
+
+	assert.Equal(t, "/opt/nvme-cli", NewNVMe(nil).searchPath())
+}
+
+func TestNewNVMeWithError(t *testing.T) {
+	root := binaryRoot(t, map[string]os.FileMode{"/usr/sbin/nvme": 0o755})
+	nvme, err := NewNVMeWithError(map[string]string{ChrootDirectory: root})
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/sbin/nvme", nvme.NVMeCommand)
+
+	nvme, err = NewNVMeWithError(map[string]string{ChrootDirectory: t.TempDir()})
+	var notFound *BinaryNotFoundError
+	assert.ErrorAs(t, err, &notFound)
+	assert.Nil(t, nvme)
+}
+
+func TestAvailable(t *testing.T) {
+	root := binaryRoot(t, map[string]os.FileMode{"/usr/sbin/nvme": 0o755})
+	var commands [][]string
+	originalExecutor := defaultExecutor
+	defer func() { defaultExecutor = originalExecutor }()
+	defaultExecutor = mockExecutor(func(name string, args ...string) mockCommand {
+		commands = append(commands, append([]string{name}, args...))
+		if args[len(args)-1] == "version" {
+			return mockCommand{out: []byte("nvme version 2.8 (git 2.8)\n")}
+		}
+		return mockCommand{out: []byte("[]")}
+	})
+
+	nvme := NewNVMe(map[string]string{ChrootDirectory: root})
+	assert.NoError(t, nvme.Available())
+	require.NotEmpty(t, commands)
+	assert.Equal(t, []string{"chroot", root, "/usr/sbin/nvme", "version"}, commands[0])
+
+	// every subcommand runs the resolved nvme-cli
+	_, _ = nvme.GetSessions()
+	_ = nvme.DeviceRescan("/dev/nvme0")
+	_, _ = nvme.ListNVMeDeviceAndNamespace()
+	_, _, _ = nvme.GetNVMeDeviceData("/dev/nvme0n1")
+	for _, command := range commands {
+		assert.Equal(t, "/usr/sbin/nvme", command[2])
+	}
+
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		return mockCommand{outErr: &ExitError{Code: 127}}
+	})
+	nvme = NewNVMe(map[string]string{ChrootDirectory: root})
+	assert.Error(t, nvme.Available())
+}
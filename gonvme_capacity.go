@@ -0,0 +1,227 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	sysBlockPath           = "/sys/block"
+	nvmeSubsystemClassPath = "/sys/class/nvme-subsystem"
+
+	// capacityPollInterval is the delay between two size checks of RefreshNamespaceCapacity
+	capacityPollInterval = 500 * time.Millisecond
+	// capacityDefaultTimeout bounds RefreshNamespaceCapacity when the context has no deadline
+	capacityDefaultTimeout = 30 * time.Second
+
+	controllerNameRegexp = regexp.MustCompile(`^nvme[0-9]+$`)
+	lbafInUseRegexp      = regexp.MustCompile(`lbads:([0-9]+).*\(in use\)`)
+)
+
+// ErrCapacityNotConverged is returned when the block devices do not report the Identify size before the timeout
+var ErrCapacityNotConverged = errors.New("namespace capacity did not converge")
+
+// NamespaceCapacity reports the sizes, in bytes, seen while refreshing a namespace
+type NamespaceCapacity struct {
+	Device string
+	// OldSize is the size of the block device before the rescan
+	OldSize uint64
+	// NewSize is the size of the block device after the rescan
+	NewSize uint64
+	// IdentifySize is the namespace size (nsze) reported by the controller
+	IdentifySize uint64
+	// PathSizes holds the size of each per-controller path device of a multipath namespace
+	PathSizes map[string]uint64
+}
+
+// RefreshNamespaceCapacity rescans every controller of the subsystem owning the namespace device
+// and waits until the block device, and each of its paths, report the size returned by Identify Namespace.
+// If the context has no deadline the wait is bounded by a default timeout.
+func (nvme *NVMe) RefreshNamespaceCapacity(ctx context.Context, device string) (NamespaceCapacity, error) {
+	name := path.Base(device)
+	capacity := NamespaceCapacity{Device: devPath(name)}
+	start := time.Now()
+
+	oldSize, err := nvme.readBlockSize(filepath.Join(sysBlockPath, name))
+	if err != nil {
+		return capacity, err
+	}
+	capacity.OldSize = oldSize
+
+	// a single path picking up the new size is enough for the kernel to resize the namespace head
+	controllers := nvme.namespaceControllers(name)
+	if err := nvme.rescanControllers(controllers); err != nil {
+		if failed, ok := err.(ControllerErrors); ok && len(failed) == len(controllers) {
+			return capacity, failed
+		}
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, capacityDefaultTimeout)
+		defer cancel()
+	}
+
+	for {
+		converged, err := nvme.checkNamespaceCapacity(name, &capacity)
+		if err != nil {
+			return capacity, err
+		}
+		if converged {
+			nvme.logOperation("refreshCapacity", deviceField(capacity.Device), LogField{Key: logKeyDuration, Value: time.Since(start)}).
+				WithContext(ctx).Infof("Namespace capacity refreshed from %d to %d bytes", capacity.OldSize, capacity.NewSize)
+			return capacity, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return capacity, fmt.Errorf("%w: %s reports %d bytes, identify reports %d bytes: %v",
+				ErrCapacityNotConverged, capacity.Device, capacity.NewSize, capacity.IdentifySize, ctx.Err())
+		case <-time.After(capacityPollInterval):
+		}
+	}
+}
+
+// checkNamespaceCapacity refreshes the sizes held by capacity and reports whether they all match Identify
+func (nvme *NVMe) checkNamespaceCapacity(name string, capacity *NamespaceCapacity) (bool, error) {
+	identifySize, err := nvme.getIdentifyNamespaceSize(devPath(name))
+	if err != nil {
+		return false, err
+	}
+	capacity.IdentifySize = identifySize
+
+	size, err := nvme.readBlockSize(filepath.Join(sysBlockPath, name))
+	if err != nil {
+		return false, err
+	}
+	capacity.NewSize = size
+	converged := size == identifySize
+
+	paths, _ := filepath.Glob(filepath.Join(nvme.hostPath(sysBlockPath), name, "multipath", "*"))
+	capacity.PathSizes = make(map[string]uint64, len(paths))
+	for _, p := range paths {
+		pathSize, err := nvme.readBlockSize(filepath.Join(sysBlockPath, name, "multipath", filepath.Base(p)))
+		if err != nil {
+			return false, err
+		}
+		capacity.PathSizes[filepath.Base(p)] = pathSize
+		if pathSize != identifySize {
+			converged = false
+		}
+	}
+	return converged, nil
+}
+
+// namespaceControllers returns the controllers of the subsystem owning the namespace device.
+// A namespace which is not part of a multipath subsystem belongs to its parent controller only.
+func (nvme *NVMe) namespaceControllers(name string) []string {
+	heads, _ := filepath.Glob(filepath.Join(nvme.hostPath(nvmeSubsystemClassPath), "*", name))
+	if len(heads) > 0 {
+		entries, err := os.ReadDir(filepath.Dir(heads[0]))
+		if err == nil {
+			var controllers []string
+			for _, entry := range entries {
+				if controllerNameRegexp.MatchString(entry.Name()) {
+					controllers = append(controllers, entry.Name())
+				}
+			}
+			if len(controllers) > 0 {
+				return controllers
+			}
+		}
+	}
+	return []string{controllerFromNamespace(name)}
+}
+
+// readBlockSize returns the size in bytes of the block device sysfs directory, which reports 512 byte sectors
+func (nvme *NVMe) readBlockSize(dir string) (uint64, error) {
+	data, err := os.ReadFile(filepath.Clean(filepath.Join(nvme.hostPath(dir), "size")))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read size of %s: %w", dir, err)
+	}
+	sectors, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size of %s: %w", dir, err)
+	}
+	return sectors * 512, nil
+}
+
+// getIdentifyNamespaceSize returns nsze multiplied by the block size of the LBA format in use
+func (nvme *NVMe) getIdentifyNamespaceSize(device string) (uint64, error) {
+	exe := []string{nvme.NVMeCommand, "id-ns", device}
+	output, err := nvme.output(exe)
+	if err != nil {
+		return 0, err
+	}
+	return parseIdentifyNamespaceSize(output)
+}
+
+// parseIdentifyNamespaceSize extracts the namespace size in bytes from nvme id-ns output:
+//
+//	nsze    : 0x1000000
+//	...
+//	lbaf  0 : ms:0   lbads:9  rp:0 (in use)
+func parseIdentifyNamespaceSize(output []byte) (uint64, error) {
+	var nsze uint64
+	var lbads uint64
+	foundNsze := false
+
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.HasPrefix(line, "nsze") {
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			value, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 0, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid nsze %q: %w", line, err)
+			}
+			nsze = value
+			foundNsze = true
+		}
+		if match := lbafInUseRegexp.FindStringSubmatch(line); match != nil {
+			value, err := strconv.ParseUint(match[1], 10, 8)
+			if err != nil {
+				return 0, fmt.Errorf("invalid lbads %q: %w", line, err)
+			}
+			lbads = value
+		}
+	}
+
+	if !foundNsze || lbads == 0 {
+		return 0, fmt.Errorf("nsze or LBA format in use not found in nvme id-ns output")
+	}
+	return nsze << lbads, nil
+}
+
+// hostPath returns the path p of the host, as seen from the configured host root
+func (nvme *NVMe) hostPath(p string) string {
+	if nvme.hostRoot() == "/" {
+		return p
+	}
+	return filepath.Join(nvme.hostRoot(), p)
+}
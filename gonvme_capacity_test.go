@@ -0,0 +1,164 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const idNamespaceOutput = `NVME Identify Namespace 1:
+nsze    : 0x200000
+ncap    : 0x200000
+nuse    : 0x223b8
+flbas   : 0
+lbaf  0 : ms:0   lbads:9  rp:0 (in use)
+lbaf  1 : ms:0   lbads:12 rp:0
+`
+
+// setupCapacitySysfs builds a fake sysfs with a multipath namespace nvme0n1 reached through nvme0 and nvme1
+func setupCapacitySysfs(t *testing.T, sectors string) string {
+	root := t.TempDir()
+	files := []string{
+		"block/nvme0n1/size",
+		"block/nvme0n1/multipath/nvme0c0n1/size",
+		"block/nvme0n1/multipath/nvme0c1n1/size",
+	}
+	for _, f := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, filepath.Dir(f)), 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(root, f), []byte(sectors+"\n"), 0o600))
+	}
+	for _, d := range []string{"nvme0", "nvme1", "nvme0n1"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, "nvme-subsystem", "nvme-subsys0", d), 0o755))
+	}
+
+	originalBlock, originalSubsystem, originalInterval := sysBlockPath, nvmeSubsystemClassPath, capacityPollInterval
+	sysBlockPath = filepath.Join(root, "block")
+	nvmeSubsystemClassPath = filepath.Join(root, "nvme-subsystem")
+	capacityPollInterval = time.Millisecond
+	t.Cleanup(func() {
+		sysBlockPath, nvmeSubsystemClassPath, capacityPollInterval = originalBlock, originalSubsystem, originalInterval
+	})
+	return root
+}
+
+func TestRefreshNamespaceCapacity(t *testing.T) {
+	root := setupCapacitySysfs(t, "1048576")
+
+	var mu sync.Mutex
+	var rescanned []string
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		mu.Lock()
+		defer mu.Unlock()
+		if args[0] == "ns-rescan" {
+			rescanned = append(rescanned, args[1])
+			// the kernel picks up the new size once every path has been rescanned
+			if len(rescanned) == 2 {
+				for _, f := range []string{"nvme0n1/size", "nvme0n1/multipath/nvme0c0n1/size", "nvme0n1/multipath/nvme0c1n1/size"} {
+					_ = os.WriteFile(filepath.Join(root, "block", f), []byte("2097152\n"), 0o600)
+				}
+			}
+			return mockCommand{}
+		}
+		return mockCommand{out: []byte(idNamespaceOutput)}
+	})
+	defer func() { defaultExecutor = originalExecutor }()
+
+	nvme := NewNVMe(nil)
+	capacity, err := nvme.RefreshNamespaceCapacity(context.Background(), "/dev/nvme0n1")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/dev/nvme0", "/dev/nvme1"}, rescanned)
+	assert.Equal(t, NamespaceCapacity{
+		Device:       "/dev/nvme0n1",
+		OldSize:      536870912,
+		NewSize:      1073741824,
+		IdentifySize: 1073741824,
+		PathSizes: map[string]uint64{
+			"nvme0c0n1": 1073741824,
+			"nvme0c1n1": 1073741824,
+		},
+	}, capacity)
+}
+
+func TestRefreshNamespaceCapacityTimeout(t *testing.T) {
+	setupCapacitySysfs(t, "1048576")
+
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		return mockCommand{out: []byte(idNamespaceOutput)}
+	})
+	defer func() { defaultExecutor = originalExecutor }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	nvme := NewNVMe(nil)
+	capacity, err := nvme.RefreshNamespaceCapacity(ctx, "nvme0n1")
+	assert.True(t, errors.Is(err, ErrCapacityNotConverged))
+	assert.Equal(t, uint64(536870912), capacity.NewSize)
+	assert.Equal(t, uint64(1073741824), capacity.IdentifySize)
+}
+
+func TestRefreshNamespaceCapacityErrors(t *testing.T) {
+	setupCapacitySysfs(t, "1048576")
+
+	originalExecutor := defaultExecutor
+	defer func() { defaultExecutor = originalExecutor }()
+	nvme := NewNVMe(nil)
+
+	// unknown device
+	_, err := nvme.RefreshNamespaceCapacity(context.Background(), "nvme9n9")
+	assert.Error(t, err)
+
+	// every rescan fails
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		return mockCommand{outErr: errors.New("rescan failed")}
+	})
+	_, err = nvme.RefreshNamespaceCapacity(context.Background(), "nvme0n1")
+	var ctrlErrs ControllerErrors
+	assert.True(t, errors.As(err, &ctrlErrs))
+	assert.Len(t, ctrlErrs, 2)
+}
+
+func TestParseIdentifyNamespaceSize(t *testing.T) {
+	size, err := parseIdentifyNamespaceSize([]byte(idNamespaceOutput))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1073741824), size)
+
+	_, err = parseIdentifyNamespaceSize([]byte("nsze    : 0x200000\n"))
+	assert.Error(t, err)
+
+	_, err = parseIdentifyNamespaceSize([]byte("nsze    : size\n"))
+	assert.Error(t, err)
+}
+
+func TestNamespaceControllers(t *testing.T) {
+	setupCapacitySysfs(t, "1")
+	nvme := NewNVMe(nil)
+
+	assert.Equal(t, []string{"nvme0", "nvme1"}, nvme.namespaceControllers("nvme0n1"))
+	// not part of a multipath subsystem
+	assert.Equal(t, []string{"nvme3"}, nvme.namespaceControllers("nvme3n2"))
+}
@@ -0,0 +1,212 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ControllerTimeoutOff is the value of a controller timeout which is disabled, "off" in sysfs
+const ControllerTimeoutOff = -1
+
+// Controller holds the sysfs attributes of an NVMe controller, /sys/class/nvme/nvmeN.
+// Attributes which do not exist for the transport, the fabrics timeouts of a PCIe controller for instance, are left unset.
+type Controller struct {
+	Name         string
+	State        NVMESessionState
+	Cntlid       uint16
+	SubsysNQN    string
+	ModelNumber  string
+	SerialNumber string
+	Firmware     string
+	Transport    NVMETransportName
+	// Address is the raw controller address, TrAddr, TrSvcID and HostAddress being parsed out of it
+	Address     string
+	TrAddr      string
+	TrSvcID     string
+	HostAddress string
+	QueueCount  int
+	SQSize      int
+	// KATO is the keep alive timeout in seconds
+	KATO int
+	// ReconnectDelay, CtrlLossTmo and FastIOFailTmo are in seconds, ControllerTimeoutOff when disabled
+	ReconnectDelay int
+	CtrlLossTmo    int
+	FastIOFailTmo  int
+	NumaNode       int
+}
+
+// GetController returns the sysfs attributes of the controller, given as nvme0 or /dev/nvme0
+func (nvme *NVMe) GetController(name string) (Controller, error) {
+	name = path.Base(name)
+	if !controllerNameRegexp.MatchString(name) {
+		return Controller{}, fmt.Errorf("invalid controller name %q", name)
+	}
+
+	dir := filepath.Join(nvme.hostPath(nvmeClassPath), name)
+	if _, err := os.Stat(dir); err != nil {
+		return Controller{}, fmt.Errorf("controller %s not found: %w", name, err)
+	}
+	return readController(dir, name)
+}
+
+// ListControllers returns the sysfs attributes of every NVMe controller, sorted by name.
+// Controllers removed while listing are skipped. The controllers which cannot be read are left out
+// and named in a ControllerErrors, returned along with the controllers read.
+func (nvme *NVMe) ListControllers() ([]Controller, error) {
+	entries, err := os.ReadDir(nvme.hostPath(nvmeClassPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return []Controller{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	controllers := []Controller{}
+	failed := ControllerErrors{}
+	for _, entry := range entries {
+		if !controllerNameRegexp.MatchString(entry.Name()) {
+			continue
+		}
+		controller, err := readController(filepath.Join(nvme.hostPath(nvmeClassPath), entry.Name()), entry.Name())
+		if errors.Is(err, os.ErrNotExist) {
+			nvme.log().With(controllerField(entry.Name())).Debugf("Controller removed while listing")
+			continue
+		}
+		if err != nil {
+			failed[entry.Name()] = err
+			continue
+		}
+		controllers = append(controllers, controller)
+	}
+	sort.Slice(controllers, func(i, j int) bool {
+		return controllerIndex(controllers[i].Name) < controllerIndex(controllers[j].Name)
+	})
+
+	if len(failed) > 0 {
+		return controllers, failed
+	}
+	return controllers, nil
+}
+
+// readController reads the attributes of the controller sysfs directory
+func readController(dir string, name string) (Controller, error) {
+	attrs := controllerAttributes{dir: dir}
+	controller := Controller{
+		Name:           name,
+		State:          NVMESessionState(attrs.string("state")),
+		SubsysNQN:      attrs.string("subsysnqn"),
+		ModelNumber:    attrs.string("model"),
+		SerialNumber:   attrs.string("serial"),
+		Firmware:       attrs.string("firmware_rev"),
+		Transport:      NVMETransportName(attrs.string("transport")),
+		Address:        attrs.string("address"),
+		Cntlid:         uint16(attrs.int("cntlid")),
+		QueueCount:     attrs.int("queue_count"),
+		SQSize:         attrs.int("sqsize"),
+		KATO:           attrs.int("kato"),
+		ReconnectDelay: attrs.timeout("reconnect_delay"),
+		CtrlLossTmo:    attrs.timeout("ctrl_loss_tmo"),
+		FastIOFailTmo:  attrs.timeout("fast_io_fail_tmo"),
+		NumaNode:       attrs.int("numa_node"),
+	}
+	if attrs.err != nil {
+		return Controller{}, fmt.Errorf("failed to read controller %s: %w", name, attrs.err)
+	}
+	if controller.State == "" {
+		// sysfs always has the state of a controller, unless it is being removed
+		if _, err := os.Stat(filepath.Join(dir, "state")); errors.Is(err, os.ErrNotExist) {
+			return Controller{}, fmt.Errorf("controller %s not found: %w", name, err)
+		}
+		return Controller{}, fmt.Errorf("failed to read controller %s: no state", name)
+	}
+
+	controller.TrAddr = addressField(controller.Address, "traddr")
+	controller.TrSvcID = addressField(controller.Address, "trsvcid")
+	controller.HostAddress = addressField(controller.Address, "host_traddr")
+	if controller.HostAddress == "" {
+		controller.HostAddress = addressField(controller.Address, "src_addr")
+	}
+	return controller, nil
+}
+
+// controllerAttributes reads sysfs attributes, keeping the first parse error.
+// Missing attributes read as empty, sysfs only exposing those supported by the transport.
+type controllerAttributes struct {
+	dir string
+	err error
+}
+
+func (a *controllerAttributes) string(attr string) string {
+	data, err := os.ReadFile(filepath.Clean(filepath.Join(a.dir, attr)))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) && a.err == nil {
+			a.err = err
+		}
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func (a *controllerAttributes) int(attr string) int {
+	return a.parseInt(attr, a.string(attr))
+}
+
+func (a *controllerAttributes) timeout(attr string) int {
+	value := a.string(attr)
+	if value == "off" {
+		return ControllerTimeoutOff
+	}
+	return a.parseInt(attr, value)
+}
+
+func (a *controllerAttributes) parseInt(attr string, value string) int {
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil && a.err == nil {
+		a.err = fmt.Errorf("invalid %s %q: %w", attr, value, err)
+	}
+	return n
+}
+
+// controllerIndex returns N of nvmeN, used to sort nvme10 after nvme9
+func controllerIndex(name string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(name, "nvme"))
+	return n
+}
+
+// writeSysfsAttribute writes value to an existing sysfs attribute
+func writeSysfsAttribute(file string, value string) error {
+	f, err := os.OpenFile(filepath.Clean(file), os.O_WRONLY|os.O_TRUNC, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(value); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write %q to %s: %w", value, file, err)
+	}
+	return f.Close()
+}
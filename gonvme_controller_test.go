@@ -0,0 +1,213 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSysfsTestNVMe returns an NVMe chrooted in testdata/sysfs, which holds a fake /sys/class/nvme
+func newSysfsTestNVMe(t *testing.T) *NVMe {
+	root, err := filepath.Abs("testdata/sysfs")
+	assert.NoError(t, err)
+	return NewNVMe(map[string]string{ChrootDirectory: root})
+}
+
+// newWritableSysfsTestNVMe returns an NVMe chrooted in a copy of testdata/sysfs which tests may modify
+func newWritableSysfsTestNVMe(t *testing.T) (*NVMe, string) {
+	root := t.TempDir()
+	err := filepath.WalkDir("testdata/sysfs", func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel("testdata/sysfs", p)
+		target := filepath.Join(root, rel)
+		switch {
+		case d.Type()&os.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.IsDir():
+			return os.MkdirAll(target, 0o755)
+		default:
+			data, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(target, data, 0o600)
+		}
+	})
+	assert.NoError(t, err)
+	return NewNVMe(map[string]string{ChrootDirectory: root}), root
+}
+
+func TestGetController(t *testing.T) {
+	nvme := newSysfsTestNVMe(t)
+
+	tests := []struct {
+		name    string
+		want    Controller
+		wantErr bool
+	}{
+		{
+			name: "nvme0",
+			want: Controller{
+				Name:           "nvme0",
+				State:          NVMESessionStateLive,
+				Cntlid:         1,
+				SubsysNQN:      "nqn.1988-11.com.dell:powerstore:00:1a1111a1111aAA11111A",
+				ModelNumber:    "PowerStore",
+				SerialNumber:   "1a1111a1111aAA11",
+				Firmware:       "3.0.0.0",
+				Transport:      NVMETransportNameTCP,
+				Address:        "traddr=10.1.1.1,trsvcid=4420,src_addr=10.1.1.10",
+				TrAddr:         "10.1.1.1",
+				TrSvcID:        "4420",
+				HostAddress:    "10.1.1.10",
+				QueueCount:     9,
+				SQSize:         127,
+				KATO:           5,
+				ReconnectDelay: 10,
+				CtrlLossTmo:    600,
+				FastIOFailTmo:  ControllerTimeoutOff,
+				NumaNode:       -1,
+			},
+		},
+		{
+			name: "/dev/nvme1",
+			want: Controller{
+				Name:           "nvme1",
+				State:          NVMESessionStateResetting,
+				Cntlid:         2,
+				SubsysNQN:      "nqn.1988-11.com.dell:powermax:00:000120001647",
+				ModelNumber:    "PowerMax_8500",
+				SerialNumber:   "000120001647",
+				Firmware:       "6079",
+				Transport:      NVMETransportNameFC,
+				Address:        "traddr=nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf,host_traddr=nn-0x200000109b6460e1:pn-0x100000109b6460e1",
+				TrAddr:         "nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf",
+				HostAddress:    "nn-0x200000109b6460e1:pn-0x100000109b6460e1",
+				QueueCount:     33,
+				SQSize:         31,
+				KATO:           10,
+				ReconnectDelay: 2,
+				CtrlLossTmo:    ControllerTimeoutOff,
+				FastIOFailTmo:  5,
+				NumaNode:       1,
+			},
+		},
+		{
+			name: "nvme2",
+			want: Controller{
+				Name:         "nvme2",
+				State:        NVMESessionStateDead,
+				SubsysNQN:    "nqn.2014.08.org.nvmexpress:80868086PHKS7481008L375AGN",
+				ModelNumber:  "INTEL SSDPED1K375GA",
+				SerialNumber: "PHKS7481008L375AGN",
+				Firmware:     "E2010325",
+				Transport:    "pcie",
+				Address:      "0000:5e:00.0",
+				QueueCount:   41,
+				SQSize:       1023,
+			},
+		},
+		{name: "nvme3", wantErr: true},
+		{name: "nvme-fabrics", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nvme.GetController(tt.name)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestListControllers(t *testing.T) {
+	controllers, err := newSysfsTestNVMe(t).ListControllers()
+	assert.NoError(t, err)
+
+	var names []string
+	for _, controller := range controllers {
+		names = append(names, controller.Name)
+	}
+	assert.Equal(t, []string{"nvme0", "nvme1", "nvme2", "nvme10"}, names)
+	assert.Equal(t, NVMESessionStateConnecting, controllers[3].State)
+
+	// no NVMe controller on the host
+	controllers, err = NewNVMe(map[string]string{ChrootDirectory: t.TempDir()}).ListControllers()
+	assert.NoError(t, err)
+	assert.Empty(t, controllers)
+}
+
+func TestListControllersPartial(t *testing.T) {
+	nvme, root := newWritableSysfsTestNVMe(t)
+	// nvme1 is being deleted, its attributes already gone
+	assert.NoError(t, os.Remove(filepath.Join(root, "sys/class/nvme/nvme1/state")))
+	assert.NoError(t, os.WriteFile(filepath.Join(root, "sys/class/nvme/nvme2/queue_count"), []byte("many\n"), 0o600))
+
+	controllers, err := nvme.ListControllers()
+	var ctrlErrs ControllerErrors
+	assert.True(t, errors.As(err, &ctrlErrs))
+	assert.Len(t, ctrlErrs, 1)
+	assert.ErrorContains(t, ctrlErrs["nvme2"], "invalid queue_count")
+
+	var names []string
+	for _, controller := range controllers {
+		names = append(names, controller.Name)
+	}
+	assert.Equal(t, []string{"nvme0", "nvme10"}, names)
+
+	_, err = nvme.GetController("nvme1")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestReadControllerErrors(t *testing.T) {
+	write := func(t *testing.T, dir string, attrs map[string]string) {
+		for attr, value := range attrs {
+			assert.NoError(t, os.WriteFile(filepath.Join(dir, attr), []byte(value+"\n"), 0o600))
+		}
+	}
+
+	tests := []struct {
+		name  string
+		attrs map[string]string
+	}{
+		{"no state", map[string]string{"transport": "tcp"}},
+		{"invalid queue count", map[string]string{"state": "live", "queue_count": "many"}},
+		{"invalid ctrl_loss_tmo", map[string]string{"state": "live", "ctrl_loss_tmo": "never"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			write(t, dir, tt.attrs)
+			_, err := readController(dir, "nvme0")
+			assert.Error(t, err)
+		})
+	}
+}
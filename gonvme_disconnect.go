@@ -0,0 +1,381 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DisconnectPolicy controls what SafeDisconnect does when namespaces of the target are in use
+type DisconnectPolicy string
+
+const (
+	// DisconnectRefuseInUse refuses to disconnect while a namespace of the target is mounted, held or open
+	DisconnectRefuseInUse DisconnectPolicy = "refuse"
+	// DisconnectFlush flushes the namespaces of the target and disconnects even if they are in use
+	DisconnectFlush DisconnectPolicy = "flush"
+)
+
+// DeviceUserKind describes how a namespace device is in use
+type DeviceUserKind string
+
+const (
+	// DeviceUserMount indicates a mounted filesystem
+	DeviceUserMount DeviceUserKind = "mount"
+	// DeviceUserHolder indicates a stacked device such as dm, md or LVM
+	DeviceUserHolder DeviceUserKind = "holder"
+	// DeviceUserOpenFile indicates a process holding the device open
+	DeviceUserOpenFile DeviceUserKind = "open"
+)
+
+// DeviceUser is a user of a namespace device found by SafeDisconnect
+type DeviceUser struct {
+	Device string
+	Kind   DeviceUserKind
+	Detail string
+}
+
+// DeviceInUseError is returned by SafeDisconnect when it refuses to disconnect a target in use
+type DeviceInUseError struct {
+	TargetNqn string
+	Users     []DeviceUser
+}
+
+func (e *DeviceInUseError) Error() string {
+	users := make([]string, 0, len(e.Users))
+	for _, u := range e.Users {
+		users = append(users, fmt.Sprintf("%s %s %s", u.Device, u.Kind, u.Detail))
+	}
+	return fmt.Sprintf("namespaces of %s are in use: %s", e.TargetNqn, strings.Join(users, ", "))
+}
+
+var (
+	mountInfoPath = "/proc/self/mountinfo"
+	procPath      = "/proc"
+
+	namespaceNameRegexp = regexp.MustCompile(`^nvme[0-9]+n[0-9]+$`)
+)
+
+// syncDevice flushes the dirty buffers of a block device
+var syncDevice = func(path string) error {
+	f, err := os.OpenFile(filepath.Clean(path), os.O_RDONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close() // #nosec G307
+	return f.Sync()
+}
+
+// SessionFilter selects NVMe sessions; empty fields match any session
+type SessionFilter struct {
+	TargetNqn string
+	// Portal matches the session portal, with or without the port for TCP
+	Portal      string
+	HostAddress string
+	Transport   NVMETransportName
+	State       NVMESessionState
+}
+
+// Match reports whether the session is selected by the filter
+func (f SessionFilter) Match(session NVMESession) bool {
+	if f.TargetNqn != "" && f.TargetNqn != session.Target {
+		return false
+	}
+	if f.Portal != "" && f.Portal != session.Portal && !strings.HasPrefix(session.Portal, f.Portal+":") {
+		return false
+	}
+	if f.HostAddress != "" && f.HostAddress != session.HostAddress {
+		return false
+	}
+	if f.Transport != "" && f.Transport != session.NVMETransportName {
+		return false
+	}
+	if f.State != "" && f.State != session.NVMESessionState {
+		return false
+	}
+	return true
+}
+
+// DisconnectController will attempt to disconnect a single controller, e.g. nvme3, leaving the other paths of its subsystem connected
+func (nvme *NVMe) DisconnectController(name string) error {
+	// nvme disconnect -d <controller>
+	ctrl := filepath.Base(name)
+	exe := []string{nvme.NVMeCommand, "disconnect", "-d", ctrl}
+	_, err := nvme.output(exe)
+	log := nvme.logOperation("disconnect", controllerField(ctrl))
+	if err != nil {
+		log.Errorf("NVMe disconnect failed: %v", err)
+		return err
+	}
+	log.Infof("NVMe disconnect successful")
+	return nil
+}
+
+// DisconnectPath disconnects the controllers of the subsystem NQN reached through the portal,
+// and through hostAddr if it is not empty. It returns the controllers which were disconnected.
+func (nvme *NVMe) DisconnectPath(nqn string, portal string, hostAddr string) ([]string, error) {
+	if nqn == "" || portal == "" {
+		return nil, fmt.Errorf("both the target NQN and the portal are required to disconnect a path")
+	}
+	return nvme.DisconnectAll(SessionFilter{TargetNqn: nqn, Portal: portal, HostAddress: hostAddr})
+}
+
+// DisconnectAll disconnects every controller whose session matches the filter, skipping those
+// already being deleted. It returns the controllers which were disconnected, failing with a ControllerErrors.
+func (nvme *NVMe) DisconnectAll(filter SessionFilter) ([]string, error) {
+	sessions, err := nvme.GetSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	var disconnected []string
+	failed := ControllerErrors{}
+	for _, session := range sessions {
+		if !filter.Match(session) || session.NVMESessionState == NVMESessionStateDeleting {
+			continue
+		}
+		if err := nvme.DisconnectController(session.Name); err != nil {
+			failed[session.Name] = err
+			continue
+		}
+		disconnected = append(disconnected, session.Name)
+	}
+
+	if len(failed) > 0 {
+		return disconnected, failed
+	}
+	return disconnected, nil
+}
+
+// SafeDisconnect disconnects from the target after making sure none of its namespaces is
+// mounted, held by a stacked device or open by a process. With DisconnectRefuseInUse a
+// DeviceInUseError listing the users is returned instead; with DisconnectFlush the target is
+// disconnected regardless. The namespaces are flushed before disconnecting in both cases.
+// The target is not disconnected when its namespaces cannot be listed from sysfs.
+func (nvme *NVMe) SafeDisconnect(ctx context.Context, target NVMeTarget, policy DisconnectPolicy) error {
+	namespaces, err := nvme.subsystemNamespaces(target.TargetNqn)
+	if err != nil {
+		return err
+	}
+
+	users, err := nvme.namespaceUsers(ctx, namespaces)
+	if err != nil {
+		return err
+	}
+	if len(users) > 0 {
+		if policy != DisconnectFlush {
+			return &DeviceInUseError{TargetNqn: target.TargetNqn, Users: users}
+		}
+		nvme.logOperation("disconnect", targetFields(target)...).WithContext(ctx).Warnf("Disconnecting although the namespaces are in use: %v", users)
+	}
+
+	for _, ns := range namespaces {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := syncDevice(nvme.hostPath(devPath(ns))); err != nil {
+			return fmt.Errorf("failed to flush %s before disconnecting %s: %w", devPath(ns), target.TargetNqn, err)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return nvme.NVMeDisconnect(target)
+}
+
+// subsystemNamespaces returns the namespace block devices (e.g. nvme0n1) of the subsystem NQN,
+// both multipath heads and namespaces attached to a single controller. An error is returned when
+// the sysfs directory of the subsystem or of one of its controllers cannot be read, as the
+// namespaces found would not be all of them.
+func (nvme *NVMe) subsystemNamespaces(nqn string) ([]string, error) {
+	controllers, err := nvme.subsystemControllers(nqn)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the controllers of %s: %w", nqn, err)
+	}
+
+	seen := map[string]bool{}
+	var namespaces []string
+	add := func(dir string) error {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return fmt.Errorf("unable to list the namespaces of %s: %w", nqn, err)
+		}
+		for _, entry := range entries {
+			if namespaceNameRegexp.MatchString(entry.Name()) && !seen[entry.Name()] {
+				seen[entry.Name()] = true
+				namespaces = append(namespaces, entry.Name())
+			}
+		}
+		return nil
+	}
+
+	subsystems, err := filepath.Glob(filepath.Join(nvme.hostPath(nvmeSubsystemClassPath), "*"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the namespaces of %s: %w", nqn, err)
+	}
+	for _, subsystem := range subsystems {
+		data, err := os.ReadFile(filepath.Clean(filepath.Join(subsystem, "subsysnqn")))
+		if err == nil && strings.TrimSpace(string(data)) == nqn {
+			if err := add(subsystem); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, ctrl := range controllers {
+		if err := add(filepath.Join(nvme.hostPath(nvmeClassPath), ctrl)); err != nil {
+			return nil, err
+		}
+	}
+	return namespaces, nil
+}
+
+// namespaceUsers returns the mounts, holders and open file handles of the namespaces and their partitions
+func (nvme *NVMe) namespaceUsers(ctx context.Context, namespaces []string) ([]DeviceUser, error) {
+	var users []DeviceUser
+	// block device path -> major:minor, for each namespace and partition
+	devices := map[string]string{}
+
+	for _, ns := range namespaces {
+		dirs, err := filepath.Glob(filepath.Join(nvme.hostPath(sysBlockPath), ns, ns+"p*"))
+		if err != nil {
+			return nil, fmt.Errorf("unable to list partitions of %s: %w", devPath(ns), err)
+		}
+		dirs = append([]string{filepath.Join(nvme.hostPath(sysBlockPath), ns)}, dirs...)
+		for _, dir := range dirs {
+			device := devPath(filepath.Base(dir))
+			data, err := os.ReadFile(filepath.Clean(filepath.Join(dir, "dev")))
+			if err != nil {
+				return nil, fmt.Errorf("unable to read device number of %s: %w", device, err)
+			}
+			devices[device] = strings.TrimSpace(string(data))
+
+			holders, err := os.ReadDir(filepath.Join(dir, "holders"))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("unable to read holders of %s: %w", device, err)
+			}
+			for _, holder := range holders {
+				detail := holder.Name()
+				// device mapper holders (dm, LVM) carry a friendlier name
+				name, err := os.ReadFile(filepath.Clean(filepath.Join(nvme.hostPath(sysBlockPath), holder.Name(), "dm", "name")))
+				if err == nil {
+					detail = fmt.Sprintf("%s (%s)", holder.Name(), strings.TrimSpace(string(name)))
+				}
+				users = append(users, DeviceUser{Device: device, Kind: DeviceUserHolder, Detail: detail})
+			}
+		}
+	}
+	if len(devices) == 0 {
+		return users, nil
+	}
+
+	mounts, err := nvme.mountUsers(devices)
+	if err != nil {
+		return nil, err
+	}
+	users = append(users, mounts...)
+
+	open, err := nvme.openFileUsers(ctx, devices)
+	if err != nil {
+		return nil, err
+	}
+	return append(users, open...), nil
+}
+
+// mountInfo returns the mountinfo of the host: the mounts of the NsenterTarget process with ExecutorNsenter,
+// since /proc/self under its root would still be this process, and mountInfoPath under the host root otherwise
+func (nvme *NVMe) mountInfo() string {
+	if nvme.options[ExecutorOption] == ExecutorNsenter {
+		return filepath.Join(procPath, strconv.Itoa(nvme.nsenterTarget()), "mountinfo")
+	}
+	return nvme.hostPath(mountInfoPath)
+}
+
+// mountUsers returns the mount points of the devices, matched on major:minor in mountinfo:
+//
+//	36 35 259:1 / /mnt/vol rw,noatime shared:1 - xfs /dev/nvme0n1 rw
+func (nvme *NVMe) mountUsers(devices map[string]string) ([]DeviceUser, error) {
+	byNumber := make(map[string]string, len(devices))
+	for device, number := range devices {
+		byNumber[number] = device
+	}
+
+	f, err := os.Open(filepath.Clean(nvme.mountInfo()))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read mounts: %w", err)
+	}
+	defer f.Close() // #nosec G307
+
+	var users []DeviceUser
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		if device, ok := byNumber[fields[2]]; ok {
+			users = append(users, DeviceUser{Device: device, Kind: DeviceUserMount, Detail: fields[4]})
+		}
+	}
+	return users, scanner.Err()
+}
+
+// openFileUsers returns the processes holding one of the devices open
+func (nvme *NVMe) openFileUsers(ctx context.Context, devices map[string]string) ([]DeviceUser, error) {
+	pids, err := os.ReadDir(procPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list processes: %w", err)
+	}
+
+	var users []DeviceUser
+	for _, pid := range pids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if !pid.IsDir() || strings.Trim(pid.Name(), "0123456789") != "" {
+			continue
+		}
+		// processes may exit or deny access while being inspected
+		fds, err := os.ReadDir(filepath.Join(procPath, pid.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(procPath, pid.Name(), "fd", fd.Name()))
+			if err != nil {
+				continue
+			}
+			if _, ok := devices[link]; !ok {
+				continue
+			}
+			detail := "pid " + pid.Name()
+			if comm, err := os.ReadFile(filepath.Clean(filepath.Join(procPath, pid.Name(), "comm"))); err == nil {
+				detail = fmt.Sprintf("%s (%s)", detail, strings.TrimSpace(string(comm)))
+			}
+			users = append(users, DeviceUser{Device: link, Kind: DeviceUserOpenFile, Detail: detail})
+		}
+	}
+	return users, nil
+}
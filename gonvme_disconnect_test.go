@@ -0,0 +1,323 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const disconnectTestNQN = "nqn.1988-11.com.dell:powerstore:00:1a1111a1111aAA11111A"
+
+type disconnectFixture struct {
+	root      string
+	synced    []string
+	commands  [][]string
+	mountInfo string
+}
+
+// setupDisconnectFixture builds a fake sysfs and procfs where nvme0n1 belongs to disconnectTestNQN.
+// When inUse is set its partition is mounted, held by a dm device and open by a process.
+func setupDisconnectFixture(t *testing.T, inUse bool) *disconnectFixture {
+	f := &disconnectFixture{root: t.TempDir()}
+	write := func(name, data string) {
+		p := filepath.Join(f.root, name)
+		assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		assert.NoError(t, os.WriteFile(p, []byte(data), 0o600))
+	}
+
+	write("sys/class/nvme-subsystem/nvme-subsys0/subsysnqn", disconnectTestNQN+"\n")
+	write("sys/class/nvme-subsystem/nvme-subsys0/nvme0/state", "live\n")
+	write("sys/class/nvme-subsystem/nvme-subsys0/nvme0n1/nsid", "1\n")
+	write("sys/class/nvme/nvme0/nvme0c0n1/nsid", "1\n")
+	write("sys/block/nvme0n1/dev", "259:0\n")
+	write("sys/block/nvme0n1/nvme0n1p1/dev", "259:1\n")
+	write("proc/1/comm", "init\n")
+	write("proc/self/mountinfo", "22 1 253:0 / / rw,relatime shared:1 - xfs /dev/mapper/root rw\n")
+
+	if inUse {
+		write("sys/block/nvme0n1/nvme0n1p1/holders/dm-3/dev", "253:3\n")
+		write("sys/block/dm-3/dm/name", "vg0-lv0\n")
+		write("proc/self/mountinfo", "22 1 253:0 / / rw,relatime shared:1 - xfs /dev/mapper/root rw\n"+
+			"36 22 259:1 / /mnt/vol rw,noatime shared:2 - xfs /dev/nvme0n1p1 rw\n")
+		write("proc/123/comm", "fio\n")
+		assert.NoError(t, os.MkdirAll(filepath.Join(f.root, "proc/123/fd"), 0o755))
+		assert.NoError(t, os.Symlink("/dev/nvme0n1", filepath.Join(f.root, "proc/123/fd/3")))
+	}
+
+	originalSubsystem, originalClass, originalBlock := nvmeSubsystemClassPath, nvmeClassPath, sysBlockPath
+	originalMountInfo, originalProc, originalSync, originalExecutor := mountInfoPath, procPath, syncDevice, defaultExecutor
+	nvmeSubsystemClassPath = filepath.Join(f.root, "sys/class/nvme-subsystem")
+	nvmeClassPath = filepath.Join(f.root, "sys/class/nvme")
+	sysBlockPath = filepath.Join(f.root, "sys/block")
+	mountInfoPath = filepath.Join(f.root, "proc/self/mountinfo")
+	procPath = filepath.Join(f.root, "proc")
+	syncDevice = func(path string) error {
+		f.synced = append(f.synced, path)
+		return nil
+	}
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		f.commands = append(f.commands, args)
+		return mockCommand{}
+	})
+	t.Cleanup(func() {
+		nvmeSubsystemClassPath, nvmeClassPath, sysBlockPath = originalSubsystem, originalClass, originalBlock
+		mountInfoPath, procPath, syncDevice, defaultExecutor = originalMountInfo, originalProc, originalSync, originalExecutor
+	})
+	return f
+}
+
+func TestSafeDisconnectNotInUse(t *testing.T) {
+	f := setupDisconnectFixture(t, false)
+
+	nvme := NewNVMe(nil)
+	err := nvme.SafeDisconnect(context.Background(), NVMeTarget{TargetNqn: disconnectTestNQN}, DisconnectRefuseInUse)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/dev/nvme0n1"}, f.synced)
+	assert.Equal(t, [][]string{{"disconnect", "-n", disconnectTestNQN}}, f.commands)
+}
+
+func TestSafeDisconnectRefuse(t *testing.T) {
+	f := setupDisconnectFixture(t, true)
+
+	nvme := NewNVMe(nil)
+	err := nvme.SafeDisconnect(context.Background(), NVMeTarget{TargetNqn: disconnectTestNQN}, DisconnectRefuseInUse)
+
+	var inUse *DeviceInUseError
+	assert.True(t, errors.As(err, &inUse))
+	assert.Equal(t, disconnectTestNQN, inUse.TargetNqn)
+	assert.ElementsMatch(t, []DeviceUser{
+		{Device: "/dev/nvme0n1p1", Kind: DeviceUserHolder, Detail: "dm-3 (vg0-lv0)"},
+		{Device: "/dev/nvme0n1p1", Kind: DeviceUserMount, Detail: "/mnt/vol"},
+		{Device: "/dev/nvme0n1", Kind: DeviceUserOpenFile, Detail: "pid 123 (fio)"},
+	}, inUse.Users)
+	assert.Contains(t, err.Error(), "/mnt/vol")
+	assert.Empty(t, f.synced)
+	assert.Empty(t, f.commands)
+}
+
+func TestSafeDisconnectFlush(t *testing.T) {
+	f := setupDisconnectFixture(t, true)
+
+	nvme := NewNVMe(nil)
+	err := nvme.SafeDisconnect(context.Background(), NVMeTarget{TargetNqn: disconnectTestNQN}, DisconnectFlush)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/dev/nvme0n1"}, f.synced)
+	assert.Len(t, f.commands, 1)
+}
+
+func TestSafeDisconnectErrors(t *testing.T) {
+	setupDisconnectFixture(t, false)
+	nvme := NewNVMe(nil)
+	target := NVMeTarget{TargetNqn: disconnectTestNQN}
+
+	syncDevice = func(_ string) error { return errors.New("flush failed") }
+	err := nvme.SafeDisconnect(context.Background(), target, DisconnectRefuseInUse)
+	assert.ErrorContains(t, err, "flush failed")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = nvme.SafeDisconnect(ctx, target, DisconnectRefuseInUse)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	mountInfoPath = "testdata/bad/mountinfo"
+	err = nvme.SafeDisconnect(context.Background(), target, DisconnectRefuseInUse)
+	assert.ErrorContains(t, err, "unable to read mounts")
+}
+
+func TestSafeDisconnectUnreadableSysfs(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(root string) error
+	}{
+		{"controller directory missing", func(root string) error {
+			return os.RemoveAll(filepath.Join(root, "sys/class/nvme/nvme0"))
+		}},
+		{"controller directory not a directory", func(root string) error {
+			dir := filepath.Join(root, "sys/class/nvme/nvme0")
+			if err := os.RemoveAll(dir); err != nil {
+				return err
+			}
+			return os.WriteFile(dir, nil, 0o600)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := setupDisconnectFixture(t, false)
+			assert.NoError(t, tt.setup(f.root))
+
+			nvme := NewNVMe(nil)
+			err := nvme.SafeDisconnect(context.Background(), NVMeTarget{TargetNqn: disconnectTestNQN}, DisconnectFlush)
+			assert.ErrorContains(t, err, "unable to list the namespaces of "+disconnectTestNQN)
+			assert.Empty(t, f.synced)
+			assert.Empty(t, f.commands)
+		})
+	}
+}
+
+func TestMountInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		opts map[string]string
+		want string
+	}{
+		{"direct", nil, "/proc/self/mountinfo"},
+		{"chroot", map[string]string{ChrootDirectory: "/noderoot"}, "/noderoot/proc/self/mountinfo"},
+		{"nsenter", map[string]string{ExecutorOption: ExecutorNsenter, NsenterTarget: "42"}, "/proc/42/mountinfo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NewNVMe(tt.opts).mountInfo())
+		})
+	}
+}
+
+const disconnectTestSessions = `[
+  {
+    "HostNQN":"nqn.2014-08.org.nvmexpress:uuid:1a11111a-aa11-11aa-1111-a11aa1a11111",
+    "Subsystems":[
+      {
+        "Name":"nvme-subsys0",
+        "NQN":"nqn.1988-11.com.dell:powerstore:00:1a1111a1111aAA11111A",
+        "Paths":[
+          {"Name":"nvme0","Transport":"tcp","Address":"traddr=10.1.1.1,trsvcid=4420,src_addr=10.1.1.10","State":"live"},
+          {"Name":"nvme1","Transport":"tcp","Address":"traddr=10.1.1.2,trsvcid=4420,src_addr=10.1.1.10","State":"live"},
+          {"Name":"nvme2","Transport":"tcp","Address":"traddr=10.1.1.1,trsvcid=4420,src_addr=10.1.1.11","State":"connecting"},
+          {"Name":"nvme3","Transport":"tcp","Address":"traddr=10.1.1.2,trsvcid=4420,src_addr=10.1.1.11","State":"deleting"}
+        ]
+      },
+      {
+        "Name":"nvme-subsys1",
+        "NQN":"nqn.1988-11.com.dell:powermax:00:000120001647",
+        "Paths":[
+          {"Name":"nvme4","Transport":"fc","Address":"traddr=nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf host_traddr=nn-0x200000109b6460e1:pn-0x100000109b6460e1","State":"live"}
+        ]
+      }
+    ]
+  }
+]`
+
+// recordDisconnects replaces the default executor, answering list-subsys with disconnectTestSessions
+// and recording the controllers passed to nvme disconnect -d
+func recordDisconnects(t *testing.T, failing string) *[]string {
+	disconnected := []string{}
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		if args[0] == "list-subsys" {
+			return mockCommand{out: []byte(disconnectTestSessions)}
+		}
+		if args[len(args)-1] == failing {
+			return mockCommand{outErr: errors.New("disconnect failed")}
+		}
+		disconnected = append(disconnected, args[len(args)-1])
+		return mockCommand{}
+	})
+	t.Cleanup(func() { defaultExecutor = originalExecutor })
+	return &disconnected
+}
+
+func TestDisconnectController(t *testing.T) {
+	var gotArgs []string
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		gotArgs = args
+		return mockCommand{}
+	})
+	defer func() { defaultExecutor = originalExecutor }()
+
+	nvme := NewNVMe(nil)
+	assert.NoError(t, nvme.DisconnectController("/dev/nvme3"))
+	assert.Equal(t, []string{"disconnect", "-d", "nvme3"}, gotArgs)
+
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		return mockCommand{outErr: errors.New("error")}
+	})
+	assert.Error(t, nvme.DisconnectController("nvme3"))
+}
+
+func TestDisconnectPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		nqn      string
+		portal   string
+		hostAddr string
+		want     []string
+		wantErr  bool
+	}{
+		{"portal without port", disconnectTestNQN, "10.1.1.1", "", []string{"nvme0", "nvme2"}, false},
+		{"portal and host address", disconnectTestNQN, "10.1.1.1:4420", "10.1.1.11", []string{"nvme2"}, false},
+		{"deleting path is skipped", disconnectTestNQN, "10.1.1.2", "10.1.1.11", nil, false},
+		{"fc path", "nqn.1988-11.com.dell:powermax:00:000120001647", "nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf", "nn-0x200000109b6460e1:pn-0x100000109b6460e1", []string{"nvme4"}, false},
+		{"missing portal", disconnectTestNQN, "", "", nil, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			disconnected := recordDisconnects(t, "")
+			nvme := NewNVMe(nil)
+			got, err := nvme.DisconnectPath(tc.nqn, tc.portal, tc.hostAddr)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, len(tc.want), len(*disconnected))
+		})
+	}
+}
+
+func TestDisconnectAll(t *testing.T) {
+	recordDisconnects(t, "nvme1")
+	nvme := NewNVMe(nil)
+
+	got, err := nvme.DisconnectAll(SessionFilter{Transport: NVMETransportNameTCP})
+	assert.Equal(t, []string{"nvme0", "nvme2"}, got)
+	var ctrlErrs ControllerErrors
+	assert.ErrorAs(t, err, &ctrlErrs)
+	assert.Contains(t, ctrlErrs, "nvme1")
+
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		return mockCommand{outErr: errors.New("list-subsys failed")}
+	})
+	_, err = nvme.DisconnectAll(SessionFilter{})
+	assert.ErrorContains(t, err, "list-subsys failed")
+}
+
+func TestSessionFilterMatch(t *testing.T) {
+	session := NVMESession{
+		Target:            disconnectTestNQN,
+		Portal:            "10.1.1.1:4420",
+		Name:              "nvme0",
+		NVMESessionState:  NVMESessionStateLive,
+		NVMETransportName: NVMETransportNameTCP,
+		HostAddress:       "10.1.1.10",
+	}
+
+	assert.True(t, SessionFilter{}.Match(session))
+	assert.True(t, SessionFilter{Portal: "10.1.1.1"}.Match(session))
+	assert.False(t, SessionFilter{Portal: "10.1.1.10"}.Match(session))
+	assert.False(t, SessionFilter{TargetNqn: "nqn.other"}.Match(session))
+	assert.False(t, SessionFilter{HostAddress: "10.1.1.11"}.Match(session))
+	assert.False(t, SessionFilter{Transport: NVMETransportNameFC}.Match(session))
+	assert.False(t, SessionFilter{State: NVMESessionStateConnecting}.Match(session))
+}
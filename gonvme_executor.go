@@ -0,0 +1,389 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Executor runs the external commands of NVMe, nvme-cli for the most part
+type Executor interface {
+	// Run runs the command and returns its standard output and standard error. The error of a command
+	// which exited with a non zero status has an ExitCode() int method, as *exec.ExitError and *ExitError.
+	Run(name string, args ...string) (stdout []byte, stderr []byte, err error)
+}
+
+const (
+	// ExecutorOption selects how the nvme commands are run: ExecutorDirect, ExecutorChroot or ExecutorNsenter.
+	// It defaults to ExecutorChroot when ChrootDirectory is set and to ExecutorDirect otherwise.
+	ExecutorOption = "executor"
+
+	// ExecutorDirect runs the commands in the namespaces of the process
+	ExecutorDirect = "direct"
+
+	// ExecutorChroot runs the commands chrooted in ChrootDirectory, which holds the root of the host
+	ExecutorChroot = "chroot"
+
+	// ExecutorNsenter runs the commands in the mount and network namespaces of the NsenterTarget process,
+	// for the pods sharing the PID namespace of the host but not its root directory
+	ExecutorNsenter = "nsenter"
+
+	// NsenterTarget is the PID whose namespaces ExecutorNsenter enters, 1 by default
+	NsenterTarget = "nsenterTarget"
+)
+
+// defaultExecutor runs the commands of the NVMe clients without Executor
+var defaultExecutor Executor = CommandExecutor{}
+
+// CommandExecutor runs the commands on the local host with os/exec
+type CommandExecutor struct{}
+
+// Run runs the command with exec.Command
+func (CommandExecutor) Run(name string, args ...string) ([]byte, []byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(name, args...) // #nosec G204
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// ChrootExecutor runs the commands chrooted in a directory
+type ChrootExecutor struct {
+	// Dir is the new root of the commands, usually where the root of the host is mounted
+	Dir string
+}
+
+// Run runs the command with chroot, directly when Dir is the root directory
+func (e ChrootExecutor) Run(name string, args ...string) ([]byte, []byte, error) {
+	if e.Dir == "" || e.Dir == "/" {
+		return defaultExecutor.Run(name, args...)
+	}
+	return defaultExecutor.Run("chroot", append([]string{e.Dir, name}, args...)...)
+}
+
+// NsenterExecutor runs the commands in the mount and network namespaces of another process,
+// the init process of the host for a pod with hostPID
+type NsenterExecutor struct {
+	// Target is the PID of the process, 1 when not set
+	Target int
+}
+
+// Run runs the command with nsenter --target <pid> --mount --net
+func (e NsenterExecutor) Run(name string, args ...string) ([]byte, []byte, error) {
+	nsenter := []string{"--target", strconv.Itoa(e.target()), "--mount", "--net", "--", name}
+	return defaultExecutor.Run("nsenter", append(nsenter, args...)...)
+}
+
+func (e NsenterExecutor) target() int {
+	if e.Target <= 0 {
+		return 1
+	}
+	return e.Target
+}
+
+// ExecutorFunc is a function running the commands, for the executors of the caller
+type ExecutorFunc func(name string, args ...string) (stdout []byte, stderr []byte, err error)
+
+// Run calls the function
+func (f ExecutorFunc) Run(name string, args ...string) ([]byte, []byte, error) {
+	return f(name, args...)
+}
+
+// ExitError is the error of a replayed command which exited with a non zero status
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode returns the exit status of the command
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// exitCode returns the exit status of a command which exited with a non zero status
+func exitCode(err error) (int, bool) {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), true
+	}
+	return 0, false
+}
+
+// executor returns the executor of the client
+func (nvme *NVMe) executor() Executor {
+	if nvme.Executor != nil {
+		return nvme.Executor
+	}
+	return defaultExecutor
+}
+
+// optionExecutor returns the executor selected by the options, nil for the local host
+func (nvme *NVMe) optionExecutor() Executor {
+	switch executor := nvme.options[ExecutorOption]; executor {
+	case ExecutorDirect:
+		return nil
+	case ExecutorChroot:
+		return ChrootExecutor{Dir: nvme.getChrootDirectory()}
+	case ExecutorNsenter:
+		return NsenterExecutor{Target: nvme.nsenterTarget()}
+	case "":
+	default:
+		nvme.log().Errorf("Unknown executor %s, expected one of %s, %s and %s", executor, ExecutorDirect, ExecutorChroot, ExecutorNsenter)
+	}
+	if nvme.getChrootDirectory() != "/" {
+		return ChrootExecutor{Dir: nvme.getChrootDirectory()}
+	}
+	return nil
+}
+
+// nsenterTarget returns the PID of the NsenterTarget option, 1 when not set or invalid
+func (nvme *NVMe) nsenterTarget() int {
+	s := nvme.options[NsenterTarget]
+	if s == "" {
+		return 1
+	}
+	pid, err := strconv.Atoi(s)
+	if err != nil || pid <= 0 {
+		nvme.log().Errorf("Invalid %s %s, using 1", NsenterTarget, s)
+		return 1
+	}
+	return pid
+}
+
+// hostRoot returns the directory where the root of the host is seen: the root of the NsenterTarget
+// process with ExecutorNsenter and ChrootDirectory otherwise
+func (nvme *NVMe) hostRoot() string {
+	if nvme.options[ExecutorOption] == ExecutorNsenter {
+		return fmt.Sprintf("/proc/%d/root", nvme.nsenterTarget())
+	}
+	return nvme.getChrootDirectory()
+}
+
+// run runs the command line through the executor of the client, logging it with its duration
+func (nvme *NVMe) run(exe []string) ([]byte, []byte, error) {
+	start := time.Now()
+	stdout, stderr, err := nvme.executor().Run(exe[0], exe[1:]...)
+	log := nvme.log().With(
+		LogField{Key: logKeyCommand, Value: strings.Join(redactArgs(exe), " ")},
+		LogField{Key: logKeyDuration, Value: time.Since(start)})
+	if err != nil {
+		log.Debugf("Command failed: %v", err)
+	} else {
+		log.Debugf("Command succeeded")
+	}
+	return stdout, stderr, err
+}
+
+// output runs the command line through the executor of the client and returns its standard output
+func (nvme *NVMe) output(exe []string) ([]byte, error) {
+	stdout, _, err := nvme.run(exe)
+	return stdout, err
+}
+
+// Recording is a command run through a RecordingExecutor, one JSON object per line of the recording file
+type Recording struct {
+	Args   []string `json:"args"`
+	Stdout string   `json:"stdout"`
+	Stderr string   `json:"stderr"`
+	// ExitCode is the exit status of the command, -1 when it could not be run
+	ExitCode int `json:"exitCode"`
+	// Error is the error of a command which could not be run, a missing executable for instance
+	Error    string        `json:"error,omitempty"`
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// RecordingExecutor runs the commands through another executor and appends them, with their output,
+// exit code and duration, to a file which a ReplayExecutor can serve back. Secrets given as options,
+// the DH-HMAC-CHAP ones, are not recorded. Safe for concurrent use.
+type RecordingExecutor struct {
+	next Executor
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewRecordingExecutor returns an executor recording to the file the commands run through next,
+// the local host when next is nil. Recordings are appended to an existing file.
+func NewRecordingExecutor(next Executor, path string) (*RecordingExecutor, error) {
+	if next == nil {
+		next = defaultExecutor
+	}
+	file, err := os.OpenFile(filepath.Clean(path), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordingExecutor{next: next, file: file}, nil
+}
+
+// Run runs the command and records it. A failure to record is not reported to the caller.
+func (r *RecordingExecutor) Run(name string, args ...string) ([]byte, []byte, error) {
+	start := time.Now()
+	stdout, stderr, err := r.next.Run(name, args...)
+	recording := Recording{
+		Args:     redactArgs(append([]string{name}, args...)),
+		Stdout:   string(stdout),
+		Stderr:   string(stderr),
+		Start:    start,
+		Duration: time.Since(start),
+	}
+	if code, ok := exitCode(err); ok {
+		recording.ExitCode = code
+	} else if err != nil {
+		recording.ExitCode = -1
+		recording.Error = err.Error()
+	}
+
+	data, marshalErr := json.Marshal(recording)
+	if marshalErr == nil {
+		r.mu.Lock()
+		_, writeErr := r.file.Write(append(data, '\n'))
+		r.mu.Unlock()
+		marshalErr = writeErr
+	}
+	if marshalErr != nil {
+		packageLog().With(LogField{Key: logKeyCommand, Value: strings.Join(recording.Args, " ")}).Errorf("Failed to record command: %v", marshalErr)
+	}
+	return stdout, stderr, err
+}
+
+// Close closes the recording file
+func (r *RecordingExecutor) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// ErrNoRecording is returned by a ReplayExecutor for a command which was not recorded
+var ErrNoRecording = errors.New("no recording of the command")
+
+// ReplayExecutor serves the recordings of a RecordingExecutor instead of running the commands.
+// Commands are matched on their name and arguments, leaving out the chroot prefix and the directory
+// of the command, so that recordings replay on hosts where nvme-cli is installed elsewhere.
+// The recordings of a command are served in the order they were recorded, the last one being
+// served again once all were. Safe for concurrent use.
+type ReplayExecutor struct {
+	mu         sync.Mutex
+	recordings map[string][]Recording
+	served     map[string]int
+	// Delay makes the replayed commands take as long as the recorded ones
+	Delay bool
+}
+
+// NewReplayExecutor returns an executor replaying the recording file
+func NewReplayExecutor(path string) (*ReplayExecutor, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() // #nosec G307
+
+	replay := &ReplayExecutor{recordings: map[string][]Recording{}, served: map[string]int{}}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var recording Recording
+		if err := json.Unmarshal(scanner.Bytes(), &recording); err != nil {
+			return nil, fmt.Errorf("invalid recording at %s:%d: %w", path, line, err)
+		}
+		if len(recording.Args) == 0 {
+			return nil, fmt.Errorf("invalid recording at %s:%d: no command", path, line)
+		}
+		key := replayKey(recording.Args)
+		replay.recordings[key] = append(replay.recordings[key], recording)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
+
+// Run returns the output and the exit status recorded for the command
+func (r *ReplayExecutor) Run(name string, args ...string) ([]byte, []byte, error) {
+	key := replayKey(append([]string{name}, args...))
+	r.mu.Lock()
+	recordings := r.recordings[key]
+	if len(recordings) == 0 {
+		r.mu.Unlock()
+		return nil, nil, fmt.Errorf("%w: %s", ErrNoRecording, strings.Join(redactArgs(append([]string{name}, args...)), " "))
+	}
+	idx := r.served[key]
+	if idx < len(recordings)-1 {
+		r.served[key]++
+	}
+	recording := recordings[idx]
+	r.mu.Unlock()
+
+	if r.Delay {
+		time.Sleep(recording.Duration)
+	}
+	var err error
+	switch {
+	case recording.Error != "":
+		err = errors.New(recording.Error)
+	case recording.ExitCode != 0:
+		err = &ExitError{Code: recording.ExitCode}
+	}
+	return []byte(recording.Stdout), []byte(recording.Stderr), err
+}
+
+// secretOptions are the options of nvme-cli whose values are not recorded
+var secretOptions = []string{"--dhchap-secret=", "--dhchap-ctrl-secret="}
+
+// redactArgs returns the command line with the values of the secret options replaced
+func redactArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	for i, arg := range args {
+		redacted[i] = arg
+		for _, option := range secretOptions {
+			if strings.HasPrefix(arg, option) {
+				redacted[i] = option + "REDACTED"
+			}
+		}
+	}
+	return redacted
+}
+
+// replayKey returns the command line without chroot prefix, with the base name of the command
+// and with the secrets redacted
+func replayKey(args []string) string {
+	args = redactArgs(args)
+	if len(args) > 2 && filepath.Base(args[0]) == "chroot" {
+		args = args[2:]
+	}
+	key := append([]string{filepath.Base(args[0])}, args[1:]...)
+	return strings.Join(key, "\x00")
+}
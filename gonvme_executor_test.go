@@ -0,0 +1,202 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandExecutor(t *testing.T) {
+	stdout, stderr, err := CommandExecutor{}.Run("sh", "-c", "echo out; echo err >&2; exit 3")
+	assert.Equal(t, "out\n", string(stdout))
+	assert.Equal(t, "err\n", string(stderr))
+	code, ok := exitCode(err)
+	assert.True(t, ok)
+	assert.Equal(t, 3, code)
+
+	_, _, err = CommandExecutor{}.Run("/nonexistent/nvme")
+	assert.Error(t, err)
+	_, ok = exitCode(err)
+	assert.False(t, ok)
+}
+
+func TestExecutors(t *testing.T) {
+	var commands [][]string
+	originalExecutor := defaultExecutor
+	defer func() { defaultExecutor = originalExecutor }()
+	defaultExecutor = mockExecutor(func(name string, args ...string) mockCommand {
+		commands = append(commands, append([]string{name}, args...))
+		return mockCommand{}
+	})
+
+	tests := []struct {
+		name     string
+		executor Executor
+		want     []string
+	}{
+		{"chroot", ChrootExecutor{Dir: "/noderoot"}, []string{"chroot", "/noderoot", "nvme", "list", "-o", "json"}},
+		{"chroot to root", ChrootExecutor{Dir: "/"}, []string{"nvme", "list", "-o", "json"}},
+		{"nsenter", NsenterExecutor{}, []string{"nsenter", "--target", "1", "--mount", "--net", "--", "nvme", "list", "-o", "json"}},
+		{"nsenter target", NsenterExecutor{Target: 42}, []string{"nsenter", "--target", "42", "--mount", "--net", "--", "nvme", "list", "-o", "json"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands = nil
+			_, _, err := tt.executor.Run("nvme", "list", "-o", "json")
+			assert.NoError(t, err)
+			assert.Equal(t, [][]string{tt.want}, commands)
+		})
+	}
+
+	called := false
+	stdout, _, err := ExecutorFunc(func(name string, args ...string) ([]byte, []byte, error) {
+		called = true
+		assert.Equal(t, "nvme", name)
+		assert.Equal(t, []string{"version"}, args)
+		return []byte("nvme version 2.8\n"), nil, nil
+	}).Run("nvme", "version")
+	assert.NoError(t, err)
+	assert.True(t, called)
+	assert.Equal(t, "nvme version 2.8\n", string(stdout))
+}
+
+func TestExecutorOption(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     map[string]string
+		executor Executor
+		hostRoot string
+	}{
+		{"default", nil, nil, "/"},
+		{"default chroot", map[string]string{ChrootDirectory: "/noderoot"}, ChrootExecutor{Dir: "/noderoot"}, "/noderoot"},
+		{"direct", map[string]string{ExecutorOption: ExecutorDirect, ChrootDirectory: "/noderoot"}, nil, "/noderoot"},
+		{"chroot", map[string]string{ExecutorOption: ExecutorChroot, ChrootDirectory: "/noderoot"}, ChrootExecutor{Dir: "/noderoot"}, "/noderoot"},
+		{"nsenter", map[string]string{ExecutorOption: ExecutorNsenter}, NsenterExecutor{Target: 1}, "/proc/1/root"},
+		{"nsenter target", map[string]string{ExecutorOption: ExecutorNsenter, NsenterTarget: "42"}, NsenterExecutor{Target: 42}, "/proc/42/root"},
+		{"invalid nsenter target", map[string]string{ExecutorOption: ExecutorNsenter, NsenterTarget: "init"}, NsenterExecutor{Target: 1}, "/proc/1/root"},
+		{"unknown", map[string]string{ExecutorOption: "ssh", ChrootDirectory: "/noderoot"}, ChrootExecutor{Dir: "/noderoot"}, "/noderoot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nvme := NewNVMe(tt.opts)
+			assert.Equal(t, tt.executor, nvme.Executor)
+			assert.Equal(t, tt.hostRoot, nvme.hostRoot())
+		})
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "nvme.jsonl")
+	sessions := 0
+	originalExecutor := defaultExecutor
+	defer func() { defaultExecutor = originalExecutor }()
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		// chroot /noderoot /usr/sbin/nvme <subcommand>
+		switch args[2] {
+		case "list-subsys":
+			sessions++
+			if sessions == 1 {
+				return mockCommand{outErr: &ExitError{Code: NVMeNoObjsFoundExitCode}}
+			}
+			return mockCommand{out: []byte(`{"Subsystems":[{"Name":"nvme-subsys0","NQN":"nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D0","Paths":[{"Name":"nvme0","Transport":"tcp","Address":"traddr=1.1.1.1,trsvcid=4420","State":"live"}]}]}`)}
+		case "connect":
+			return mockCommand{waitErr: &ExitError{Code: 114}, stdErr: []byte("Failed to write to /dev/nvme-fabrics: Operation already in progress\n")}
+		}
+		return mockCommand{outErr: errors.New("exec: \"nvme\": executable file not found in $PATH")}
+	})
+
+	nvme := NewNVMe(map[string]string{ChrootDirectory: "/noderoot"})
+	nvme.NVMeCommand = "/usr/sbin/nvme"
+	recorder, err := NewRecordingExecutor(nvme.Executor, file)
+	require.NoError(t, err)
+	nvme.Executor = recorder
+	target := NVMeTarget{Portal: "1.1.1.1", TargetNqn: "nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D0"}
+
+	// the commands of a host losing and regaining its connection
+	type result struct {
+		sessions   []NVMESession
+		sessionErr error
+		connectErr error
+		rescanErr  error
+	}
+	run := func() result {
+		empty, err := nvme.GetSessions()
+		require.NoError(t, err)
+		require.Empty(t, empty)
+		var r result
+		r.connectErr = nvme.NVMeTCPConnect(target, false)
+		r.sessions, r.sessionErr = nvme.GetSessions()
+		r.rescanErr = nvme.DeviceRescan("/dev/nvme0")
+		return r
+	}
+	recorded := run()
+	require.NoError(t, recorder.Close())
+	require.NoError(t, recorded.sessionErr)
+	require.Len(t, recorded.sessions, 1)
+	assert.NoError(t, recorded.connectErr)
+	assert.Error(t, recorded.rescanErr)
+
+	replay, err := NewReplayExecutor(file)
+	require.NoError(t, err)
+	// the replaying host has nvme-cli elsewhere and no chroot
+	nvme = NewNVMe(nil)
+	nvme.NVMeCommand = "/sbin/nvme"
+	nvme.Executor = replay
+
+	replayed := run()
+	assert.Equal(t, recorded, replayed)
+	assert.EqualError(t, replayed.rescanErr, "exec: \"nvme\": executable file not found in $PATH")
+
+	// the last recording of a command is served again
+	_, err = nvme.GetSessions()
+	assert.NoError(t, err)
+	_, _, err = replay.Run("nvme", "list", "-o", "json")
+	assert.ErrorIs(t, err, ErrNoRecording)
+}
+
+func TestRecording(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "nvme.jsonl")
+	recorder, err := NewRecordingExecutor(nil, file)
+	require.NoError(t, err)
+	_, _, err = recorder.Run("sh", "-c", "echo out; echo err >&2; exit 2")
+	assert.Error(t, err)
+	require.NoError(t, recorder.Close())
+
+	replay, err := NewReplayExecutor(file)
+	require.NoError(t, err)
+	recordings := replay.recordings[replayKey([]string{"sh", "-c", "echo out; echo err >&2; exit 2"})]
+	require.Len(t, recordings, 1)
+	assert.Equal(t, "out\n", recordings[0].Stdout)
+	assert.Equal(t, "err\n", recordings[0].Stderr)
+	assert.Equal(t, 2, recordings[0].ExitCode)
+	assert.Positive(t, recordings[0].Duration)
+
+	require.NoError(t, os.WriteFile(file, []byte("{\"args\":[]}\n"), 0o600))
+	_, err = NewReplayExecutor(file)
+	assert.Error(t, err)
+	require.NoError(t, os.WriteFile(file, []byte("not json\n"), 0o600))
+	_, err = NewReplayExecutor(file)
+	assert.Error(t, err)
+	_, err = NewReplayExecutor(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,242 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// FCRoleNVMeTarget is the role of a remote port exposing NVMe subsystems
+	FCRoleNVMeTarget = "NVMe Target"
+	// FCRoleNVMeDiscovery is the role of a remote port exposing an NVMe discovery controller
+	FCRoleNVMeDiscovery = "NVMe Discovery"
+	// FCPortStateOnline is the state of a usable FC port
+	FCPortStateOnline = "Online"
+)
+
+var (
+	fcRemotePortsPath = "/sys/class/fc_remote_ports/rport-*"
+	// fcNVMeDiscoveryPath makes nvme_fc re-emit a discovery uevent for every NVMe discovery remote port
+	fcNVMeDiscoveryPath = "/sys/class/fc/fc_udev_device/nvme_discovery"
+
+	// fcHostNameRegexp matches the name of an fc_host, host2
+	fcHostNameRegexp = regexp.MustCompile(`^host[0-9]+$`)
+
+	// rportNameRegexp matches rport-<host>:<channel>-<index>, the host being the number of the owning fc_host
+	rportNameRegexp = regexp.MustCompile(`^rport-([0-9]+):[0-9]+-[0-9]+$`)
+)
+
+// FCRemotePort is a Fibre Channel port seen by a local HBA, /sys/class/fc_remote_ports/rport-*
+type FCRemotePort struct {
+	Name string // rport-2:0-3
+	// Host is the fc_host, host2, the remote port is reached through
+	Host      string
+	PortName  string
+	NodeName  string
+	PortID    string
+	PortState string
+	// Roles are the FC-4 roles of the port, "FCP Target", "NVMe Target" or "NVMe Discovery" for instance
+	Roles []string
+}
+
+// HasRole reports whether the remote port has the role
+func (p FCRemotePort) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ListFCRemotePorts returns the Fibre Channel remote ports, sorted by name.
+// Ports which cannot be read are skipped and reported in a ControllerErrors keyed by port.
+func (nvme *NVMe) ListFCRemotePorts() ([]FCRemotePort, error) {
+	matches, err := filepath.Glob(nvme.hostPath(fcRemotePortsPath))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+
+	ports := []FCRemotePort{}
+	failed := ControllerErrors{}
+	for _, m := range matches {
+		port, err := readFCRemotePort(m)
+		if err != nil {
+			nvme.log().Errorf("Error reading FC remote port %s: %v", filepath.Base(m), err)
+			failed[filepath.Base(m)] = err
+			continue
+		}
+		ports = append(ports, port)
+	}
+	if len(failed) > 0 {
+		return ports, failed
+	}
+	return ports, nil
+}
+
+func readFCRemotePort(dir string) (FCRemotePort, error) {
+	name := filepath.Base(dir)
+	match := rportNameRegexp.FindStringSubmatch(name)
+	if match == nil {
+		return FCRemotePort{}, fmt.Errorf("unexpected remote port name %q", name)
+	}
+
+	attrs := controllerAttributes{dir: dir}
+	port := FCRemotePort{
+		Name:      name,
+		Host:      "host" + match[1],
+		PortName:  attrs.string("port_name"),
+		NodeName:  attrs.string("node_name"),
+		PortID:    attrs.string("port_id"),
+		PortState: attrs.string("port_state"),
+	}
+	for _, role := range strings.Split(attrs.string("roles"), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			port.Roles = append(port.Roles, role)
+		}
+	}
+	if attrs.err != nil {
+		return FCRemotePort{}, attrs.err
+	}
+	if port.PortName == "" || port.NodeName == "" {
+		return FCRemotePort{}, errors.New("missing port_name or node_name")
+	}
+	return port, nil
+}
+
+// DiscoverAllNVMeFCTargets runs an NVMe/FC discovery on every online remote port with the NVMe Discovery role,
+// from the local HBA it is reached through, and returns the NVMe/FC targets without duplicates.
+// Failed discoveries are only reported, as a ControllerErrors keyed by remote port, when no target is found.
+func (nvme *NVMe) DiscoverAllNVMeFCTargets(login bool) ([]NVMeTarget, error) {
+	hbas, err := nvme.getUsableFCHostInfo()
+	if err != nil || len(hbas) == 0 {
+		nvme.logOperation("discover").Errorf("Error gathering NVMe/FC Hosts on the host side: %v", err)
+		return []NVMeTarget{}, err
+	}
+	initiators := map[string]string{}
+	for _, hba := range hbas {
+		initiators[hba.Host] = fcTransportAddress(hba.NodeName, hba.PortName)
+	}
+
+	rports, err := nvme.ListFCRemotePorts()
+	if err != nil && len(rports) == 0 {
+		return []NVMeTarget{}, err
+	}
+
+	targets := make([]NVMeTarget, 0)
+	seen := map[string]bool{}
+	failed := ControllerErrors{}
+	for _, rport := range rports {
+		initiator, ok := initiators[rport.Host]
+		if !ok || rport.PortState != FCPortStateOnline || !rport.HasRole(FCRoleNVMeDiscovery) {
+			continue
+		}
+
+		found, err := nvme.discoverFCTargetsOn(fcTransportAddress(rport.NodeName, rport.PortName), initiator)
+		if err != nil {
+			nvme.logOperation("discover", portalField(fcTransportAddress(rport.NodeName, rport.PortName)), LogField{Key: logKeyHostAddr, Value: initiator}).
+				Errorf("Error discovering NVMe/FC targets on %s from %s: %v", rport.Name, rport.Host, err)
+			failed[rport.Name] = err
+			continue
+		}
+		for _, target := range found {
+			if key := pathKey(target); !seen[key] {
+				seen[key] = true
+				targets = append(targets, target)
+			}
+		}
+	}
+
+	if len(targets) == 0 && len(failed) > 0 {
+		return targets, failed
+	}
+
+	if login {
+		for _, t := range targets {
+			if err := nvme.NVMeFCConnect(t, false); err != nil {
+				nvme.logOperation("discover", targetFields(t)...).Errorf("Error during NVMe/FC connect: %v", err)
+			}
+		}
+	}
+	return targets, nil
+}
+
+// RescanFCHostOptions controls how RescanFCHost finds the new remote ports
+type RescanFCHostOptions struct {
+	// IssueLIP issues a LIP before scanning. The LIP resets the link of the HBA, pausing the I/O of
+	// every volume behind the port; it is only needed when the fabric does not report zoning changes.
+	IssueLIP bool
+}
+
+// RescanFCHost makes the FC transport of the host, host2 or /sys/class/fc_host/host2, find the remote ports
+// added by zoning changes. It scans its SCSI host and asks nvme_fc to emit discovery uevents, so that udev
+// connects the new NVMe/FC targets, after issuing a LIP when opts.IssueLIP is set. The triggers which the
+// drivers do not expose are skipped; the error, if any, is a ControllerErrors keyed by the failed attribute.
+func (nvme *NVMe) RescanFCHost(host string, opts RescanFCHostOptions) error {
+	host = filepath.Base(host)
+	if !fcHostNameRegexp.MatchString(host) {
+		return fmt.Errorf("invalid FC host name %q", host)
+	}
+	dir := filepath.Join(nvme.hostPath(filepath.Dir(fcHostPath)), host)
+	if _, err := os.Stat(dir); err != nil {
+		return fmt.Errorf("FC host %s not found: %w", host, err)
+	}
+
+	type write struct {
+		file  string
+		value string
+	}
+	var writes []write
+	if opts.IssueLIP {
+		writes = append(writes, write{filepath.Join(dir, "issue_lip"), "1"})
+	}
+	writes = append(writes,
+		write{filepath.Join(dir, "device", "scsi_host", host, "scan"), "- - -"},
+		write{nvme.hostPath(fcNVMeDiscoveryPath), "add"})
+
+	issued := 0
+	failed := ControllerErrors{}
+	for _, w := range writes {
+		err := writeSysfsAttribute(w.file, w.value)
+		switch {
+		case err == nil:
+			issued++
+		case errors.Is(err, os.ErrNotExist):
+			nvme.logOperation("rescanFCHost").Debugf("Skipping %s, not supported by the FC driver", w.file)
+		default:
+			nvme.logOperation("rescanFCHost").Errorf("Error rescanning FC host %s through %s: %v", host, w.file, err)
+			failed[filepath.Base(w.file)] = err
+		}
+	}
+
+	if len(failed) > 0 {
+		return failed
+	}
+	if issued == 0 {
+		return fmt.Errorf("FC host %s cannot be rescanned, no rescan trigger found", host)
+	}
+	nvme.logOperation("rescanFCHost").Infof("FC host %s rescanned", host)
+	return nil
+}
@@ -0,0 +1,253 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setupFCSysfs builds fake fc_host and fc_remote_ports classes from attribute maps keyed by entry then attribute.
+// The fixture is built at run time as rport names contain colons, which module zips do not allow.
+func setupFCSysfs(t *testing.T, hosts map[string]map[string]string, rports map[string]map[string]string) string {
+	root := t.TempDir()
+	write := func(class string, entries map[string]map[string]string) {
+		for entry, attrs := range entries {
+			dir := filepath.Join(root, class, entry)
+			assert.NoError(t, os.MkdirAll(dir, 0o755))
+			for attr, value := range attrs {
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, attr), []byte(value+"\n"), 0o600))
+			}
+		}
+	}
+	write("fc_host", hosts)
+	write("fc_remote_ports", rports)
+
+	originalHosts, originalRports := fcHostPath, fcRemotePortsPath
+	fcHostPath = filepath.Join(root, "fc_host", "host*")
+	fcRemotePortsPath = filepath.Join(root, "fc_remote_ports", "rport-*")
+	t.Cleanup(func() { fcHostPath, fcRemotePortsPath = originalHosts, originalRports })
+	return root
+}
+
+var (
+	fcTestHosts = map[string]map[string]string{
+		"host1": {"port_name": "0x100000109b6460e1", "node_name": "0x200000109b6460e1", "port_state": "Online"},
+		"host2": {"port_name": "0x100000109b6460e2", "node_name": "0x200000109b6460e2", "port_state": "Online"},
+	}
+	fcTestRports = map[string]map[string]string{
+		// NVMe discovery and target port seen from both HBAs
+		"rport-1:0-0": {"port_name": "0x58ccf091492b0bcf", "node_name": "0x58ccf090c9200bcf", "port_id": "0x010400", "port_state": "Online", "roles": "NVMe Target, NVMe Discovery"},
+		"rport-2:0-0": {"port_name": "0x58ccf091492b0bcf", "node_name": "0x58ccf090c9200bcf", "port_id": "0x010400", "port_state": "Online", "roles": "NVMe Target, NVMe Discovery"},
+		// SCSI only
+		"rport-1:0-1": {"port_name": "0x500009700825b81c", "node_name": "0x500009700825b000", "port_id": "0x010500", "port_state": "Online", "roles": "FCP Target"},
+		// zoned but not logged in
+		"rport-1:0-2": {"port_name": "0x58ccf091492b0bd0", "node_name": "0x58ccf090c9200bcf", "port_id": "0x010600", "port_state": "Blocked", "roles": "NVMe Target, NVMe Discovery"},
+		// reached through an HBA which is not an fc_host of this host anymore
+		"rport-3:0-0": {"port_name": "0x58ccf091492b0bd1", "node_name": "0x58ccf090c9200bcf", "port_id": "0x010700", "port_state": "Online", "roles": "NVMe Discovery"},
+	}
+)
+
+func TestListFCRemotePorts(t *testing.T) {
+	setupFCSysfs(t, fcTestHosts, fcTestRports)
+
+	ports, err := NewNVMe(nil).ListFCRemotePorts()
+	assert.NoError(t, err)
+	assert.Len(t, ports, 5)
+	assert.Equal(t, FCRemotePort{
+		Name:      "rport-1:0-0",
+		Host:      "host1",
+		PortName:  "0x58ccf091492b0bcf",
+		NodeName:  "0x58ccf090c9200bcf",
+		PortID:    "0x010400",
+		PortState: FCPortStateOnline,
+		Roles:     []string{FCRoleNVMeTarget, FCRoleNVMeDiscovery},
+	}, ports[0])
+	assert.Equal(t, "rport-3:0-0", ports[4].Name)
+	assert.True(t, ports[4].HasRole(FCRoleNVMeDiscovery))
+	assert.False(t, ports[4].HasRole(FCRoleNVMeTarget))
+	assert.False(t, ports[1].HasRole(FCRoleNVMeDiscovery))
+}
+
+func TestListFCRemotePortsPartialErrors(t *testing.T) {
+	setupFCSysfs(t, fcTestHosts, map[string]map[string]string{
+		"rport-1:0-0": fcTestRports["rport-1:0-0"],
+		"rport-1:0-1": {"port_state": "Online"},
+	})
+
+	ports, err := NewNVMe(nil).ListFCRemotePorts()
+	assert.Len(t, ports, 1)
+	failed, ok := err.(ControllerErrors)
+	assert.True(t, ok)
+	assert.Contains(t, failed, "rport-1:0-1")
+
+	// no FC remote port at all
+	fcRemotePortsPath = filepath.Join(t.TempDir(), "rport-*")
+	ports, err = NewNVMe(nil).ListFCRemotePorts()
+	assert.NoError(t, err)
+	assert.Empty(t, ports)
+}
+
+// recordFCDiscovery replaces the default executor, answering nvme discover with a log entry for the queried
+// target port and recording "target from initiator"; discoveries of failOn fail
+func recordFCDiscovery(t *testing.T, failOn string) *[]string {
+	var mu sync.Mutex
+	discoveries := []string{}
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		var traddr, hostTraddr string
+		for i := 0; i+1 < len(args); i++ {
+			switch args[i] {
+			case "-a":
+				traddr = args[i+1]
+			case "-w":
+				hostTraddr = args[i+1]
+			}
+		}
+		mu.Lock()
+		discoveries = append(discoveries, traddr+" from "+hostTraddr)
+		mu.Unlock()
+		if failOn != "" && strings.Contains(traddr, failOn) {
+			return mockCommand{outErr: errors.New("discovery failed")}
+		}
+		return mockCommand{out: []byte(fmt.Sprintf(`
+Discovery Log Number of Records 2, Generation counter 2
+=====Discovery Log Entry 0======
+trtype:  fc
+adrfam:  fibre-channel
+subtype: nvme subsystem
+treq:    not specified
+portid:  0
+trsvcid: none
+subnqn:  nqn.1988-11.com.dell:powermax:00:000120001647
+traddr:  %s
+=====Discovery Log Entry 1======
+trtype:  fc
+adrfam:  fibre-channel
+subtype: nvme subsystem
+treq:    not specified
+portid:  1
+trsvcid: none
+subnqn:  nqn.1988-11.com.dell:powermax:00:000120001647
+traddr:  nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0fff
+`, traddr))}
+	})
+	t.Cleanup(func() { defaultExecutor = originalExecutor })
+	return &discoveries
+}
+
+func TestDiscoverAllNVMeFCTargets(t *testing.T) {
+	setupFCSysfs(t, fcTestHosts, fcTestRports)
+	discoveries := recordFCDiscovery(t, "")
+
+	targets, err := NewNVMe(nil).DiscoverAllNVMeFCTargets(false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf from nn-0x200000109b6460e1:pn-0x100000109b6460e1",
+		"nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf from nn-0x200000109b6460e2:pn-0x100000109b6460e2",
+	}, *discoveries)
+
+	assert.Len(t, targets, 2)
+	for i, target := range targets {
+		assert.Equal(t, "nqn.1988-11.com.dell:powermax:00:000120001647", target.TargetNqn)
+		assert.Equal(t, "nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf", target.Portal)
+		assert.Equal(t, NVMeTransportTypeFC, target.TargetType)
+		assert.Equal(t, fmt.Sprintf("nn-0x200000109b6460e%d:pn-0x100000109b6460e%d", i+1, i+1), target.HostAdr)
+	}
+}
+
+func TestDiscoverAllNVMeFCTargetsErrors(t *testing.T) {
+	setupFCSysfs(t, fcTestHosts, fcTestRports)
+	recordFCDiscovery(t, "0x58ccf091492b0bcf")
+
+	targets, err := NewNVMe(nil).DiscoverAllNVMeFCTargets(false)
+	assert.Empty(t, targets)
+	failed, ok := err.(ControllerErrors)
+	assert.True(t, ok)
+	assert.Len(t, failed, 2)
+
+	// discovery ports are only reached through offline HBAs
+	setupFCSysfs(t, map[string]map[string]string{
+		"host1": {"port_name": "0x100000109b6460e1", "node_name": "0x200000109b6460e1", "port_state": "Linkdown"},
+		"host2": {"port_name": "0x100000109b6460e2", "node_name": "0x200000109b6460e2", "port_state": "Linkdown"},
+	}, fcTestRports)
+	targets, err = NewNVMe(nil).DiscoverAllNVMeFCTargets(false)
+	assert.NoError(t, err)
+	assert.Empty(t, targets)
+
+	// no local HBA
+	setupFCSysfs(t, nil, fcTestRports)
+	targets, err = NewNVMe(nil).DiscoverAllNVMeFCTargets(false)
+	assert.NoError(t, err)
+	assert.Empty(t, targets)
+}
+
+func TestRescanFCHost(t *testing.T) {
+	root := setupFCSysfs(t, map[string]map[string]string{
+		"host1": {"port_name": "0x100000109b6460e1", "node_name": "0x200000109b6460e1", "issue_lip": "0"},
+		"host2": {"port_name": "0x100000109b6460e2", "node_name": "0x200000109b6460e2"},
+		"host3": {"port_name": "0x100000109b6460e3", "node_name": "0x200000109b6460e3"},
+	}, nil)
+	scsiHost := filepath.Join(root, "fc_host", "host1", "device", "scsi_host", "host1")
+	assert.NoError(t, os.MkdirAll(scsiHost, 0o755))
+	assert.NoError(t, os.WriteFile(filepath.Join(scsiHost, "scan"), nil, 0o600))
+	// a scan attribute which cannot be written
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "fc_host", "host3", "device", "scsi_host", "host3", "scan"), 0o755))
+
+	discovery := filepath.Join(root, "fc_udev_device", "nvme_discovery")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(discovery), 0o755))
+	assert.NoError(t, os.WriteFile(discovery, nil, 0o600))
+	originalDiscovery := fcNVMeDiscoveryPath
+	fcNVMeDiscoveryPath = discovery
+	defer func() { fcNVMeDiscoveryPath = originalDiscovery }()
+
+	nvme := NewNVMe(nil)
+	// no LIP by default, the links stay up
+	assert.NoError(t, nvme.RescanFCHost("/sys/class/fc_host/host1", RescanFCHostOptions{}))
+	assert.Equal(t, "0", readSysfsTestFile(t, root, "fc_host/host1/issue_lip"))
+	assert.Equal(t, "- - -", readSysfsTestFile(t, scsiHost, "scan"))
+	assert.Equal(t, "add", readSysfsTestFile(t, root, "fc_udev_device/nvme_discovery"))
+
+	assert.NoError(t, os.WriteFile(filepath.Join(scsiHost, "scan"), nil, 0o600))
+	assert.NoError(t, nvme.RescanFCHost("host1", RescanFCHostOptions{IssueLIP: true}))
+	assert.Equal(t, "1", readSysfsTestFile(t, root, "fc_host/host1/issue_lip"))
+	assert.Equal(t, "- - -", readSysfsTestFile(t, scsiHost, "scan"))
+
+	// neither LIP nor SCSI scan supported, only the NVMe discovery is triggered
+	assert.NoError(t, os.WriteFile(discovery, nil, 0o600))
+	assert.NoError(t, nvme.RescanFCHost("host2", RescanFCHostOptions{IssueLIP: true}))
+	assert.Equal(t, "add", readSysfsTestFile(t, root, "fc_udev_device/nvme_discovery"))
+
+	err := nvme.RescanFCHost("host3", RescanFCHostOptions{})
+	failed, ok := err.(ControllerErrors)
+	assert.True(t, ok)
+	assert.Contains(t, failed, "scan")
+
+	assert.Error(t, nvme.RescanFCHost("host4", RescanFCHostOptions{}))
+	assert.Error(t, nvme.RescanFCHost("rport-1:0-0", RescanFCHostOptions{}))
+
+	// no rescan trigger at all
+	fcNVMeDiscoveryPath = filepath.Join(root, "missing", "nvme_discovery")
+	assert.Error(t, nvme.RescanFCHost("host2", RescanFCHostOptions{}))
+}
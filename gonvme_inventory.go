@@ -0,0 +1,371 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrUnknownListFormat is returned when the output of nvme list does not match any known schema
+var ErrUnknownListFormat = errors.New("unknown nvme list output format")
+
+// ListFormatError describes an nvme list entry which could not be decoded
+type ListFormatError struct {
+	Index  int
+	Reason string
+}
+
+func (e *ListFormatError) Error() string {
+	return fmt.Sprintf("nvme list device entry %d: %s", e.Index, e.Reason)
+}
+
+// flexString decodes a JSON string or number into a string.
+// nvme-cli has emitted fields such as Cntlid as both over time.
+type flexString string
+
+func (f *flexString) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*f = flexString(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	*f = flexString(n.String())
+	return nil
+}
+
+// nvmeListOutput is the top level object of nvme list -o json
+type nvmeListOutput struct {
+	Devices []json.RawMessage `json:"Devices"`
+}
+
+// nvmeListLegacyDevice is a device entry of nvme list -o json prior to nvme-cli 2.11.
+//
+//	{
+//		"NameSpace" : 9217,
+//		"DevicePath" : "/dev/nvme0n1",
+//		"Firmware" : "2.1.0.0",
+//		"Index" : 0,
+//		"ModelNumber" : "dellemc",
+//		"SerialNumber" : "FP08RZ2",
+//		"UsedBytes" : 0,
+//		"MaximumLBA" : 10485760,
+//		"PhysicalSize" : 5368709120,
+//		"SectorSize" : 512
+//	}
+type nvmeListLegacyDevice struct {
+	NameSpace    uint32 `json:"NameSpace"`
+	DevicePath   string `json:"DevicePath"`
+	GenericPath  string `json:"GenericPath"`
+	Firmware     string `json:"Firmware"`
+	Index        int    `json:"Index"`
+	ModelNumber  string `json:"ModelNumber"`
+	SerialNumber string `json:"SerialNumber"`
+	UsedBytes    uint64 `json:"UsedBytes"`
+	MaximumLBA   uint64 `json:"MaximumLBA"`
+	PhysicalSize uint64 `json:"PhysicalSize"`
+	SectorSize   uint32 `json:"SectorSize"`
+}
+
+// nvmeListHost is a device entry of nvme list -o json in nvme-cli 2.11 and above,
+// and of nvme list -v -o json in nvme-cli 2.x.
+type nvmeListHost struct {
+	HostNQN    string              `json:"HostNQN"`
+	HostID     string              `json:"HostID"`
+	Subsystems []nvmeListSubsystem `json:"Subsystems"`
+}
+
+// nvmeListSubsystem is a subsystem entry of the nvme-cli 2.x schema.
+// nvme-cli 1.x nvme list -v -o json emits these directly as device entries.
+type nvmeListSubsystem struct {
+	Subsystem    string               `json:"Subsystem"`
+	SubsystemNQN string               `json:"SubsystemNQN"`
+	Controllers  []nvmeListController `json:"Controllers"`
+	Namespaces   []nvmeListNamespace  `json:"Namespaces"`
+}
+
+type nvmeListController struct {
+	Controller   string              `json:"Controller"`
+	Cntlid       flexString          `json:"Cntlid"`
+	SerialNumber string              `json:"SerialNumber"`
+	ModelNumber  string              `json:"ModelNumber"`
+	Firmware     string              `json:"Firmware"`
+	Transport    string              `json:"Transport"`
+	Address      string              `json:"Address"`
+	Slot         string              `json:"Slot"`
+	Namespaces   []nvmeListNamespace `json:"Namespaces"`
+	Paths        []nvmeListPath      `json:"Paths"`
+}
+
+type nvmeListNamespace struct {
+	NameSpace    string `json:"NameSpace"`
+	Generic      string `json:"Generic"`
+	NSID         uint32 `json:"NSID"`
+	UsedBytes    uint64 `json:"UsedBytes"`
+	MaximumLBA   uint64 `json:"MaximumLBA"`
+	PhysicalSize uint64 `json:"PhysicalSize"`
+	SectorSize   uint32 `json:"SectorSize"`
+}
+
+type nvmeListPath struct {
+	Path     string `json:"Path"`
+	ANAState string `json:"ANAState"`
+}
+
+// Inventory is the normalised view of the NVMe devices on the host
+type Inventory struct {
+	Hosts []InventoryHost
+}
+
+// InventoryHost groups the subsystems seen by a host NQN
+type InventoryHost struct {
+	HostNQN    string
+	HostID     string
+	Subsystems []InventorySubsystem
+}
+
+// InventorySubsystem describes an NVMe subsystem and its controllers
+type InventorySubsystem struct {
+	Name        string
+	NQN         string
+	Controllers []InventoryController
+	// Namespaces holds the multipath namespace heads of the subsystem
+	Namespaces []InventoryNamespace
+}
+
+// InventoryController describes a single controller (path) of a subsystem
+type InventoryController struct {
+	Name         string
+	Cntlid       string
+	SerialNumber string
+	ModelNumber  string
+	Firmware     string
+	Transport    string
+	Address      string
+	// Namespaces holds the namespaces attached directly to the controller
+	Namespaces []InventoryNamespace
+	Paths      []InventoryPath
+}
+
+// InventoryPath describes a per-controller path to a multipath namespace
+type InventoryPath struct {
+	Name     string
+	ANAState string
+}
+
+// InventoryNamespace describes an NVMe namespace block device
+type InventoryNamespace struct {
+	DevicePath   string
+	GenericPath  string
+	NSID         uint32
+	UsedBytes    uint64
+	MaximumLBA   uint64
+	PhysicalSize uint64
+	SectorSize   uint32
+	ModelNumber  string
+	SerialNumber string
+	Firmware     string
+}
+
+// AllNamespaces returns every namespace of the inventory, subsystem heads first
+func (inv Inventory) AllNamespaces() []InventoryNamespace {
+	var result []InventoryNamespace
+	for _, host := range inv.Hosts {
+		for _, subsystem := range host.Subsystems {
+			result = append(result, subsystem.Namespaces...)
+			for _, ctrl := range subsystem.Controllers {
+				result = append(result, ctrl.Namespaces...)
+			}
+		}
+	}
+	return result
+}
+
+// parseNVMeList decodes the output of nvme list -o json (or -v -o json) in any known schema.
+// Entries which are not objects or which cannot be decoded produce a ListFormatError.
+// Objects of an unknown shape are skipped; if no entry matches a known schema ErrUnknownListFormat is returned.
+func parseNVMeList(output []byte) (Inventory, error) {
+	var list nvmeListOutput
+	if err := json.Unmarshal(output, &list); err != nil {
+		return Inventory{}, fmt.Errorf("could not unmarshal nvme list output: %w", err)
+	}
+
+	inventory := Inventory{}
+	// legacy entries carry no host information; they are grouped under a host with an empty NQN
+	legacyHost := -1
+	recognised := 0
+
+	for idx, raw := range list.Devices {
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &keys); err != nil || keys == nil {
+			return Inventory{}, &ListFormatError{Index: idx, Reason: "entry is not an object"}
+		}
+
+		switch {
+		case keys["Subsystems"] != nil:
+			var host nvmeListHost
+			if err := json.Unmarshal(raw, &host); err != nil {
+				return Inventory{}, &ListFormatError{Index: idx, Reason: err.Error()}
+			}
+			invHost := InventoryHost{HostNQN: host.HostNQN, HostID: host.HostID}
+			for _, subsystem := range host.Subsystems {
+				invHost.Subsystems = append(invHost.Subsystems, subsystem.normalise())
+			}
+			inventory.Hosts = append(inventory.Hosts, invHost)
+			recognised++
+
+		case keys["SubsystemNQN"] != nil || keys["Controllers"] != nil:
+			var subsystem nvmeListSubsystem
+			if err := json.Unmarshal(raw, &subsystem); err != nil {
+				return Inventory{}, &ListFormatError{Index: idx, Reason: err.Error()}
+			}
+			legacyHost = legacyInventoryHost(&inventory, legacyHost)
+			inventory.Hosts[legacyHost].Subsystems = append(inventory.Hosts[legacyHost].Subsystems, subsystem.normalise())
+			recognised++
+
+		case keys["DevicePath"] != nil:
+			var device nvmeListLegacyDevice
+			if err := json.Unmarshal(raw, &device); err != nil {
+				return Inventory{}, &ListFormatError{Index: idx, Reason: err.Error()}
+			}
+			legacyHost = legacyInventoryHost(&inventory, legacyHost)
+			inventory.Hosts[legacyHost].Subsystems = append(inventory.Hosts[legacyHost].Subsystems, device.normalise())
+			recognised++
+		}
+	}
+
+	if len(list.Devices) > 0 && recognised == 0 {
+		return inventory, ErrUnknownListFormat
+	}
+	return inventory, nil
+}
+
+// legacyInventoryHost returns the index of the host holding legacy entries, adding it on first use
+func legacyInventoryHost(inventory *Inventory, idx int) int {
+	if idx >= 0 {
+		return idx
+	}
+	inventory.Hosts = append(inventory.Hosts, InventoryHost{})
+	return len(inventory.Hosts) - 1
+}
+
+func (s nvmeListSubsystem) normalise() InventorySubsystem {
+	subsystem := InventorySubsystem{
+		Name: s.Subsystem,
+		NQN:  s.SubsystemNQN,
+	}
+
+	// multipath namespace heads inherit identity data from the first controller
+	var first nvmeListController
+	if len(s.Controllers) > 0 {
+		first = s.Controllers[0]
+	}
+	for _, ns := range s.Namespaces {
+		subsystem.Namespaces = append(subsystem.Namespaces, ns.normalise(first))
+	}
+
+	for _, c := range s.Controllers {
+		ctrl := InventoryController{
+			Name:         c.Controller,
+			Cntlid:       string(c.Cntlid),
+			SerialNumber: c.SerialNumber,
+			ModelNumber:  c.ModelNumber,
+			Firmware:     c.Firmware,
+			Transport:    c.Transport,
+			Address:      c.Address,
+		}
+		for _, ns := range c.Namespaces {
+			ctrl.Namespaces = append(ctrl.Namespaces, ns.normalise(c))
+		}
+		for _, p := range c.Paths {
+			ctrl.Paths = append(ctrl.Paths, InventoryPath{Name: p.Path, ANAState: p.ANAState})
+		}
+		subsystem.Controllers = append(subsystem.Controllers, ctrl)
+	}
+	return subsystem
+}
+
+func (n nvmeListNamespace) normalise(ctrl nvmeListController) InventoryNamespace {
+	return InventoryNamespace{
+		DevicePath:   devPath(n.NameSpace),
+		GenericPath:  devPath(n.Generic),
+		NSID:         n.NSID,
+		UsedBytes:    n.UsedBytes,
+		MaximumLBA:   n.MaximumLBA,
+		PhysicalSize: n.PhysicalSize,
+		SectorSize:   n.SectorSize,
+		ModelNumber:  ctrl.ModelNumber,
+		SerialNumber: ctrl.SerialNumber,
+		Firmware:     ctrl.Firmware,
+	}
+}
+
+// normalise maps a legacy device onto a subsystem with a single controller,
+// the controller name being derived from the device name (nvme0n1 -> nvme0)
+func (d nvmeListLegacyDevice) normalise() InventorySubsystem {
+	ns := InventoryNamespace{
+		DevicePath:   devPath(d.DevicePath),
+		GenericPath:  devPath(d.GenericPath),
+		NSID:         d.NameSpace,
+		UsedBytes:    d.UsedBytes,
+		MaximumLBA:   d.MaximumLBA,
+		PhysicalSize: d.PhysicalSize,
+		SectorSize:   d.SectorSize,
+		ModelNumber:  d.ModelNumber,
+		SerialNumber: d.SerialNumber,
+		Firmware:     d.Firmware,
+	}
+	return InventorySubsystem{
+		Controllers: []InventoryController{
+			{
+				Name:         controllerFromNamespace(ns.DevicePath),
+				SerialNumber: d.SerialNumber,
+				ModelNumber:  d.ModelNumber,
+				Firmware:     d.Firmware,
+				Namespaces:   []InventoryNamespace{ns},
+			},
+		},
+	}
+}
+
+func devPath(name string) string {
+	if name == "" || strings.HasPrefix(name, "/dev/") {
+		return name
+	}
+	return "/dev/" + name
+}
+
+// controllerFromNamespace returns the controller name of a namespace device, e.g. /dev/nvme0n1 -> nvme0
+func controllerFromNamespace(device string) string {
+	name := strings.TrimPrefix(device, "/dev/")
+	if idx := strings.LastIndex(name, "n"); idx > len("nvme") {
+		if _, err := strconv.Atoi(name[idx+1:]); err == nil {
+			return name[:idx]
+		}
+	}
+	return name
+}
@@ -0,0 +1,306 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNVMeList(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Inventory
+		wantErr error
+	}{
+		{
+			name: "nvme-cli pre 2_11 format",
+			input: `{
+				"Devices" : [
+				  {
+					"NameSpace" : 9217,
+					"DevicePath" : "/dev/nvme0n1",
+					"Firmware" : "2.1.0.0",
+					"Index" : 0,
+					"ModelNumber" : "dellemc",
+					"SerialNumber" : "FP08RZ2",
+					"UsedBytes" : 0,
+					"MaximumLBA" : 10485760,
+					"PhysicalSize" : 5368709120,
+					"SectorSize" : 512
+				  }
+				]
+			}`,
+			want: Inventory{
+				Hosts: []InventoryHost{
+					{
+						Subsystems: []InventorySubsystem{
+							{
+								Controllers: []InventoryController{
+									{
+										Name:         "nvme0",
+										SerialNumber: "FP08RZ2",
+										ModelNumber:  "dellemc",
+										Firmware:     "2.1.0.0",
+										Namespaces: []InventoryNamespace{
+											{
+												DevicePath:   "/dev/nvme0n1",
+												NSID:         9217,
+												MaximumLBA:   10485760,
+												PhysicalSize: 5368709120,
+												SectorSize:   512,
+												ModelNumber:  "dellemc",
+												SerialNumber: "FP08RZ2",
+												Firmware:     "2.1.0.0",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "nvme-cli 2_11 format",
+			input: `{
+				"Devices":[
+					{
+					"HostNQN":"nqn.2014-08.org.nvmexpress:uuid:a66f1c42-4bce-a619-9c59-9ae6ac2ccb8a",
+					"HostID":"a2d57d74-a198-4e6b-aa78-97af9cd00f31",
+					"Subsystems":[
+						{
+						"Subsystem":"nvme-subsys0",
+						"SubsystemNQN":"nqn.1988-11.com.dell:powerstore:00:42c92aa830b1FF113003",
+						"Controllers":[
+							{
+							"Controller":"nvme0",
+							"Cntlid":4102,
+							"SerialNumber":"883YCJ3",
+							"ModelNumber":"dellemc-powerstore",
+							"Firmware":"4.1.0.0",
+							"Transport":"tcp",
+							"Address":"traddr=10.11.12.13,trsvcid=4420,src_addr=10.10.10.21",
+							"Slot":"",
+							"Namespaces":[],
+							"Paths":[
+								{
+								"Path":"nvme0c0n1",
+								"ANAState":"optimized"
+								}
+							]
+							}
+						],
+						"Namespaces":[
+							{
+							"NameSpace":"nvme0n1",
+							"Generic":"ng0n1",
+							"NSID":293,
+							"UsedBytes":620130304,
+							"MaximumLBA":6291456,
+							"PhysicalSize":3221225472,
+							"SectorSize":512
+							}
+						]
+						}
+					]
+					}
+				]
+			}`,
+			want: Inventory{
+				Hosts: []InventoryHost{
+					{
+						HostNQN: "nqn.2014-08.org.nvmexpress:uuid:a66f1c42-4bce-a619-9c59-9ae6ac2ccb8a",
+						HostID:  "a2d57d74-a198-4e6b-aa78-97af9cd00f31",
+						Subsystems: []InventorySubsystem{
+							{
+								Name: "nvme-subsys0",
+								NQN:  "nqn.1988-11.com.dell:powerstore:00:42c92aa830b1FF113003",
+								Controllers: []InventoryController{
+									{
+										Name:         "nvme0",
+										Cntlid:       "4102",
+										SerialNumber: "883YCJ3",
+										ModelNumber:  "dellemc-powerstore",
+										Firmware:     "4.1.0.0",
+										Transport:    "tcp",
+										Address:      "traddr=10.11.12.13,trsvcid=4420,src_addr=10.10.10.21",
+										Paths: []InventoryPath{
+											{Name: "nvme0c0n1", ANAState: "optimized"},
+										},
+									},
+								},
+								Namespaces: []InventoryNamespace{
+									{
+										DevicePath:   "/dev/nvme0n1",
+										GenericPath:  "/dev/ng0n1",
+										NSID:         293,
+										UsedBytes:    620130304,
+										MaximumLBA:   6291456,
+										PhysicalSize: 3221225472,
+										SectorSize:   512,
+										ModelNumber:  "dellemc-powerstore",
+										SerialNumber: "883YCJ3",
+										Firmware:     "4.1.0.0",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "nvme-cli 1.x verbose format",
+			input: `{
+				"Devices":[
+					{
+					"Subsystem":"nvme-subsys1",
+					"SubsystemNQN":"nqn.1988-11.com.dell:powermax:00:000120001647",
+					"Controllers":[
+						{
+						"Controller":"nvme1",
+						"SerialNumber":"00000120001647",
+						"ModelNumber":"EMC PowerMax_2500",
+						"Firmware":"60790275",
+						"Transport":"fc",
+						"Address":"traddr=nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf",
+						"Namespaces":[
+							{
+							"NameSpace":"nvme1n3",
+							"NSID":3,
+							"UsedBytes":0,
+							"MaximumLBA":2048,
+							"PhysicalSize":1048576,
+							"SectorSize":512
+							}
+						]
+						}
+					]
+					}
+				]
+			}`,
+			want: Inventory{
+				Hosts: []InventoryHost{
+					{
+						Subsystems: []InventorySubsystem{
+							{
+								Name: "nvme-subsys1",
+								NQN:  "nqn.1988-11.com.dell:powermax:00:000120001647",
+								Controllers: []InventoryController{
+									{
+										Name:         "nvme1",
+										SerialNumber: "00000120001647",
+										ModelNumber:  "EMC PowerMax_2500",
+										Firmware:     "60790275",
+										Transport:    "fc",
+										Address:      "traddr=nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf",
+										Namespaces: []InventoryNamespace{
+											{
+												DevicePath:   "/dev/nvme1n3",
+												NSID:         3,
+												MaximumLBA:   2048,
+												PhysicalSize: 1048576,
+												SectorSize:   512,
+												ModelNumber:  "EMC PowerMax_2500",
+												SerialNumber: "00000120001647",
+												Firmware:     "60790275",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "empty device list",
+			input: `{"Devices":[]}`,
+			want:  Inventory{},
+		},
+		{
+			name:    "unknown device shape",
+			input:   `{"Devices":[{"ValidButNotWhatWeExpect":"value"}]}`,
+			want:    Inventory{},
+			wantErr: ErrUnknownListFormat,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseNVMeList([]byte(tc.input))
+			if tc.wantErr != nil {
+				assert.True(t, errors.Is(err, tc.wantErr))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParseNVMeListMalformed(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"not json", `{"Devices":[`},
+		{"device is not an object", `{"Devices":["nvme0n1"]}`},
+		{"subsystems of wrong type", `{"Devices":[{"HostNQN":"nqn","Subsystems":"nvme-subsys0"}]}`},
+		{"namespace id of wrong type", `{"Devices":[{"DevicePath":"/dev/nvme0n1","NameSpace":"one"}]}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseNVMeList([]byte(tc.input))
+			assert.Error(t, err)
+			assert.False(t, errors.Is(err, ErrUnknownListFormat))
+		})
+	}
+}
+
+func TestInventoryAllNamespaces(t *testing.T) {
+	inventory := Inventory{
+		Hosts: []InventoryHost{
+			{
+				Subsystems: []InventorySubsystem{
+					{
+						Namespaces: []InventoryNamespace{{DevicePath: "/dev/nvme0n1"}},
+						Controllers: []InventoryController{
+							{Namespaces: []InventoryNamespace{{DevicePath: "/dev/nvme1n1"}}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := inventory.AllNamespaces()
+	assert.Equal(t, []InventoryNamespace{{DevicePath: "/dev/nvme0n1"}, {DevicePath: "/dev/nvme1n1"}}, got)
+}
+
+func TestControllerFromNamespace(t *testing.T) {
+	assert.Equal(t, "nvme0", controllerFromNamespace("/dev/nvme0n1"))
+	assert.Equal(t, "nvme12", controllerFromNamespace("nvme12n305"))
+	assert.Equal(t, "nvme0", controllerFromNamespace("nvme0"))
+}
@@ -0,0 +1,111 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"log/slog"
+
+	"github.com/dell/gonvme/internal/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// FieldLogger - Placeholder for a logger taking the fields of the messages apart from the message
+type FieldLogger = logger.FieldLogger
+
+// LogField - Placeholder for a key/value pair describing the context of a message
+type LogField = logger.Field
+
+// LogLevel - Placeholder for the severity of a message
+type LogLevel = logger.Level
+
+const (
+	// LogLevelDebug is the level of the messages useful to debug gonvme
+	LogLevelDebug = logger.LevelDebug
+	// LogLevelInfo is the level of the changes made to the host
+	LogLevelInfo = logger.LevelInfo
+	// LogLevelWarn is the level of the unexpected conditions gonvme works around
+	LogLevelWarn = logger.LevelWarn
+	// LogLevelError is the level of the failures
+	LogLevelError = logger.LevelError
+)
+
+// the keys of the fields of the messages
+const (
+	logKeyOperation  = "operation"
+	logKeyNQN        = "nqn"
+	logKeyPortal     = "portal"
+	logKeyHostAddr   = "hostAddress"
+	logKeyDevice     = "device"
+	logKeyController = "controller"
+	logKeyCommand    = "command"
+	logKeyDuration   = "duration"
+)
+
+// NewLogrusLogger returns a Logger logging with logrus, the standard logrus logger when nil.
+// It is the logger of gonvme until SetLogger is called.
+func NewLogrusLogger(l logrus.FieldLogger) Logger {
+	return logger.NewLogrusLogger(l)
+}
+
+// NewSlogLogger returns a Logger logging with log/slog, the default slog logger when nil
+func NewSlogLogger(l *slog.Logger) Logger {
+	return logger.NewSlogLogger(l)
+}
+
+// log returns the logger of the client, the one set with SetLogger when Logger is nil
+func (i *NVMeType) log() *logger.Entry {
+	return logger.New(i.Logger)
+}
+
+// logOperation returns the logger of the client for an operation, with the fields describing it
+func (i *NVMeType) logOperation(operation string, fields ...LogField) *logger.Entry {
+	return i.log().With(append([]LogField{{Key: logKeyOperation, Value: operation}}, fields...)...)
+}
+
+// packageLog returns the logger set with SetLogger, for the code which does not belong to a client
+func packageLog() *logger.Entry {
+	return logger.New(nil)
+}
+
+// targetFields returns the fields of a target: its NQN, portal and, for NVMe/FC, host address
+func targetFields(target NVMeTarget) []LogField {
+	fields := []LogField{{Key: logKeyNQN, Value: target.TargetNqn}, {Key: logKeyPortal, Value: target.Portal}}
+	if target.HostAdr != "" {
+		fields = append(fields, LogField{Key: logKeyHostAddr, Value: target.HostAdr})
+	}
+	return fields
+}
+
+func nqnField(nqn string) LogField {
+	return LogField{Key: logKeyNQN, Value: nqn}
+}
+
+func portalField(portal string) LogField {
+	return LogField{Key: logKeyPortal, Value: portal}
+}
+
+func deviceField(device string) LogField {
+	return LogField{Key: logKeyDevice, Value: device}
+}
+
+func controllerField(controller string) LogField {
+	return LogField{Key: logKeyController, Value: controller}
+}
+
+func logField(key string, value interface{}) LogField {
+	return LogField{Key: key, Value: value}
+}
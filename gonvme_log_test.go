@@ -0,0 +1,112 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package gonvme
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loggedMessage struct {
+	level  LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+// fieldRecorder is a FieldLogger keeping the messages it receives
+type fieldRecorder struct {
+	messages []loggedMessage
+}
+
+func (r *fieldRecorder) Info(ctx context.Context, format string, args ...interface{}) {
+	r.Log(ctx, LogLevelInfo, fmt.Sprintf(format, args...), nil)
+}
+
+func (r *fieldRecorder) Debug(ctx context.Context, format string, args ...interface{}) {
+	r.Log(ctx, LogLevelDebug, fmt.Sprintf(format, args...), nil)
+}
+
+func (r *fieldRecorder) Error(ctx context.Context, format string, args ...interface{}) {
+	r.Log(ctx, LogLevelError, fmt.Sprintf(format, args...), nil)
+}
+
+func (r *fieldRecorder) Log(_ context.Context, level LogLevel, msg string, fields []LogField) {
+	m := loggedMessage{level: level, msg: msg, fields: map[string]interface{}{}}
+	for _, f := range fields {
+		m.fields[f.Key] = f.Value
+	}
+	r.messages = append(r.messages, m)
+}
+
+// plainRecorder is a Logger which is not a FieldLogger
+type plainRecorder struct {
+	messages []string
+}
+
+func (r *plainRecorder) Info(_ context.Context, format string, args ...interface{}) {
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func (r *plainRecorder) Debug(_ context.Context, format string, args ...interface{}) {
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func (r *plainRecorder) Error(_ context.Context, format string, args ...interface{}) {
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func TestClientLogger(t *testing.T) {
+	originalExecutor := defaultExecutor
+	defer func() { defaultExecutor = originalExecutor }()
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		return mockCommand{}
+	})
+
+	target := NVMeTarget{TargetNqn: "nqn.1988-11.com.dell:powerstore:00:1", Portal: "1.1.1.1"}
+	recorder := &fieldRecorder{}
+	nvme := NewNVMe(map[string]string{})
+	nvme.Logger = recorder
+	other := NewNVMe(map[string]string{})
+	other.Logger = &fieldRecorder{}
+
+	require.NoError(t, nvme.NVMeTCPConnect(target, false))
+	require.Len(t, recorder.messages, 3)
+
+	command := recorder.messages[0]
+	assert.Equal(t, LogLevelDebug, command.level)
+	assert.Contains(t, command.fields[logKeyCommand], "connect -t tcp -n nqn.1988-11.com.dell:powerstore:00:1")
+	assert.IsType(t, time.Duration(0), command.fields[logKeyDuration])
+
+	connected := recorder.messages[2]
+	assert.Equal(t, LogLevelInfo, connected.level)
+	assert.Equal(t, "nvme connect successful", connected.msg)
+	assert.Equal(t, map[string]interface{}{
+		logKeyOperation: "connect",
+		logKeyNQN:       target.TargetNqn,
+		logKeyPortal:    target.Portal,
+	}, connected.fields)
+	assert.Empty(t, other.Logger.(*fieldRecorder).messages)
+
+	plain := &plainRecorder{}
+	nvme.Logger = plain
+	require.NoError(t, nvme.NVMeTCPConnect(target, false))
+	assert.Contains(t, plain.messages, "nvme connect successful operation=connect nqn=nqn.1988-11.com.dell:powerstore:00:1 portal=1.1.1.1")
+}
@@ -17,8 +17,10 @@
 package gonvme
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"path"
 	"strconv"
 )
 
@@ -46,11 +48,26 @@ var GONVMEMock struct {
 	InducedNVMeDeviceAndNamespaceError bool
 	InducedNVMeNamespaceIDError        bool
 	InducedNVMeDeviceDataError         bool
+	InduceGetInventoryError            bool
+	InduceRefreshCapacityError         bool
+	InduceRescanError                  bool
+	InduceDeviceInUseError             bool
+	InduceGetControllerError           bool
+	InduceUpdateTimeoutsError          bool
+	InduceResetControllerError         bool
+	InduceTuneQueueError               bool
+	InduceRescanFCHostError            bool
+	InduceDeviceRescanError            bool
+	InduceCLIVersionError              bool
+	InduceAvailableError               bool
+	InduceCheckPrerequisitesError      bool
 }
 
 // MockNVMe provides a mock implementation of an NVMe client
 type MockNVMe struct {
 	NVMeType
+	faults mockFaults
+	state  mockState
 }
 
 // NewMockNVMe - returns a mock NVMe client
@@ -70,9 +87,20 @@ func getOptionAsInt(opts map[string]string, key string) int64 {
 	return v
 }
 
-func (nvme *MockNVMe) discoverNVMeTCPTargets(address string, _ bool) ([]NVMeTarget, error) {
-	if GONVMEMock.InduceDiscoveryError {
-		return []NVMeTarget{}, errors.New("discoverTargets induced error")
+func (nvme *MockNVMe) discoverNVMeTCPTargets(address string, login bool) ([]NVMeTarget, error) {
+	if err := nvme.injectedError("DiscoverNVMeTCPTargets", GONVMEMock.InduceDiscoveryError, errors.New("discoverTargets induced error")); err != nil {
+		return []NVMeTarget{}, err
+	}
+	if targets, ok, err := nvme.state.discover(address, NVMETransportNameTCP); ok {
+		if err != nil {
+			return []NVMeTarget{}, err
+		}
+		if login {
+			for _, target := range targets {
+				_ = nvme.nvmeTCPConnect(target, false)
+			}
+		}
+		return targets, nil
 	}
 	mockedTargets := make([]NVMeTarget, 0)
 	count := getOptionAsInt(nvme.options, MockNumberOfTCPTargets)
@@ -102,9 +130,20 @@ func (nvme *MockNVMe) discoverNVMeTCPTargets(address string, _ bool) ([]NVMeTarg
 	return mockedTargets, nil
 }
 
-func (nvme *MockNVMe) discoverNVMeFCTargets(address string, _ bool) ([]NVMeTarget, error) {
-	if GONVMEMock.InduceDiscoveryError {
-		return []NVMeTarget{}, errors.New("discoverTargets induced error")
+func (nvme *MockNVMe) discoverNVMeFCTargets(address string, login bool) ([]NVMeTarget, error) {
+	if err := nvme.injectedError("DiscoverNVMeFCTargets", GONVMEMock.InduceDiscoveryError, errors.New("discoverTargets induced error")); err != nil {
+		return []NVMeTarget{}, err
+	}
+	if targets, ok, err := nvme.state.discover(address, NVMETransportNameFC); ok {
+		if err != nil {
+			return []NVMeTarget{}, err
+		}
+		if login {
+			for _, target := range targets {
+				_ = nvme.nvmeFCConnect(target, false)
+			}
+		}
+		return targets, nil
 	}
 	mockedTargets := make([]NVMeTarget, 0)
 	count := getOptionAsInt(nvme.options, MockNumberOfFCTargets)
@@ -136,8 +175,11 @@ func (nvme *MockNVMe) discoverNVMeFCTargets(address string, _ bool) ([]NVMeTarge
 }
 
 func (nvme *MockNVMe) getInitiators(_ string) ([]string, error) {
-	if GONVMEMock.InduceInitiatorError {
-		return []string{}, errors.New("getInitiators induced error")
+	if err := nvme.injectedError("GetInitiators", GONVMEMock.InduceInitiatorError, errors.New("getInitiators induced error")); err != nil {
+		return []string{}, err
+	}
+	if hostNQN, _, ok := nvme.state.host(); ok {
+		return []string{hostNQN}, nil
 	}
 
 	mockedInitiators := make([]string, 0)
@@ -155,42 +197,254 @@ func (nvme *MockNVMe) getInitiators(_ string) ([]string, error) {
 }
 
 func (nvme *MockNVMe) getHostID() (string, error) {
-	if GONVMEMock.InduceInitiatorError {
-		return "", errors.New("getHostID induced error")
+	if err := nvme.injectedError("GetHostID", GONVMEMock.InduceInitiatorError, errors.New("getHostID induced error")); err != nil {
+		return "", err
+	}
+	if _, hostID, ok := nvme.state.host(); ok {
+		return hostID, nil
 	}
 
 	// Return a mock host ID
 	return "a2d57d74-a198-4e6b-aa78-97af9cd00f31", nil
 }
 
-func (nvme *MockNVMe) nvmeTCPConnect(_ NVMeTarget, _ bool) error {
-	if GONVMEMock.InduceTCPLoginError {
-		return errors.New("NVMeTCP Login induced error")
+func (nvme *MockNVMe) nvmeTCPConnect(target NVMeTarget, duplicateConnect bool) error {
+	if err := nvme.injectedError("NVMeTCPConnect", GONVMEMock.InduceTCPLoginError, errors.New("NVMeTCP Login induced error")); err != nil {
+		return err
+	}
+	if nvme.stateful() {
+		return nvme.state.connect(target, NVMETransportNameTCP, duplicateConnect)
 	}
 
 	return nil
 }
 
-func (nvme *MockNVMe) nvmeFCConnect(_ NVMeTarget, _ bool) error {
-	if GONVMEMock.InduceFCLoginError {
-		return errors.New("NVMeFC Login induced error")
+func (nvme *MockNVMe) nvmeFCConnect(target NVMeTarget, duplicateConnect bool) error {
+	if err := nvme.injectedError("NVMeFCConnect", GONVMEMock.InduceFCLoginError, errors.New("NVMeFC Login induced error")); err != nil {
+		return err
+	}
+	if nvme.stateful() {
+		return nvme.state.connect(target, NVMETransportNameFC, duplicateConnect)
 	}
 
 	return nil
 }
 
-func (nvme *MockNVMe) nvmeDisconnect(_ NVMeTarget) error {
-	if GONVMEMock.InduceLogoutError {
-		return errors.New("NVMe Logout induced error")
+func (nvme *MockNVMe) nvmeDisconnect(target NVMeTarget) error {
+	if err := nvme.injectedError("NVMeDisconnect", GONVMEMock.InduceLogoutError, errors.New("NVMe Logout induced error")); err != nil {
+		return err
+	}
+	if nvme.stateful() {
+		nvme.state.disconnect(func(session NVMESession) bool { return session.Target == target.TargetNqn })
 	}
 
 	return nil
 }
 
+// DisconnectController will attempt to disconnect a single mocked controller
+func (nvme *MockNVMe) DisconnectController(controller string) error {
+	if err := nvme.injectedError("DisconnectController", GONVMEMock.InduceLogoutError, errors.New("NVMe Logout induced error")); err != nil {
+		return err
+	}
+	if nvme.stateful() {
+		name := path.Base(controller)
+		if nvme.state.disconnect(func(session NVMESession) bool { return session.Name == name }) == 0 {
+			return fmt.Errorf("controller %s not found", controller)
+		}
+	}
+	return nil
+}
+
+// DisconnectPath will attempt to disconnect the mocked controllers reached through a portal
+func (nvme *MockNVMe) DisconnectPath(nqn string, portal string, hostAddr string) ([]string, error) {
+	if err := nvme.injectedError("DisconnectPath", false, nil); err != nil {
+		return nil, err
+	}
+	return nvme.DisconnectAll(SessionFilter{TargetNqn: nqn, Portal: portal, HostAddress: hostAddr})
+}
+
+// DisconnectAll will attempt to disconnect every mocked controller matching the filter
+func (nvme *MockNVMe) DisconnectAll(filter SessionFilter) ([]string, error) {
+	if err := nvme.injectedError("DisconnectAll", false, nil); err != nil {
+		return nil, err
+	}
+	sessions, err := nvme.getSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	var disconnected []string
+	failed := ControllerErrors{}
+	for _, session := range sessions {
+		if !filter.Match(session) {
+			continue
+		}
+		if err := nvme.DisconnectController(session.Name); err != nil {
+			failed[session.Name] = err
+			continue
+		}
+		disconnected = append(disconnected, session.Name)
+	}
+	if len(failed) > 0 {
+		return disconnected, failed
+	}
+	return disconnected, nil
+}
+
+// SafeDisconnect will attempt to log out of an NVMe target whose namespaces are not in use
+func (nvme *MockNVMe) SafeDisconnect(_ context.Context, target NVMeTarget, policy DisconnectPolicy) error {
+	inUse := &DeviceInUseError{
+		TargetNqn: target.TargetNqn,
+		Users:     []DeviceUser{{Device: "/dev/nvme0n1", Kind: DeviceUserMount, Detail: "/mnt/mock"}},
+	}
+	if err := nvme.injectedError("SafeDisconnect", GONVMEMock.InduceDeviceInUseError && policy != DisconnectFlush, inUse); err != nil {
+		return err
+	}
+	return nvme.nvmeDisconnect(target)
+}
+
+// GetController returns the attributes of a mocked controller
+func (nvme *MockNVMe) GetController(name string) (Controller, error) {
+	if err := nvme.injectedError("GetController", false, nil); err != nil {
+		return Controller{}, err
+	}
+	controllers, err := nvme.ListControllers()
+	if err != nil {
+		return Controller{}, err
+	}
+	for _, controller := range controllers {
+		if controller.Name == path.Base(name) {
+			return controller, nil
+		}
+	}
+	return Controller{}, fmt.Errorf("controller %s not found", name)
+}
+
+// ListControllers returns the attributes of the mocked controllers, one per mocked session
+func (nvme *MockNVMe) ListControllers() ([]Controller, error) {
+	if err := nvme.injectedError("ListControllers", GONVMEMock.InduceGetControllerError, errors.New("getController induced error")); err != nil {
+		return nil, err
+	}
+	sessions, err := nvme.getSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	controllers := []Controller{}
+	for idx, session := range sessions {
+		controllers = append(controllers, Controller{
+			Name:           session.Name,
+			State:          session.NVMESessionState,
+			Cntlid:         uint16(idx + 1),
+			SubsysNQN:      session.Target,
+			ModelNumber:    "Mock NVMe Controller",
+			SerialNumber:   fmt.Sprintf("MOCK%04d", idx),
+			Transport:      session.NVMETransportName,
+			Address:        fmt.Sprintf("traddr=%s,trsvcid=4420", session.Portal),
+			TrAddr:         session.Portal,
+			TrSvcID:        "4420",
+			QueueCount:     5,
+			SQSize:         127,
+			KATO:           5,
+			ReconnectDelay: 10,
+			CtrlLossTmo:    600,
+			FastIOFailTmo:  ControllerTimeoutOff,
+			NumaNode:       -1,
+		})
+	}
+	return controllers, nil
+}
+
+// UpdateControllerTimeouts validates the timeouts and returns the mocked controller updated with them
+func (nvme *MockNVMe) UpdateControllerTimeouts(name string, timeouts ControllerTimeouts) (Controller, error) {
+	if err := nvme.injectedError("UpdateControllerTimeouts", GONVMEMock.InduceUpdateTimeoutsError, errors.New("updateControllerTimeouts induced error")); err != nil {
+		return Controller{}, err
+	}
+	controller, err := nvme.GetController(name)
+	if err != nil {
+		return Controller{}, err
+	}
+	if err := validateTimeouts(controller, timeouts); err != nil {
+		return controller, err
+	}
+	if timeouts.ReconnectDelay != nil {
+		controller.ReconnectDelay = *timeouts.ReconnectDelay
+	}
+	if timeouts.CtrlLossTmo != nil {
+		controller.CtrlLossTmo = *timeouts.CtrlLossTmo
+	}
+	if timeouts.FastIOFailTmo != nil {
+		controller.FastIOFailTmo = *timeouts.FastIOFailTmo
+	}
+	return controller, nil
+}
+
+// UpdateSubsystemTimeouts updates the timeouts of the mocked controllers of the subsystem NQN
+func (nvme *MockNVMe) UpdateSubsystemTimeouts(nqn string, timeouts ControllerTimeouts) error {
+	if err := nvme.injectedError("UpdateSubsystemTimeouts", false, nil); err != nil {
+		return err
+	}
+	controllers, err := nvme.ListControllers()
+	if err != nil {
+		return err
+	}
+
+	found := false
+	failed := ControllerErrors{}
+	for _, controller := range controllers {
+		if controller.SubsysNQN != nqn {
+			continue
+		}
+		found = true
+		if _, err := nvme.UpdateControllerTimeouts(controller.Name, timeouts); err != nil {
+			failed[controller.Name] = err
+		}
+	}
+	if !found {
+		return fmt.Errorf("no controllers found for subsystem %s", nqn)
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
+
+// ResetController resets a mocked controller
+func (nvme *MockNVMe) ResetController(name string) error {
+	if err := nvme.injectedError("ResetController", GONVMEMock.InduceResetControllerError, errors.New("resetController induced error")); err != nil {
+		return err
+	}
+	_, err := nvme.GetController(name)
+	return err
+}
+
+// TuneNamespaceQueue validates the settings of a mocked namespace
+func (nvme *MockNVMe) TuneNamespaceQueue(_ string, settings NamespaceQueueSettings) error {
+	if err := nvme.injectedError("TuneNamespaceQueue", GONVMEMock.InduceTuneQueueError, errors.New("tuneNamespaceQueue induced error")); err != nil {
+		return err
+	}
+	return settings.validate()
+}
+
+// SetCoreMaxRetries validates the mocked nvme_core max_retries
+func (nvme *MockNVMe) SetCoreMaxRetries(retries int) error {
+	if err := nvme.injectedError("SetCoreMaxRetries", GONVMEMock.InduceTuneQueueError, errors.New("setCoreMaxRetries induced error")); err != nil {
+		return err
+	}
+	return validateMaxRetries(retries)
+}
+
 // GetNVMeDeviceData returns the information (nguid and namespace) of an NVME device path
-func (nvme *MockNVMe) GetNVMeDeviceData(_ string) (string, string, error) {
-	if GONVMEMock.InducedNVMeDeviceDataError {
-		return "", "", errors.New("NVMe Namespace Data Induced Error")
+func (nvme *MockNVMe) GetNVMeDeviceData(path string) (string, string, error) {
+	if err := nvme.injectedError("GetNVMeDeviceData", GONVMEMock.InducedNVMeDeviceDataError, errors.New("NVMe Namespace Data Induced Error")); err != nil {
+		return "", "", err
+	}
+	if nvme.stateful() {
+		device, err := nvme.state.device(path)
+		if err != nil {
+			return "", "", err
+		}
+		return device.NGUID, strconv.FormatUint(uint64(device.NSID), 10), nil
 	}
 
 	nguid := "1a111a1111aa11111aaa1111111111a1"
@@ -200,9 +454,18 @@ func (nvme *MockNVMe) GetNVMeDeviceData(_ string) (string, string, error) {
 }
 
 // ListNVMeNamespaceID returns the namespace IDs for each NVME device path
-func (nvme *MockNVMe) ListNVMeNamespaceID(_ []DevicePathAndNamespace) (map[DevicePathAndNamespace][]string, error) {
-	if GONVMEMock.InducedNVMeNamespaceIDError {
-		return map[DevicePathAndNamespace][]string{}, errors.New("listNamespaceID induced error")
+func (nvme *MockNVMe) ListNVMeNamespaceID(devices []DevicePathAndNamespace) (map[DevicePathAndNamespace][]string, error) {
+	if err := nvme.injectedError("ListNVMeNamespaceID", GONVMEMock.InducedNVMeNamespaceIDError, errors.New("listNamespaceID induced error")); err != nil {
+		return map[DevicePathAndNamespace][]string{}, err
+	}
+	if nvme.stateful() {
+		namespaceIDs := make(map[DevicePathAndNamespace][]string)
+		for _, d := range devices {
+			if device, err := nvme.state.device(d.DevicePath); err == nil {
+				namespaceIDs[d] = []string{fmt.Sprintf("0x%x", device.NSID)}
+			}
+		}
+		return namespaceIDs, nil
 	}
 
 	mockedNamespaceIDs := make(map[DevicePathAndNamespace][]string)
@@ -225,10 +488,58 @@ func (nvme *MockNVMe) ListNVMeNamespaceID(_ []DevicePathAndNamespace) (map[Devic
 	return mockedNamespaceIDs, nil
 }
 
+// ListNamespaces returns the mocked namespace IDs for each controller
+func (nvme *MockNVMe) ListNamespaces(controllers []string, _ ListNamespacesOptions) ([]ControllerNamespaces, error) {
+	result := make([]ControllerNamespaces, 0, len(controllers))
+	failed := ControllerErrors{}
+
+	count := getOptionAsInt(nvme.options, MockNumberOfNamespaceDevices)
+	if count == 0 {
+		count = 1
+	}
+
+	induced := nvme.injectedError("ListNamespaces", GONVMEMock.InducedNVMeNamespaceIDError, errors.New("listNamespaces induced error"))
+	for _, ctrl := range controllers {
+		if induced != nil {
+			failed[ctrl] = induced
+			result = append(result, ControllerNamespaces{Controller: ctrl, Err: induced})
+			continue
+		}
+		if nvme.stateful() {
+			nsids, err := nvme.state.controllerNamespaces(path.Base(ctrl))
+			if err != nil {
+				failed[ctrl] = err
+			}
+			result = append(result, ControllerNamespaces{Controller: ctrl, NSIDs: nsids, Err: err})
+			continue
+		}
+		var nsids []uint32
+		for idx := 1; idx <= int(count); idx++ {
+			nsids = append(nsids, uint32(idx)) // #nosec G115
+		}
+		result = append(result, ControllerNamespaces{Controller: ctrl, NSIDs: nsids})
+	}
+
+	if len(failed) > 0 {
+		return result, failed
+	}
+	return result, nil
+}
+
 // ListNVMeDeviceAndNamespace returns the Device Paths and Namespace of each NVMe device and each output content
 func (nvme *MockNVMe) ListNVMeDeviceAndNamespace() ([]DevicePathAndNamespace, error) {
-	if GONVMEMock.InducedNVMeDeviceAndNamespaceError {
-		return []DevicePathAndNamespace{}, errors.New("listNamespaceDevices induced error")
+	if err := nvme.injectedError("ListNVMeDeviceAndNamespace", GONVMEMock.InducedNVMeDeviceAndNamespaceError, errors.New("listNamespaceDevices induced error")); err != nil {
+		return []DevicePathAndNamespace{}, err
+	}
+	if nvme.stateful() {
+		devices := []DevicePathAndNamespace{}
+		for _, device := range nvme.state.devices() {
+			devices = append(devices, DevicePathAndNamespace{
+				DevicePath: device.Path,
+				Namespace:  strconv.FormatUint(uint64(device.NSID), 10),
+			})
+		}
+		return devices, nil
 	}
 
 	var mockedDeviceAndNamespaces []DevicePathAndNamespace
@@ -249,9 +560,68 @@ func (nvme *MockNVMe) ListNVMeDeviceAndNamespace() ([]DevicePathAndNamespace, er
 	return mockedDeviceAndNamespaces, nil
 }
 
+// GetInventory returns an inventory holding the mocked namespace devices
+func (nvme *MockNVMe) GetInventory() (Inventory, error) {
+	if err := nvme.injectedError("GetInventory", GONVMEMock.InduceGetInventoryError, errors.New("getInventory induced error")); err != nil {
+		return Inventory{}, err
+	}
+	if nvme.stateful() {
+		hostNQN, hostID, ok := nvme.state.host()
+		if !ok {
+			hostNQN, hostID = "nqn.1988-11.com.dell.mock:01:0000000000000", "a2d57d74-a198-4e6b-aa78-97af9cd00f31"
+		}
+		return nvme.state.inventory(hostNQN, hostID), nil
+	}
+
+	subsystem := InventorySubsystem{
+		Name: "nvme-subsys0",
+		NQN:  "nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D0",
+		Controllers: []InventoryController{
+			{
+				Name:         "nvme0",
+				Cntlid:       "1",
+				SerialNumber: "MOCK0001",
+				ModelNumber:  "dellemc-mock",
+				Firmware:     "1.0.0.0",
+				Transport:    NVMeTransportTypeTCP,
+				Address:      "traddr=192.168.1.0,trsvcid=4420",
+			},
+		},
+	}
+	count := getOptionAsInt(nvme.options, MockNumberOfNamespaceDevices)
+	if count == 0 {
+		count = 1
+	}
+	for idx := 0; idx < int(count); idx++ {
+		subsystem.Namespaces = append(subsystem.Namespaces, InventoryNamespace{
+			DevicePath:   "/dev/nvme0n" + fmt.Sprintf("%05d", idx),
+			NSID:         uint32(idx), // #nosec G115
+			MaximumLBA:   2097152,
+			PhysicalSize: 1073741824,
+			SectorSize:   512,
+			SerialNumber: "MOCK0001",
+			ModelNumber:  "dellemc-mock",
+			Firmware:     "1.0.0.0",
+		})
+	}
+
+	return Inventory{
+		Hosts: []InventoryHost{
+			{
+				HostNQN:    "nqn.1988-11.com.dell.mock:01:0000000000000",
+				HostID:     "a2d57d74-a198-4e6b-aa78-97af9cd00f31",
+				Subsystems: []InventorySubsystem{subsystem},
+			},
+		},
+	}, nil
+}
+
 func (nvme *MockNVMe) getSessions() ([]NVMESession, error) {
-	if GONVMEMock.InduceGetSessionsError {
-		return []NVMESession{}, errors.New("getSessions induced error")
+	if err := nvme.injectedError("GetSessions", GONVMEMock.InduceGetSessionsError, errors.New("getSessions induced error")); err != nil {
+		return []NVMESession{}, err
+	}
+	if nvme.stateful() {
+		return nvme.state.getSessions(), nil
 	}
 
 	var sessions []NVMESession
@@ -285,6 +655,62 @@ func (nvme *MockNVMe) DiscoverNVMeFCTargets(address string, login bool) ([]NVMeT
 	return nvme.discoverNVMeFCTargets(address, login)
 }
 
+// DiscoverAllNVMeFCTargets runs an NVMe discovery on every mocked remote port and returns a list of targets.
+func (nvme *MockNVMe) DiscoverAllNVMeFCTargets(login bool) ([]NVMeTarget, error) {
+	if err := nvme.injectedError("DiscoverAllNVMeFCTargets", false, nil); err != nil {
+		return []NVMeTarget{}, err
+	}
+	rports, err := nvme.ListFCRemotePorts()
+	if err != nil {
+		return []NVMeTarget{}, err
+	}
+	targets := make([]NVMeTarget, 0)
+	for _, rport := range rports {
+		found, err := nvme.discoverNVMeFCTargets(fcTransportAddress(rport.NodeName, rport.PortName), login)
+		if err != nil {
+			return []NVMeTarget{}, err
+		}
+		targets = append(targets, found...)
+	}
+	return targets, nil
+}
+
+// ListFCRemotePorts returns a mocked NVMe remote port per mocked FC target
+func (nvme *MockNVMe) ListFCRemotePorts() ([]FCRemotePort, error) {
+	if err := nvme.injectedError("ListFCRemotePorts", GONVMEMock.InduceDiscoveryError, errors.New("listFCRemotePorts induced error")); err != nil {
+		return nil, err
+	}
+	count := getOptionAsInt(nvme.options, MockNumberOfFCTargets)
+	if count == 0 {
+		count = 1
+	}
+
+	rports := []FCRemotePort{}
+	for idx := 0; idx < int(count); idx++ {
+		rports = append(rports, FCRemotePort{
+			Name:      fmt.Sprintf("rport-1:0-%d", idx),
+			Host:      "host1",
+			PortName:  fmt.Sprintf("0x58ccf091492b%04x", idx),
+			NodeName:  "0x58ccf090c9200bcf",
+			PortID:    fmt.Sprintf("0x%06x", 0x010100+idx),
+			PortState: FCPortStateOnline,
+			Roles:     []string{FCRoleNVMeTarget, FCRoleNVMeDiscovery},
+		})
+	}
+	return rports, nil
+}
+
+// RescanFCHost rescans a mocked FC host
+func (nvme *MockNVMe) RescanFCHost(host string, _ RescanFCHostOptions) error {
+	if err := nvme.injectedError("RescanFCHost", GONVMEMock.InduceRescanFCHostError, errors.New("rescanFCHost induced error")); err != nil {
+		return err
+	}
+	if !fcHostNameRegexp.MatchString(path.Base(host)) {
+		return fmt.Errorf("invalid FC host name %q", host)
+	}
+	return nil
+}
+
 // GetInitiators returns a list of NVMe initiators on the local system.
 func (nvme *MockNVMe) GetInitiators(filename string) ([]string, error) {
 	return nvme.getInitiators(filename)
@@ -295,6 +721,44 @@ func (nvme *MockNVMe) GetHostID() (string, error) {
 	return nvme.getHostID()
 }
 
+// CLIVersion returns the mocked nvme-cli version, 2.11
+func (nvme *MockNVMe) CLIVersion() (NVMeCLIVersion, error) {
+	if err := nvme.injectedError("CLIVersion", GONVMEMock.InduceCLIVersionError, errors.New("cliVersion induced error")); err != nil {
+		return NVMeCLIVersion{}, err
+	}
+	return NVMeCLIVersion{Major: 2, Minor: 11, LibNVMe: "1.11"}, nil
+}
+
+// Available returns nil, nvme-cli being always available to the mock
+func (nvme *MockNVMe) Available() error {
+	return nvme.injectedError("Available", GONVMEMock.InduceAvailableError,
+		&BinaryNotFoundError{Root: "/", Paths: getPaths()})
+}
+
+// CheckPrerequisites reports a mocked host ready to connect over the transports
+func (nvme *MockNVMe) CheckPrerequisites(transports []string) (PrerequisiteReport, error) {
+	if err := nvme.injectedError("CheckPrerequisites", GONVMEMock.InduceCheckPrerequisitesError, errors.New("checkPrerequisites induced error")); err != nil {
+		return PrerequisiteReport{}, err
+	}
+	report := PrerequisiteReport{
+		Modules:       []ModuleStatus{{Name: moduleNVMeCore, Loaded: true}, {Name: moduleNVMeFabric, Loaded: true}},
+		FabricsDevice: true,
+		Multipath:     "Y",
+	}
+	if nqns, err := nvme.getInitiators(""); err == nil && len(nqns) > 0 {
+		report.HostNQN = nqns[0]
+	}
+	report.HostID, _ = nvme.getHostID()
+	for _, transport := range transports {
+		module, ok := transportModules[transport]
+		if !ok {
+			return PrerequisiteReport{}, fmt.Errorf("unknown NVMe transport %q", transport)
+		}
+		report.Modules = append(report.Modules, ModuleStatus{Name: module, Loaded: true})
+	}
+	return report, nil
+}
+
 // NVMeTCPConnect will attempt to log into an NVMe target
 func (nvme *MockNVMe) NVMeTCPConnect(target NVMeTarget, duplicateConnect bool) error {
 	return nvme.nvmeTCPConnect(target, duplicateConnect)
@@ -315,14 +779,58 @@ func (nvme *MockNVMe) GetSessions() ([]NVMESession, error) {
 	return nvme.getSessions()
 }
 
+// Reconcile converges the mocked connections to the desired paths
+func (nvme *MockNVMe) Reconcile(ctx context.Context, desired []NVMeTarget, opts ReconcileOptions) (ReconcileReport, error) {
+	if err := nvme.injectedError("Reconcile", false, nil); err != nil {
+		return ReconcileReport{}, err
+	}
+	return reconcile(ctx, nvme, desired, opts)
+}
+
 // DeviceRescan rescan the NVMe device
 func (nvme *MockNVMe) DeviceRescan(device string) error {
 	return nvme.deviceRescan(device)
 }
 
 func (nvme *MockNVMe) deviceRescan(_ string) error {
-	if GONVMEMock.InduceGetSessionsError {
-		return errors.New("deviceRescan induced error")
+	// InduceGetSessionsError used to be the only way to fail DeviceRescan and is still honoured
+	if err := nvme.injectedError("DeviceRescan", GONVMEMock.InduceDeviceRescanError || GONVMEMock.InduceGetSessionsError, errors.New("deviceRescan induced error")); err != nil {
+		return err
 	}
 	return nil
 }
+
+// RescanSubsystem rescans the mocked controllers of a subsystem
+func (nvme *MockNVMe) RescanSubsystem(_ string) error {
+	return nvme.injectedError("RescanSubsystem", GONVMEMock.InduceRescanError,
+		ControllerErrors{"nvme0": errors.New("rescanSubsystem induced error")})
+}
+
+// RescanAll rescans every mocked controller
+func (nvme *MockNVMe) RescanAll() error {
+	return nvme.injectedError("RescanAll", GONVMEMock.InduceRescanError,
+		ControllerErrors{"nvme0": errors.New("rescanAll induced error")})
+}
+
+// RefreshNamespaceCapacity returns an unchanged capacity for the mocked namespace device
+func (nvme *MockNVMe) RefreshNamespaceCapacity(_ context.Context, device string) (NamespaceCapacity, error) {
+	if err := nvme.injectedError("RefreshNamespaceCapacity", GONVMEMock.InduceRefreshCapacityError, errors.New("refreshNamespaceCapacity induced error")); err != nil {
+		return NamespaceCapacity{}, err
+	}
+
+	size := uint64(1073741824)
+	if nvme.stateful() {
+		namespace, err := nvme.state.device(device)
+		if err != nil {
+			return NamespaceCapacity{}, err
+		}
+		size = namespace.Size
+	}
+	return NamespaceCapacity{
+		Device:       device,
+		OldSize:      size,
+		NewSize:      size,
+		IdentifySize: size,
+		PathSizes:    map[string]uint64{},
+	}, nil
+}
@@ -0,0 +1,148 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MockFaultSeed seeds the random failures of the faults injected in a mock, for reproducible tests
+const MockFaultSeed = "faultSeed"
+
+// MockFault is a failure injected into a method of a MockNVMe.
+// Without OnCall and Probability, every call of the method fails.
+type MockFault struct {
+	// Err is returned by the method, an "induced error" naming the method when nil
+	Err error
+	// OnCall fails only the Nth call of the method made after the fault is injected, 1 being the next call
+	OnCall int
+	// Probability fails the calls at random with this probability, between 0 and 1
+	Probability float64
+	// Latency delays every call of the method, whether it fails or not
+	Latency time.Duration
+	// LatencyOnly delays the calls without failing them
+	LatencyOnly bool
+}
+
+// mockFaults holds the faults injected into a MockNVMe, keyed by method name
+type mockFaults struct {
+	mu     sync.Mutex
+	faults map[string]*injectedFault
+	calls  map[string]int
+	rand   *rand.Rand
+}
+
+type injectedFault struct {
+	MockFault
+	calls int
+}
+
+// InjectFault makes the method, named as in NVMEinterface (NVMeTCPConnect for instance), fail as described
+// by the fault, replacing any fault previously injected into it. Methods without an injected fault fall
+// back to the GONVMEMock flags. Faults also apply to the calls a mocked method makes to the others,
+// GetController failing with ListControllers and GetSessions for instance. Safe for concurrent use.
+func (nvme *MockNVMe) InjectFault(method string, fault MockFault) {
+	nvme.faults.mu.Lock()
+	defer nvme.faults.mu.Unlock()
+	if nvme.faults.faults == nil {
+		nvme.faults.faults = map[string]*injectedFault{}
+	}
+	nvme.faults.faults[method] = &injectedFault{MockFault: fault}
+}
+
+// ClearFault removes the fault injected into the method
+func (nvme *MockNVMe) ClearFault(method string) {
+	nvme.faults.mu.Lock()
+	defer nvme.faults.mu.Unlock()
+	delete(nvme.faults.faults, method)
+}
+
+// ClearFaults removes every injected fault and resets the call counts
+func (nvme *MockNVMe) ClearFaults() {
+	nvme.faults.mu.Lock()
+	defer nvme.faults.mu.Unlock()
+	nvme.faults.faults = nil
+	nvme.faults.calls = nil
+}
+
+// Calls returns the number of calls made to the method, failed ones included
+func (nvme *MockNVMe) Calls(method string) int {
+	nvme.faults.mu.Lock()
+	defer nvme.faults.mu.Unlock()
+	return nvme.faults.calls[method]
+}
+
+// injectedError counts a call to the method and returns the error of the fault injected into it, after
+// its latency. Without injected fault, induced falls back to the GONVMEMock flag and err to its error.
+func (nvme *MockNVMe) injectedError(method string, induced bool, err error) error {
+	latency, injected, failed := nvme.faults.call(method, nvme.options)
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	if injected {
+		return failed
+	}
+	if induced {
+		return err
+	}
+	return nil
+}
+
+// call records a call to the method and returns the latency and the error of the fault injected into it
+func (f *mockFaults) call(method string, opts map[string]string) (time.Duration, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.calls == nil {
+		f.calls = map[string]int{}
+	}
+	f.calls[method]++
+
+	fault, ok := f.faults[method]
+	if !ok {
+		return 0, false, nil
+	}
+	fault.calls++
+	if fault.LatencyOnly {
+		return fault.Latency, true, nil
+	}
+	if fault.OnCall > 0 && fault.calls != fault.OnCall {
+		return fault.Latency, true, nil
+	}
+	if fault.Probability > 0 && f.random(opts).Float64() >= fault.Probability {
+		return fault.Latency, true, nil
+	}
+	if fault.Err != nil {
+		return fault.Latency, true, fault.Err
+	}
+	return fault.Latency, true, fmt.Errorf("%s induced error", method)
+}
+
+// random returns the generator of the probabilistic faults, seeded by MockFaultSeed when set
+func (f *mockFaults) random(opts map[string]string) *rand.Rand {
+	if f.rand == nil {
+		seed, err := strconv.ParseUint(opts[MockFaultSeed], 10, 64)
+		if err != nil {
+			seed = rand.Uint64()
+		}
+		f.rand = rand.New(rand.NewPCG(seed, seed)) // #nosec G404
+	}
+	return f.rand
+}
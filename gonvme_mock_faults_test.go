@@ -0,0 +1,142 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var mockFaultTarget = NVMeTarget{Portal: "1.1.1.1", TargetNqn: "nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D0"}
+
+func TestMockFaultsPerInstance(t *testing.T) {
+	// the parallel tests run once the others are done with the GONVMEMock flags
+	GONVMEMock.InduceTCPLoginError = false
+	GONVMEMock.InduceGetSessionsError = false
+	t.Parallel()
+
+	errLogin := errors.New("authentication failed")
+	failing := NewMockNVMe(map[string]string{})
+	failing.InjectFault("NVMeTCPConnect", MockFault{Err: errLogin})
+	healthy := NewMockNVMe(map[string]string{})
+
+	assert.ErrorIs(t, failing.NVMeTCPConnect(mockFaultTarget, false), errLogin)
+	assert.NoError(t, healthy.NVMeTCPConnect(mockFaultTarget, false))
+
+	// without error value, the error names the method
+	failing.InjectFault("GetSessions", MockFault{})
+	_, err := failing.GetSessions()
+	assert.ErrorContains(t, err, "GetSessions induced error")
+	// and composite methods fail with the methods they call
+	_, err = failing.GetController("nvme0")
+	assert.Error(t, err)
+
+	failing.ClearFault("NVMeTCPConnect")
+	assert.NoError(t, failing.NVMeTCPConnect(mockFaultTarget, false))
+	assert.Equal(t, 2, failing.Calls("NVMeTCPConnect"))
+	assert.Equal(t, 1, healthy.Calls("NVMeTCPConnect"))
+
+	failing.ClearFaults()
+	_, err = failing.GetSessions()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, failing.Calls("GetSessions"))
+}
+
+func TestMockFaultOnCall(t *testing.T) {
+	GONVMEMock.InduceLogoutError = false
+	t.Parallel()
+
+	nvme := NewMockNVMe(map[string]string{})
+	nvme.InjectFault("DisconnectController", MockFault{OnCall: 2})
+
+	assert.NoError(t, nvme.DisconnectController("nvme0"))
+	assert.Error(t, nvme.DisconnectController("nvme0"))
+	assert.NoError(t, nvme.DisconnectController("nvme0"))
+}
+
+func TestMockFaultProbability(t *testing.T) {
+	GONVMEMock.InduceDiscoveryError = false
+	t.Parallel()
+
+	failures := func(seed string) int {
+		nvme := NewMockNVMe(map[string]string{MockFaultSeed: seed})
+		nvme.InjectFault("DiscoverNVMeTCPTargets", MockFault{Probability: 0.25})
+		n := 0
+		for i := 0; i < 1000; i++ {
+			if _, err := nvme.DiscoverNVMeTCPTargets("1.1.1.1", false); err != nil {
+				n++
+			}
+		}
+		return n
+	}
+
+	n := failures("42")
+	assert.InDelta(t, 250, n, 75)
+	assert.Equal(t, n, failures("42"))
+}
+
+func TestMockFaultLatency(t *testing.T) {
+	GONVMEMock.InduceResetControllerError = false
+	GONVMEMock.InduceGetControllerError = false
+	GONVMEMock.InduceGetSessionsError = false
+	t.Parallel()
+
+	nvme := NewMockNVMe(map[string]string{})
+	nvme.InjectFault("ResetController", MockFault{Latency: 20 * time.Millisecond, LatencyOnly: true})
+
+	start := time.Now()
+	assert.NoError(t, nvme.ResetController("nvme0"))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+}
+
+func TestMockFaultsConcurrentUse(t *testing.T) {
+	GONVMEMock.InduceFCLoginError = false
+	t.Parallel()
+
+	nvme := NewMockNVMe(map[string]string{})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			nvme.InjectFault("NVMeFCConnect", MockFault{OnCall: 3})
+		}()
+		go func() {
+			defer wg.Done()
+			_ = nvme.NVMeFCConnect(mockFaultTarget, false)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 8, nvme.Calls("NVMeFCConnect"))
+}
+
+func TestMockFaultsGlobalFallback(t *testing.T) {
+	GONVMEMock.InduceTuneQueueError = true
+	defer func() { GONVMEMock.InduceTuneQueueError = false }()
+
+	nvme := NewMockNVMe(map[string]string{})
+	assert.Error(t, nvme.TuneNamespaceQueue("/dev/nvme0n1", NamespaceQueueSettings{}))
+
+	// an injected fault takes precedence over the global flag
+	nvme.InjectFault("TuneNamespaceQueue", MockFault{OnCall: 2})
+	assert.NoError(t, nvme.TuneNamespaceQueue("/dev/nvme0n1", NamespaceQueueSettings{}))
+	assert.Error(t, nvme.TuneNamespaceQueue("/dev/nvme0n1", NamespaceQueueSettings{}))
+}
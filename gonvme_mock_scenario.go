@@ -0,0 +1,235 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MockScenario describes the host and the storage arrays seen by a mock, see NewMockNVMeFromScenario
+type MockScenario struct {
+	Host     MockScenarioHost      `json:"host" yaml:"host"`
+	Arrays   []MockScenarioArray   `json:"arrays" yaml:"arrays"`
+	Failures []MockScenarioFailure `json:"failures" yaml:"failures"`
+}
+
+// MockScenarioHost is the identity of the mocked host
+type MockScenarioHost struct {
+	NQN string `json:"nqn" yaml:"nqn"`
+	ID  string `json:"id" yaml:"id"`
+}
+
+// MockScenarioArray is a storage array: every subsystem of the array is discovered through each of its portals
+type MockScenarioArray struct {
+	Name       string                  `json:"name" yaml:"name"`
+	Portals    []MockScenarioPortal    `json:"portals" yaml:"portals"`
+	Subsystems []MockScenarioSubsystem `json:"subsystems" yaml:"subsystems"`
+}
+
+// MockScenarioPortal is a target port of an array
+type MockScenarioPortal struct {
+	// Address is the IP address of a TCP portal or the nn-0x...:pn-0x... address of an FC one
+	Address string `json:"address" yaml:"address"`
+	// Transport is tcp, the default, or fc
+	Transport string `json:"transport" yaml:"transport"`
+	// HostAddress is the address of the local FC port the portal is reached through
+	HostAddress string `json:"hostAddress" yaml:"hostAddress"`
+	// Connected connects the subsystems of the array through the portal when the mock is created
+	Connected bool `json:"connected" yaml:"connected"`
+}
+
+// MockScenarioSubsystem is an NVMe subsystem of an array
+type MockScenarioSubsystem struct {
+	NQN        string          `json:"nqn" yaml:"nqn"`
+	Namespaces []MockNamespace `json:"namespaces" yaml:"namespaces"`
+}
+
+// MockScenarioFailure is a fault injected into a method of the mock, see MockFault
+type MockScenarioFailure struct {
+	// Method is named as in NVMEinterface, NVMeTCPConnect for instance
+	Method      string  `json:"method" yaml:"method"`
+	Error       string  `json:"error" yaml:"error"`
+	OnCall      int     `json:"onCall" yaml:"onCall"`
+	Probability float64 `json:"probability" yaml:"probability"`
+	// Latency is a duration, "250ms" for instance
+	Latency     string `json:"latency" yaml:"latency"`
+	LatencyOnly bool   `json:"latencyOnly" yaml:"latencyOnly"`
+}
+
+// NewMockNVMeFromScenario returns a stateful mock, see MockStateful, set up from a YAML or JSON scenario file,
+// JSON being expected for a .json file. Discovery on a portal returns the subsystems of its array.
+func NewMockNVMeFromScenario(path string) (*MockNVMe, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var scenario MockScenario
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&scenario)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&scenario)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid mock scenario %s: %w", path, err)
+	}
+
+	nvme := NewMockNVMe(map[string]string{MockStateful: "true"})
+	if err := nvme.loadScenario(scenario); err != nil {
+		return nil, fmt.Errorf("invalid mock scenario %s: %w", path, err)
+	}
+	return nvme, nil
+}
+
+// loadScenario sets the topology, the connections and the failures of the scenario
+func (nvme *MockNVMe) loadScenario(scenario MockScenario) error {
+	nvme.state.mu.Lock()
+	nvme.state.hostNQN = scenario.Host.NQN
+	nvme.state.hostID = scenario.Host.ID
+	nvme.state.mu.Unlock()
+
+	for _, array := range scenario.Arrays {
+		for _, portal := range array.Portals {
+			if portal.Address == "" {
+				return fmt.Errorf("array %s has a portal without address", array.Name)
+			}
+			if _, err := scenarioTransport(portal.Transport); err != nil {
+				return fmt.Errorf("array %s portal %s: %w", array.Name, portal.Address, err)
+			}
+		}
+		for _, subsystem := range array.Subsystems {
+			if subsystem.NQN == "" {
+				return fmt.Errorf("array %s has a subsystem without NQN", array.Name)
+			}
+			for _, ns := range subsystem.Namespaces {
+				if err := nvme.AddNamespace(subsystem.NQN, ns); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	nvme.state.mu.Lock()
+	nvme.state.arrays = scenario.Arrays
+	nvme.state.mu.Unlock()
+
+	for _, array := range scenario.Arrays {
+		for _, portal := range array.Portals {
+			if !portal.Connected {
+				continue
+			}
+			transport, _ := scenarioTransport(portal.Transport)
+			for _, subsystem := range array.Subsystems {
+				target := NVMeTarget{TargetNqn: subsystem.NQN, Portal: portal.Address, HostAdr: portal.HostAddress}
+				if err := nvme.state.connect(target, transport, false); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, failure := range scenario.Failures {
+		if failure.Method == "" {
+			return errors.New("failure without method")
+		}
+		fault := MockFault{OnCall: failure.OnCall, Probability: failure.Probability, LatencyOnly: failure.LatencyOnly}
+		if failure.Error != "" {
+			fault.Err = errors.New(failure.Error)
+		}
+		if failure.Latency != "" {
+			latency, err := time.ParseDuration(failure.Latency)
+			if err != nil {
+				return fmt.Errorf("failure of %s: %w", failure.Method, err)
+			}
+			fault.Latency = latency
+		}
+		nvme.InjectFault(failure.Method, fault)
+	}
+	return nil
+}
+
+func scenarioTransport(transport string) (NVMETransportName, error) {
+	switch NVMETransportName(transport) {
+	case "", NVMETransportNameTCP:
+		return NVMETransportNameTCP, nil
+	case NVMETransportNameFC:
+		return NVMETransportNameFC, nil
+	}
+	return "", fmt.Errorf("unsupported transport %q", transport)
+}
+
+// discover returns the targets of the array owning the portal, with ok false when the mock has no scenario
+func (s *mockState) discover(address string, transport NVMETransportName) (targets []NVMeTarget, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.arrays) == 0 {
+		return nil, false, nil
+	}
+
+	for _, array := range s.arrays {
+		var portals []MockScenarioPortal
+		found := false
+		for _, portal := range array.Portals {
+			if t, _ := scenarioTransport(portal.Transport); t != transport {
+				continue
+			}
+			portals = append(portals, portal)
+			found = found || portal.Address == address
+		}
+		if !found {
+			continue
+		}
+
+		targets = []NVMeTarget{}
+		for _, subsystem := range array.Subsystems {
+			for idx, portal := range portals {
+				target := NVMeTarget{
+					Portal:     portal.Address,
+					TargetNqn:  subsystem.NQN,
+					TrType:     string(transport),
+					SubType:    "nvme subsystem",
+					Treq:       "not specified",
+					PortID:     fmt.Sprintf("%d", idx),
+					TrsvcID:    "4420",
+					SecType:    "none",
+					TargetType: string(transport),
+					AdrFam:     "ipv4",
+				}
+				if transport == NVMETransportNameFC {
+					target.AdrFam = "fibre-channel"
+					target.TrsvcID = "none"
+					target.HostAdr = portal.HostAddress
+				}
+				targets = append(targets, target)
+			}
+		}
+		return targets, true, nil
+	}
+	return nil, true, fmt.Errorf("no %s portal %s in the mock scenario", transport, address)
+}
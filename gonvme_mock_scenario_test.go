@@ -0,0 +1,117 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewMockNVMeFromScenarioYAML(t *testing.T) {
+	reflect.ValueOf(&GONVMEMock).Elem().SetZero()
+	nvme, err := NewMockNVMeFromScenario("testdata/mock/scenario.yaml")
+	assert.NoError(t, err)
+
+	initiators, err := nvme.GetInitiators("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"nqn.2014-08.org.nvmexpress:uuid:1a11111a-aa11-11aa-1111-a10aa1a11111"}, initiators)
+	hostID, err := nvme.GetHostID()
+	assert.NoError(t, err)
+	assert.Equal(t, "1a11111a-aa11-11aa-1111-a10aa1a11111", hostID)
+
+	sessions, err := nvme.GetSessions()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, "10.1.1.1", sessions[0].Portal)
+
+	devices, err := nvme.ListNVMeDeviceAndNamespace()
+	assert.NoError(t, err)
+	assert.Equal(t, []DevicePathAndNamespace{
+		{DevicePath: "/dev/nvme0n1", Namespace: "1"},
+		{DevicePath: "/dev/nvme0n2", Namespace: "2"},
+	}, devices)
+	nguid, _, err := nvme.GetNVMeDeviceData("/dev/nvme0n1")
+	assert.NoError(t, err)
+	assert.Equal(t, "507911ecda65a2498ccf0968009a5d07", nguid)
+
+	// discovery returns the subsystems of the array through each of its portals
+	targets, err := nvme.DiscoverNVMeTCPTargets("10.1.1.2", true)
+	assert.NoError(t, err)
+	assert.Len(t, targets, 2)
+	sessions, err = nvme.GetSessions()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+	_, err = nvme.DiscoverNVMeTCPTargets("10.9.9.9", false)
+	assert.Error(t, err)
+
+	// the scripted failure hits the first FC connect only
+	targets, err = nvme.DiscoverNVMeFCTargets("nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf", false)
+	assert.NoError(t, err)
+	assert.Len(t, targets, 1)
+	assert.Equal(t, "nn-0x200000109b6460e1:pn-0x100000109b6460e1", targets[0].HostAdr)
+	assert.ErrorContains(t, nvme.NVMeFCConnect(targets[0], false), "Connection refused")
+	assert.NoError(t, nvme.NVMeFCConnect(targets[0], false))
+}
+
+func TestNewMockNVMeFromScenarioJSON(t *testing.T) {
+	reflect.ValueOf(&GONVMEMock).Elem().SetZero()
+	nvme, err := NewMockNVMeFromScenario("testdata/mock/scenario.json")
+	assert.NoError(t, err)
+
+	inventory, err := nvme.GetInventory()
+	assert.NoError(t, err)
+	assert.Equal(t, "nqn.1988-11.com.mock:01:00000000000001", inventory.Hosts[0].HostNQN)
+	assert.Len(t, inventory.Hosts[0].Subsystems, 1)
+	assert.Equal(t, uint64(1048576), inventory.AllNamespaces()[0].PhysicalSize)
+	assert.Equal(t, 1, nvme.Calls("GetInventory"))
+}
+
+func TestNewMockNVMeFromScenarioErrors(t *testing.T) {
+	write := func(t *testing.T, name string, content string) string {
+		p := filepath.Join(t.TempDir(), name)
+		assert.NoError(t, os.WriteFile(p, []byte(content), 0o600))
+		return p
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		scenario string
+	}{
+		{"unknown field", "scenario.yaml", "hosts:\n  nqn: nqn.x\n"},
+		{"invalid json", "scenario.json", `{"arrays": [}`},
+		{"portal without address", "scenario.yaml", "arrays:\n  - name: a\n    portals:\n      - transport: tcp\n"},
+		{"unsupported transport", "scenario.yaml", "arrays:\n  - name: a\n    portals:\n      - address: 1.1.1.1\n        transport: rdma\n"},
+		{"subsystem without NQN", "scenario.yaml", "arrays:\n  - name: a\n    subsystems:\n      - namespaces: []\n"},
+		{"duplicate namespace", "scenario.yaml", "arrays:\n  - subsystems:\n      - nqn: nqn.x\n        namespaces: [{nsid: 1}, {nsid: 1}]\n"},
+		{"failure without method", "scenario.yaml", "failures:\n  - error: boom\n"},
+		{"invalid latency", "scenario.yaml", "failures:\n  - method: GetSessions\n    latency: soon\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMockNVMeFromScenario(write(t, tt.file, tt.scenario))
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := NewMockNVMeFromScenario("testdata/mock/missing.yaml")
+	assert.Error(t, err)
+}
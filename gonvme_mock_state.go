@@ -0,0 +1,288 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+const (
+	// MockStateful set to "true" makes the mock track its connections: connected targets become sessions,
+	// disconnects remove them and the namespaces added to a subsystem become devices once it is connected.
+	// A stateful mock starts without session, the MockNumberOf options only apply to discovery.
+	MockStateful = "stateful"
+
+	mockSectorSize = 512
+)
+
+// MockNamespace is a namespace of a mocked subsystem
+type MockNamespace struct {
+	NSID uint32 `json:"nsid" yaml:"nsid"`
+	// NGUID is generated from the subsystem and the NSID when empty
+	NGUID string `json:"nguid" yaml:"nguid"`
+	// Size is in bytes, 1GiB when zero
+	Size uint64 `json:"size" yaml:"size"`
+}
+
+// mockState holds the connections and namespaces of a stateful MockNVMe
+type mockState struct {
+	mu             sync.Mutex
+	sessions       []NVMESession
+	nextController int
+	// subsystems holds the instance of each subsystem NQN, N of its /dev/nvmeNnM namespace devices
+	subsystems map[string]int
+	namespaces map[string][]MockNamespace
+	// the host identity and the arrays of a scenario, see NewMockNVMeFromScenario
+	hostNQN string
+	hostID  string
+	arrays  []MockScenarioArray
+}
+
+// host returns the host identity of the scenario, ok false without one
+func (s *mockState) host() (nqn string, id string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hostNQN, s.hostID, s.hostNQN != "" || s.hostID != ""
+}
+
+// stateful reports whether the mock tracks its connections, see MockStateful
+func (nvme *MockNVMe) stateful() bool {
+	stateful, _ := strconv.ParseBool(nvme.options[MockStateful])
+	return stateful
+}
+
+// AddNamespace adds a namespace to the mocked subsystem NQN. The namespace is listed as a device
+// while the subsystem is connected. Returns an error if the subsystem already has the NSID.
+func (nvme *MockNVMe) AddNamespace(nqn string, ns MockNamespace) error {
+	if ns.NSID == 0 {
+		return fmt.Errorf("invalid namespace ID 0 for subsystem %s", nqn)
+	}
+	s := &nvme.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.namespaces[nqn] {
+		if existing.NSID == ns.NSID {
+			return fmt.Errorf("subsystem %s already has namespace %d", nqn, ns.NSID)
+		}
+	}
+
+	instance := s.subsystem(nqn)
+	if ns.NGUID == "" {
+		ns.NGUID = fmt.Sprintf("%016x%016x", 0x6d6f636b00000000+uint64(instance), ns.NSID) // #nosec G115
+	}
+	if ns.Size == 0 {
+		ns.Size = 1073741824
+	}
+	if s.namespaces == nil {
+		s.namespaces = map[string][]MockNamespace{}
+	}
+	s.namespaces[nqn] = append(s.namespaces[nqn], ns)
+	sort.Slice(s.namespaces[nqn], func(i, j int) bool { return s.namespaces[nqn][i].NSID < s.namespaces[nqn][j].NSID })
+	return nil
+}
+
+// RemoveNamespace removes a namespace from the mocked subsystem NQN
+func (nvme *MockNVMe) RemoveNamespace(nqn string, nsid uint32) error {
+	s := &nvme.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, ns := range s.namespaces[nqn] {
+		if ns.NSID == nsid {
+			s.namespaces[nqn] = append(s.namespaces[nqn][:i], s.namespaces[nqn][i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("subsystem %s has no namespace %d", nqn, nsid)
+}
+
+// subsystem returns the instance of the subsystem NQN, allocating one the first time
+func (s *mockState) subsystem(nqn string) int {
+	if instance, ok := s.subsystems[nqn]; ok {
+		return instance
+	}
+	if s.subsystems == nil {
+		s.subsystems = map[string]int{}
+	}
+	instance := len(s.subsystems)
+	s.subsystems[nqn] = instance
+	return instance
+}
+
+// connect adds a session for the target, rejecting a second connection of the same path unless duplicateConnect
+func (s *mockState) connect(target NVMeTarget, transport NVMETransportName, duplicateConnect bool) error {
+	if target.TargetNqn == "" || target.Portal == "" {
+		return fmt.Errorf("cannot connect target %q at %q", target.TargetNqn, target.Portal)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !duplicateConnect {
+		for _, session := range s.sessions {
+			if session.Target == target.TargetNqn && session.Portal == target.Portal && session.HostAddress == target.HostAdr {
+				return fmt.Errorf("error connecting to nvme target %s at %s: already connected as %s",
+					target.TargetNqn, target.Portal, session.Name)
+			}
+		}
+	}
+
+	s.subsystem(target.TargetNqn)
+	s.sessions = append(s.sessions, NVMESession{
+		Target:            target.TargetNqn,
+		Portal:            target.Portal,
+		Name:              fmt.Sprintf("nvme%d", s.nextController),
+		NVMESessionState:  NVMESessionStateLive,
+		NVMETransportName: transport,
+		HostAddress:       target.HostAdr,
+	})
+	s.nextController++
+	return nil
+}
+
+// disconnect removes the sessions for which remove returns true and returns their number
+func (s *mockState) disconnect(remove func(NVMESession) bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.sessions[:0]
+	for _, session := range s.sessions {
+		if !remove(session) {
+			kept = append(kept, session)
+		}
+	}
+	removed := len(s.sessions) - len(kept)
+	s.sessions = kept
+	return removed
+}
+
+func (s *mockState) getSessions() []NVMESession {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]NVMESession{}, s.sessions...)
+}
+
+// mockDevice is a namespace device of a connected subsystem
+type mockDevice struct {
+	MockNamespace
+	Path string
+	NQN  string
+}
+
+// devices returns the namespace devices of the connected subsystems, sorted by path
+func (s *mockState) devices() []mockDevice {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	connected := map[string]bool{}
+	for _, session := range s.sessions {
+		connected[session.Target] = true
+	}
+
+	var devices []mockDevice
+	for nqn, namespaces := range s.namespaces {
+		if !connected[nqn] {
+			continue
+		}
+		for _, ns := range namespaces {
+			devices = append(devices, mockDevice{
+				MockNamespace: ns,
+				Path:          fmt.Sprintf("/dev/nvme%dn%d", s.subsystems[nqn], ns.NSID),
+				NQN:           nqn,
+			})
+		}
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Path < devices[j].Path })
+	return devices
+}
+
+// device returns the namespace device, given as /dev/nvme0n1 or nvme0n1
+func (s *mockState) device(device string) (mockDevice, error) {
+	for _, d := range s.devices() {
+		if path.Base(d.Path) == path.Base(device) {
+			return d, nil
+		}
+	}
+	return mockDevice{}, fmt.Errorf("namespace device %s not found", device)
+}
+
+// controllerNamespaces returns the NSIDs reached through the controller
+func (s *mockState) controllerNamespaces(controller string) ([]uint32, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, session := range s.sessions {
+		if session.Name != controller {
+			continue
+		}
+		nsids := []uint32{}
+		for _, ns := range s.namespaces[session.Target] {
+			nsids = append(nsids, ns.NSID)
+		}
+		return nsids, nil
+	}
+	return nil, fmt.Errorf("controller %s not found", controller)
+}
+
+// inventory returns the connected subsystems with their controllers and namespace devices
+func (s *mockState) inventory(hostNQN string, hostID string) Inventory {
+	devices := s.devices()
+	sessions := s.getSessions()
+
+	byNQN := map[string]*InventorySubsystem{}
+	var nqns []string
+	for _, session := range sessions {
+		subsystem, ok := byNQN[session.Target]
+		if !ok {
+			s.mu.Lock()
+			instance := s.subsystems[session.Target]
+			s.mu.Unlock()
+			subsystem = &InventorySubsystem{Name: fmt.Sprintf("nvme-subsys%d", instance), NQN: session.Target}
+			byNQN[session.Target] = subsystem
+			nqns = append(nqns, session.Target)
+		}
+		subsystem.Controllers = append(subsystem.Controllers, InventoryController{
+			Name:         session.Name,
+			Cntlid:       strconv.Itoa(len(subsystem.Controllers) + 1),
+			SerialNumber: "MOCK0001",
+			ModelNumber:  "dellemc-mock",
+			Firmware:     "1.0.0.0",
+			Transport:    string(session.NVMETransportName),
+			Address:      fmt.Sprintf("traddr=%s", session.Portal),
+		})
+	}
+	for _, d := range devices {
+		subsystem, ok := byNQN[d.NQN]
+		if !ok {
+			continue
+		}
+		subsystem.Namespaces = append(subsystem.Namespaces, InventoryNamespace{
+			DevicePath:   d.Path,
+			NSID:         d.NSID,
+			MaximumLBA:   d.Size / mockSectorSize,
+			PhysicalSize: d.Size,
+			SectorSize:   mockSectorSize,
+			SerialNumber: "MOCK0001",
+			ModelNumber:  "dellemc-mock",
+			Firmware:     "1.0.0.0",
+		})
+	}
+
+	host := InventoryHost{HostNQN: hostNQN, HostID: hostID, Subsystems: []InventorySubsystem{}}
+	for _, nqn := range nqns {
+		host.Subsystems = append(host.Subsystems, *byNQN[nqn])
+	}
+	return Inventory{Hosts: []InventoryHost{host}}
+}
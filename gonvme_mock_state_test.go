@@ -0,0 +1,163 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newStatefulTestMock returns a stateful mock, without the faults other tests leave in GONVMEMock
+func newStatefulTestMock() *MockNVMe {
+	reflect.ValueOf(&GONVMEMock).Elem().SetZero()
+	return NewMockNVMe(map[string]string{MockStateful: "true", MockNumberOfTCPTargets: "2"})
+}
+
+func TestStatefulMockConnections(t *testing.T) {
+	nvme := newStatefulTestMock()
+
+	sessions, err := nvme.GetSessions()
+	assert.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	targets, err := nvme.DiscoverNVMeTCPTargets("10.1.1.1", false)
+	assert.NoError(t, err)
+	for _, target := range targets {
+		assert.NoError(t, nvme.NVMeTCPConnect(target, false))
+	}
+	assert.Error(t, nvme.NVMeTCPConnect(targets[0], false))
+	assert.NoError(t, nvme.NVMeTCPConnect(targets[0], true))
+	assert.Error(t, nvme.NVMeTCPConnect(NVMeTarget{Portal: "10.1.1.1"}, false))
+
+	fc := NVMeTarget{
+		Portal:    "nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf",
+		TargetNqn: "nqn.1988-11.com.dell:powermax:00:000120001647",
+		HostAdr:   "nn-0x200000109b6460e1:pn-0x100000109b6460e1",
+	}
+	assert.NoError(t, nvme.NVMeFCConnect(fc, false))
+
+	sessions, err = nvme.GetSessions()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 4)
+	assert.Equal(t, NVMESession{
+		Target:            fc.TargetNqn,
+		Portal:            fc.Portal,
+		Name:              "nvme3",
+		NVMESessionState:  NVMESessionStateLive,
+		NVMETransportName: NVMETransportNameFC,
+		HostAddress:       fc.HostAdr,
+	}, sessions[3])
+
+	// disconnecting a target drops every controller of its subsystem
+	assert.NoError(t, nvme.NVMeDisconnect(targets[0]))
+	assert.NoError(t, nvme.DisconnectController("/dev/nvme3"))
+	assert.Error(t, nvme.DisconnectController("nvme3"))
+	sessions, err = nvme.GetSessions()
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, "nvme1", sessions[0].Name)
+
+	controllers, err := nvme.ListControllers()
+	assert.NoError(t, err)
+	assert.Len(t, controllers, 1)
+	assert.Equal(t, targets[1].TargetNqn, controllers[0].SubsysNQN)
+}
+
+func TestStatefulMockNamespaces(t *testing.T) {
+	nvme := newStatefulTestMock()
+	target := NVMeTarget{Portal: "10.1.1.1", TargetNqn: "nqn.1988-11.com.dell:powerstore:00:1a1111a1111aAA11111A"}
+
+	assert.NoError(t, nvme.AddNamespace(target.TargetNqn, MockNamespace{NSID: 2, NGUID: "507911ecda65a2498ccf0968009a5d07"}))
+	assert.NoError(t, nvme.AddNamespace(target.TargetNqn, MockNamespace{NSID: 1, Size: 4096}))
+	assert.Error(t, nvme.AddNamespace(target.TargetNqn, MockNamespace{NSID: 1}))
+	assert.Error(t, nvme.AddNamespace(target.TargetNqn, MockNamespace{}))
+
+	// namespaces only show up once the subsystem is connected
+	devices, err := nvme.ListNVMeDeviceAndNamespace()
+	assert.NoError(t, err)
+	assert.Empty(t, devices)
+	_, _, err = nvme.GetNVMeDeviceData("/dev/nvme0n2")
+	assert.Error(t, err)
+
+	assert.NoError(t, nvme.NVMeTCPConnect(target, false))
+	devices, err = nvme.ListNVMeDeviceAndNamespace()
+	assert.NoError(t, err)
+	assert.Equal(t, []DevicePathAndNamespace{
+		{DevicePath: "/dev/nvme0n1", Namespace: "1"},
+		{DevicePath: "/dev/nvme0n2", Namespace: "2"},
+	}, devices)
+
+	nguid, namespace, err := nvme.GetNVMeDeviceData("/dev/nvme0n2")
+	assert.NoError(t, err)
+	assert.Equal(t, "507911ecda65a2498ccf0968009a5d07", nguid)
+	assert.Equal(t, "2", namespace)
+	nguid, _, err = nvme.GetNVMeDeviceData("nvme0n1")
+	assert.NoError(t, err)
+	assert.Len(t, nguid, 32)
+
+	ids, err := nvme.ListNVMeNamespaceID(devices)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x2"}, ids[devices[1]])
+
+	namespaces, err := nvme.ListNamespaces([]string{"nvme0", "nvme5"}, ListNamespacesOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, []uint32{1, 2}, namespaces[0].NSIDs)
+	assert.Error(t, namespaces[1].Err)
+
+	capacity, err := nvme.RefreshNamespaceCapacity(context.Background(), "/dev/nvme0n1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4096), capacity.NewSize)
+
+	inventory, err := nvme.GetInventory()
+	assert.NoError(t, err)
+	assert.Len(t, inventory.Hosts[0].Subsystems, 1)
+	assert.Equal(t, "nvme0", inventory.Hosts[0].Subsystems[0].Controllers[0].Name)
+	assert.Len(t, inventory.AllNamespaces(), 2)
+
+	assert.NoError(t, nvme.RemoveNamespace(target.TargetNqn, 1))
+	assert.Error(t, nvme.RemoveNamespace(target.TargetNqn, 1))
+	devices, err = nvme.ListNVMeDeviceAndNamespace()
+	assert.NoError(t, err)
+	assert.Len(t, devices, 1)
+
+	assert.NoError(t, nvme.NVMeDisconnect(target))
+	devices, err = nvme.ListNVMeDeviceAndNamespace()
+	assert.NoError(t, err)
+	assert.Empty(t, devices)
+}
+
+func TestStatefulMockReconcile(t *testing.T) {
+	nvme := newStatefulTestMock()
+	desired := []NVMeTarget{
+		{TargetNqn: "nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D0", Portal: "192.168.1.0", TargetType: "tcp"},
+		{TargetNqn: "nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D0", Portal: "192.168.1.1", TargetType: "tcp"},
+	}
+
+	report, err := nvme.Reconcile(context.Background(), desired, ReconcileOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, report.Steps, 2)
+
+	// converged, a second reconcile has nothing to do
+	report, err = nvme.Reconcile(context.Background(), desired, ReconcileOptions{})
+	assert.NoError(t, err)
+	for _, step := range report.Steps {
+		assert.Equal(t, ReconcileActionNone, step.Action)
+	}
+}
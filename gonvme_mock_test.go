@@ -16,6 +16,7 @@
 package gonvme
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -186,6 +187,44 @@ func TestMockedNVMeDisconnectError(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestMockedDisconnectPaths(t *testing.T) {
+	GONVMEMock.InduceLogoutError = false
+	GONVMEMock.InduceGetSessionsError = false
+	nvme := NewMockNVMe(map[string]string{
+		MockNumberOfSessions: "3",
+	})
+
+	assert.Nil(t, nvme.DisconnectController("nvme0"))
+
+	disconnected, err := nvme.DisconnectPath("nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D1", "192.168.1.1", "")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"nvme1"}, disconnected)
+
+	disconnected, err = nvme.DisconnectAll(SessionFilter{})
+	assert.Nil(t, err)
+	assert.Len(t, disconnected, 3)
+
+	GONVMEMock.InduceLogoutError = true
+	assert.NotNil(t, nvme.DisconnectController("nvme0"))
+	_, err = nvme.DisconnectAll(SessionFilter{})
+	assert.NotNil(t, err)
+	GONVMEMock.InduceLogoutError = false
+}
+
+func TestMockedSafeDisconnect(t *testing.T) {
+	GONVMEMock.InduceLogoutError = false
+	GONVMEMock.InduceDeviceInUseError = true
+	nvme := NewMockNVMe(map[string]string{})
+
+	err := nvme.SafeDisconnect(context.Background(), NVMeTarget{}, DisconnectRefuseInUse)
+	var inUse *DeviceInUseError
+	assert.ErrorAs(t, err, &inUse)
+
+	err = nvme.SafeDisconnect(context.Background(), NVMeTarget{}, DisconnectFlush)
+	assert.Nil(t, err)
+	GONVMEMock.InduceDeviceInUseError = false
+}
+
 func TestMockedGetNVMeDeviceData(t *testing.T) {
 	nvme := NewMockNVMe(map[string]string{})
 	_, _, err := nvme.GetNVMeDeviceData("")
@@ -212,6 +251,25 @@ func TestMockedListNVMeNamespaceIDError(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestMockedListNamespaces(t *testing.T) {
+	GONVMEMock.InducedNVMeNamespaceIDError = false
+	nvme := NewMockNVMe(map[string]string{
+		MockNumberOfNamespaceDevices: "2",
+	})
+	result, err := nvme.ListNamespaces([]string{"nvme0", "nvme1"}, ListNamespacesOptions{})
+	assert.Nil(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, []uint32{1, 2}, result[0].NSIDs)
+}
+
+func TestMockedListNamespacesError(t *testing.T) {
+	nvme := NewMockNVMe(map[string]string{})
+	GONVMEMock.InducedNVMeNamespaceIDError = true
+	result, err := nvme.ListNamespaces([]string{"nvme0"}, ListNamespacesOptions{})
+	assert.NotNil(t, err)
+	assert.NotNil(t, result[0].Err)
+}
+
 func TestMockedListNVMeDeviceAndNamespace(t *testing.T) {
 	nvme := NewMockNVMe(map[string]string{})
 	_, err := nvme.ListNVMeDeviceAndNamespace()
@@ -225,6 +283,23 @@ func TestMockedListNVMeDeviceAndNamespaceError(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestMockedGetInventory(t *testing.T) {
+	GONVMEMock.InduceGetInventoryError = false
+	nvme := NewMockNVMe(map[string]string{
+		MockNumberOfNamespaceDevices: "3",
+	})
+	inventory, err := nvme.GetInventory()
+	assert.Nil(t, err)
+	assert.Len(t, inventory.AllNamespaces(), 3)
+}
+
+func TestMockedGetInventoryError(t *testing.T) {
+	nvme := NewMockNVMe(map[string]string{})
+	GONVMEMock.InduceGetInventoryError = true
+	_, err := nvme.GetInventory()
+	assert.NotNil(t, err)
+}
+
 func TestMockedGetSessions(t *testing.T) {
 	nvme := NewMockNVMe(map[string]string{})
 	_, err := nvme.GetSessions()
@@ -238,6 +313,23 @@ func TestMockedGetSessionsError(t *testing.T) {
 	assert.NotNil(t, err)
 }
 
+func TestMockedReconcile(t *testing.T) {
+	GONVMEMock.InduceGetSessionsError = false
+	GONVMEMock.InduceTCPLoginError = false
+	nvme := NewMockNVMe(map[string]string{
+		MockNumberOfSessions: "1",
+	})
+	desired := []NVMeTarget{
+		{TargetNqn: "nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D0", Portal: "192.168.1.0", TargetType: "tcp"},
+		{TargetNqn: "nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D0", Portal: "192.168.1.1", TargetType: "tcp"},
+	}
+	report, err := nvme.Reconcile(context.Background(), desired, ReconcileOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, ReconcileActionNone, report.Steps[0].Action)
+	assert.Equal(t, ReconcileActionConnect, report.Steps[1].Action)
+	assert.True(t, report.Steps[1].Executed)
+}
+
 func TestMockedDeviceRescan(t *testing.T) {
 	nvme := NewMockNVMe(map[string]string{})
 	GONVMEMock.InduceGetSessionsError = false
@@ -251,3 +343,183 @@ func TestMockedDeviceRescanError(t *testing.T) {
 	err := nvme.DeviceRescan("")
 	assert.NotNil(t, err)
 }
+
+func TestMockedDeviceRescanInducedError(t *testing.T) {
+	nvme := NewMockNVMe(map[string]string{})
+	GONVMEMock.InduceGetSessionsError = false
+	GONVMEMock.InduceDeviceRescanError = true
+	defer func() { GONVMEMock.InduceDeviceRescanError = false }()
+	assert.NotNil(t, nvme.DeviceRescan(""))
+}
+
+func TestMockedRescan(t *testing.T) {
+	GONVMEMock.InduceRescanError = false
+	nvme := NewMockNVMe(map[string]string{})
+	assert.Nil(t, nvme.RescanSubsystem("nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D0"))
+	assert.Nil(t, nvme.RescanAll())
+}
+
+func TestMockedRescanError(t *testing.T) {
+	nvme := NewMockNVMe(map[string]string{})
+	GONVMEMock.InduceRescanError = true
+	assert.NotNil(t, nvme.RescanSubsystem("nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D0"))
+	assert.NotNil(t, nvme.RescanAll())
+}
+
+func TestMockedRefreshNamespaceCapacity(t *testing.T) {
+	GONVMEMock.InduceRefreshCapacityError = false
+	nvme := NewMockNVMe(map[string]string{})
+	capacity, err := nvme.RefreshNamespaceCapacity(context.Background(), "/dev/nvme0n1")
+	assert.Nil(t, err)
+	assert.Equal(t, capacity.IdentifySize, capacity.NewSize)
+}
+
+func TestMockedRefreshNamespaceCapacityError(t *testing.T) {
+	nvme := NewMockNVMe(map[string]string{})
+	GONVMEMock.InduceRefreshCapacityError = true
+	_, err := nvme.RefreshNamespaceCapacity(context.Background(), "/dev/nvme0n1")
+	assert.NotNil(t, err)
+}
+
+func TestMockedGetController(t *testing.T) {
+	GONVMEMock.InduceGetSessionsError = false
+	GONVMEMock.InduceGetControllerError = false
+	nvme := NewMockNVMe(map[string]string{MockNumberOfSessions: "2"})
+
+	controllers, err := nvme.ListControllers()
+	assert.NoError(t, err)
+	assert.Len(t, controllers, 2)
+
+	controller, err := nvme.GetController("/dev/nvme1")
+	assert.NoError(t, err)
+	assert.Equal(t, "nvme1", controller.Name)
+	assert.Equal(t, NVMESessionStateLive, controller.State)
+	assert.Equal(t, "192.168.1.1", controller.TrAddr)
+
+	_, err = nvme.GetController("nvme5")
+	assert.Error(t, err)
+
+	GONVMEMock.InduceGetControllerError = true
+	defer func() { GONVMEMock.InduceGetControllerError = false }()
+	_, err = nvme.GetController("nvme0")
+	assert.Error(t, err)
+}
+
+func TestMockedUpdateControllerTimeouts(t *testing.T) {
+	GONVMEMock.InduceGetSessionsError = false
+	GONVMEMock.InduceGetControllerError = false
+	GONVMEMock.InduceUpdateTimeoutsError = false
+	nvme := NewMockNVMe(map[string]string{MockNumberOfSessions: "2"})
+
+	controller, err := nvme.UpdateControllerTimeouts("nvme0", ControllerTimeouts{CtrlLossTmo: intPtr(60), FastIOFailTmo: intPtr(5)})
+	assert.NoError(t, err)
+	assert.Equal(t, 60, controller.CtrlLossTmo)
+	assert.Equal(t, 5, controller.FastIOFailTmo)
+
+	_, err = nvme.UpdateControllerTimeouts("nvme0", ControllerTimeouts{ReconnectDelay: intPtr(0)})
+	assert.ErrorIs(t, err, ErrInvalidTimeouts)
+
+	assert.NoError(t, nvme.UpdateSubsystemTimeouts("nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D1", ControllerTimeouts{CtrlLossTmo: intPtr(60)}))
+	assert.Error(t, nvme.UpdateSubsystemTimeouts("nqn.unknown", ControllerTimeouts{CtrlLossTmo: intPtr(60)}))
+
+	GONVMEMock.InduceUpdateTimeoutsError = true
+	defer func() { GONVMEMock.InduceUpdateTimeoutsError = false }()
+	_, err = nvme.UpdateControllerTimeouts("nvme0", ControllerTimeouts{CtrlLossTmo: intPtr(60)})
+	assert.Error(t, err)
+	assert.Error(t, nvme.UpdateSubsystemTimeouts("nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D1", ControllerTimeouts{CtrlLossTmo: intPtr(60)}))
+}
+
+func TestMockedResetControllerAndTuneNamespaceQueue(t *testing.T) {
+	GONVMEMock.InduceGetSessionsError = false
+	GONVMEMock.InduceGetControllerError = false
+	nvme := NewMockNVMe(map[string]string{})
+	negative := -1
+
+	assert.NoError(t, nvme.ResetController("nvme0"))
+	assert.Error(t, nvme.ResetController("nvme7"))
+	assert.NoError(t, nvme.TuneNamespaceQueue("/dev/nvme0n1", NamespaceQueueSettings{Scheduler: "none"}))
+	assert.ErrorIs(t, nvme.TuneNamespaceQueue("/dev/nvme0n1", NamespaceQueueSettings{NrRequests: &negative}), ErrInvalidQueueSettings)
+	assert.NoError(t, nvme.SetCoreMaxRetries(5))
+	assert.ErrorIs(t, nvme.SetCoreMaxRetries(256), ErrInvalidQueueSettings)
+
+	GONVMEMock.InduceResetControllerError = true
+	GONVMEMock.InduceTuneQueueError = true
+	defer func() {
+		GONVMEMock.InduceResetControllerError = false
+		GONVMEMock.InduceTuneQueueError = false
+	}()
+	assert.Error(t, nvme.ResetController("nvme0"))
+	assert.Error(t, nvme.TuneNamespaceQueue("/dev/nvme0n1", NamespaceQueueSettings{}))
+	assert.Error(t, nvme.SetCoreMaxRetries(5))
+}
+
+func TestMockedDiscoverAllNVMeFCTargets(t *testing.T) {
+	GONVMEMock.InduceDiscoveryError = false
+	nvme := NewMockNVMe(map[string]string{MockNumberOfFCTargets: "2"})
+
+	rports, err := nvme.ListFCRemotePorts()
+	assert.NoError(t, err)
+	assert.Len(t, rports, 2)
+
+	targets, err := nvme.DiscoverAllNVMeFCTargets(false)
+	assert.NoError(t, err)
+	assert.Len(t, targets, 4)
+
+	GONVMEMock.InduceDiscoveryError = true
+	defer func() { GONVMEMock.InduceDiscoveryError = false }()
+	_, err = nvme.DiscoverAllNVMeFCTargets(false)
+	assert.Error(t, err)
+}
+
+func TestMockedRescanFCHost(t *testing.T) {
+	GONVMEMock.InduceRescanFCHostError = false
+	nvme := NewMockNVMe(map[string]string{})
+
+	assert.NoError(t, nvme.RescanFCHost("host1", RescanFCHostOptions{}))
+	assert.Error(t, nvme.RescanFCHost("rport-1:0-0", RescanFCHostOptions{}))
+
+	GONVMEMock.InduceRescanFCHostError = true
+	defer func() { GONVMEMock.InduceRescanFCHostError = false }()
+	assert.Error(t, nvme.RescanFCHost("host1", RescanFCHostOptions{}))
+}
+
+func TestMockedCLIVersion(t *testing.T) {
+	nvme := NewMockNVMe(map[string]string{})
+	GONVMEMock.InduceCLIVersionError = false
+	version, err := nvme.CLIVersion()
+	assert.Nil(t, err)
+	assert.True(t, version.Supports(CapabilityTLS))
+
+	GONVMEMock.InduceCLIVersionError = true
+	defer func() { GONVMEMock.InduceCLIVersionError = false }()
+	_, err = nvme.CLIVersion()
+	assert.NotNil(t, err)
+}
+
+func TestMockedAvailable(t *testing.T) {
+	nvme := NewMockNVMe(map[string]string{})
+	GONVMEMock.InduceAvailableError = false
+	assert.Nil(t, nvme.Available())
+
+	GONVMEMock.InduceAvailableError = true
+	defer func() { GONVMEMock.InduceAvailableError = false }()
+	var notFound *BinaryNotFoundError
+	assert.ErrorAs(t, nvme.Available(), &notFound)
+}
+
+func TestMockedCheckPrerequisites(t *testing.T) {
+	nvme := NewMockNVMe(map[string]string{})
+	GONVMEMock.InduceCheckPrerequisitesError = false
+	report, err := nvme.CheckPrerequisites([]string{NVMeTransportTypeTCP})
+	assert.Nil(t, err)
+	assert.True(t, report.Ready())
+	assert.Len(t, report.Modules, 3)
+
+	_, err = nvme.CheckPrerequisites([]string{"iscsi"})
+	assert.NotNil(t, err)
+
+	GONVMEMock.InduceCheckPrerequisitesError = true
+	defer func() { GONVMEMock.InduceCheckPrerequisitesError = false }()
+	_, err = nvme.CheckPrerequisites(nil)
+	assert.NotNil(t, err)
+}
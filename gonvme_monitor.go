@@ -0,0 +1,314 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrMonitorStarted is returned by Run when the Monitor has already been run
+var ErrMonitorStarted = errors.New("path monitor already started")
+
+// PathState is the state of a monitored path
+type PathState string
+
+const (
+	// PathStateUnknown is the state of a path which has not been sampled yet
+	PathStateUnknown PathState = ""
+	// PathStateLive indicates at least one live controller for the path
+	PathStateLive PathState = "live"
+	// PathStateConnecting indicates controllers for the path which are connecting or resetting
+	PathStateConnecting PathState = "connecting"
+	// PathStateMissing indicates no controller, or only controllers being deleted, for the path
+	PathStateMissing PathState = "missing"
+)
+
+const (
+	defaultMonitorInterval       = 30 * time.Second
+	defaultMonitorStuckTimeout   = 2 * time.Minute
+	defaultMonitorInitialBackoff = 5 * time.Second
+	defaultMonitorMaxBackoff     = 5 * time.Minute
+	defaultMonitorEventBuffer    = 64
+)
+
+// PathEvent is published by the Monitor when a path changes state or is reconnected
+type PathEvent struct {
+	Target   NVMeTarget
+	Previous PathState
+	Current  PathState
+	Time     time.Time
+	// Reconnect is set when the monitor issued a connect for the path, Err holding its outcome
+	Reconnect bool
+	Err       error
+}
+
+// MonitorOptions controls the path health Monitor; zero and negative values select the defaults
+type MonitorOptions struct {
+	// Interval between two samples of the sessions
+	Interval time.Duration
+	// StuckTimeout is how long a path may stay connecting or resetting before it is reconnected
+	StuckTimeout time.Duration
+	// InitialBackoff is the delay after a failed reconnect, doubled on every further failure
+	InitialBackoff time.Duration
+	// MaxBackoff bounds the delay between two reconnects of a path
+	MaxBackoff time.Duration
+	// EventBuffer is the capacity of the Events channel; events are dropped when it is full
+	EventBuffer int
+	// DuplicateConnect is passed to the connect calls
+	DuplicateConnect bool
+	// OnEvent, if set, is called synchronously for every event. It may call Register and Unregister.
+	OnEvent func(PathEvent)
+}
+
+// Monitor periodically samples the NVMe sessions and reconnects the registered paths which are missing or stuck
+type Monitor struct {
+	client NVMEinterface
+	opts   MonitorOptions
+	events chan PathEvent
+	now    func() time.Time
+
+	mu      sync.Mutex
+	paths   map[string]*monitoredPath
+	started bool
+}
+
+type monitoredPath struct {
+	target      NVMeTarget
+	state       PathState
+	since       time.Time
+	failures    int
+	nextAttempt time.Time
+}
+
+// pathReconnect is a reconnect decided while sampling the sessions, run once the lock is released
+type pathReconnect struct {
+	path   *monitoredPath
+	target NVMeTarget
+	state  PathState
+	since  time.Time
+	stuck  []NVMESession
+}
+
+// NewMonitor returns a path health monitor using client, which may be an NVMe or a MockNVMe
+func NewMonitor(client NVMEinterface, opts MonitorOptions) *Monitor {
+	setTimeouts(&opts.Interval, opts.Interval, defaultMonitorInterval)
+	setTimeouts(&opts.StuckTimeout, opts.StuckTimeout, defaultMonitorStuckTimeout)
+	setTimeouts(&opts.InitialBackoff, opts.InitialBackoff, defaultMonitorInitialBackoff)
+	setTimeouts(&opts.MaxBackoff, opts.MaxBackoff, defaultMonitorMaxBackoff)
+	if opts.EventBuffer <= 0 {
+		opts.EventBuffer = defaultMonitorEventBuffer
+	}
+
+	return &Monitor{
+		client: client,
+		opts:   opts,
+		events: make(chan PathEvent, opts.EventBuffer),
+		now:    time.Now,
+		paths:  map[string]*monitoredPath{},
+	}
+}
+
+// Register adds paths to the desired set of the monitor
+func (m *Monitor) Register(targets ...NVMeTarget) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, target := range targets {
+		key := pathKey(target)
+		if _, ok := m.paths[key]; !ok {
+			m.paths[key] = &monitoredPath{target: target}
+		}
+	}
+}
+
+// Unregister removes paths from the desired set of the monitor
+func (m *Monitor) Unregister(targets ...NVMeTarget) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, target := range targets {
+		delete(m.paths, pathKey(target))
+	}
+}
+
+// Events returns the channel the state transitions are published on; it is closed when Run returns
+func (m *Monitor) Events() <-chan PathEvent {
+	return m.events
+}
+
+// Run samples the sessions every Interval until the context is cancelled. A Monitor runs once:
+// Events is closed when Run returns and later calls return ErrMonitorStarted.
+func (m *Monitor) Run(ctx context.Context) error {
+	m.mu.Lock()
+	if m.started {
+		m.mu.Unlock()
+		return ErrMonitorStarted
+	}
+	m.started = true
+	m.mu.Unlock()
+	defer close(m.events)
+
+	ticker := time.NewTicker(m.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		m.check(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// check samples the sessions once, publishing state changes and reconnecting paths as needed.
+// The events are published and the paths reconnected without holding the lock, so that OnEvent
+// may register paths and slow connects do not hold Register up.
+func (m *Monitor) check(ctx context.Context) {
+	sessions, err := m.client.GetSessions()
+	if err != nil {
+		m.client.log().Errorf("Path monitor: unable to get sessions: %v", err)
+		return
+	}
+
+	now := m.now()
+	events, reconnects := m.sample(sessions, now)
+	for _, event := range events {
+		m.publish(event)
+	}
+	for _, r := range reconnects {
+		if ctx.Err() != nil {
+			return
+		}
+		m.reconnect(r, now)
+	}
+}
+
+// sample updates the state of the paths from the sessions, returning the state changes and the reconnects to run
+func (m *Monitor) sample(sessions []NVMESession, now time.Time) ([]PathEvent, []pathReconnect) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var events []PathEvent
+	var reconnects []pathReconnect
+	for _, path := range m.paths {
+		var stuck []NVMESession
+		state := PathStateMissing
+		for _, session := range sessions {
+			if !targetFilter(path.target).Match(session) {
+				continue
+			}
+			switch session.NVMESessionState {
+			case NVMESessionStateLive:
+				state = PathStateLive
+			case NVMESessionStateDeleting:
+			default:
+				stuck = append(stuck, session)
+				if state == PathStateMissing {
+					state = PathStateConnecting
+				}
+			}
+		}
+
+		if state != path.state {
+			events = append(events, PathEvent{Target: path.target, Previous: path.state, Current: state, Time: now})
+			path.state = state
+			path.since = now
+		}
+
+		if m.needsReconnect(path, now) {
+			reconnects = append(reconnects, pathReconnect{path: path, target: path.target, state: path.state, since: path.since, stuck: stuck})
+		}
+	}
+	return events, reconnects
+}
+
+func (m *Monitor) needsReconnect(path *monitoredPath, now time.Time) bool {
+	if now.Before(path.nextAttempt) {
+		return false
+	}
+	switch path.state {
+	case PathStateMissing:
+		return true
+	case PathStateConnecting:
+		return now.Sub(path.since) >= m.opts.StuckTimeout
+	default:
+		path.failures = 0
+		return false
+	}
+}
+
+// reconnect removes the stuck controllers of the path, if any, and connects it again
+func (m *Monitor) reconnect(r pathReconnect, now time.Time) {
+	if r.state == PathStateConnecting {
+		for _, session := range r.stuck {
+			log := m.client.log().With(append(targetFields(r.target), controllerField(session.Name))...)
+			log.Infof("Path monitor: removing controller stuck %s since %s", session.NVMESessionState, r.since)
+			if err := m.client.DisconnectController(session.Name); err != nil {
+				log.Errorf("Path monitor: unable to remove controller: %v", err)
+			}
+		}
+	}
+
+	m.client.log().With(targetFields(r.target)...).Infof("Path monitor: reconnecting")
+	err := connectTarget(m.client, r.target, m.opts.DuplicateConnect)
+
+	m.mu.Lock()
+	// the path may have been unregistered while connecting
+	if path := r.path; m.paths[pathKey(r.target)] == path {
+		if err != nil {
+			path.failures++
+			path.nextAttempt = now.Add(m.backoff(path.failures))
+		} else {
+			// give the kernel time to bring the controller live before trying again,
+			// and StuckTimeout to the new controller before taking it as stuck
+			path.failures = 0
+			path.nextAttempt = now.Add(m.opts.InitialBackoff)
+			path.since = now
+		}
+	}
+	m.mu.Unlock()
+	m.publish(PathEvent{Target: r.target, Previous: r.state, Current: r.state, Time: now, Reconnect: true, Err: err})
+}
+
+// backoff returns InitialBackoff doubled for every failure after the first, bounded by MaxBackoff
+func (m *Monitor) backoff(failures int) time.Duration {
+	delay := m.opts.InitialBackoff
+	for i := 1; i < failures && delay < m.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > m.opts.MaxBackoff {
+		delay = m.opts.MaxBackoff
+	}
+	return delay
+}
+
+func (m *Monitor) publish(event PathEvent) {
+	if m.opts.OnEvent != nil {
+		m.opts.OnEvent(event)
+	}
+	select {
+	case m.events <- event:
+	default:
+		m.client.log().With(targetFields(event.Target)...).Errorf("Path monitor: event channel full, dropping event")
+	}
+}
+
+func pathKey(target NVMeTarget) string {
+	return target.TargetNqn + "|" + target.Portal + "|" + target.HostAdr
+}
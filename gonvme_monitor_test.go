@@ -0,0 +1,238 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	monitorTestLive    = NVMeTarget{TargetNqn: "nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D0", Portal: "192.168.1.0", TargetType: "tcp"}
+	monitorTestMissing = NVMeTarget{TargetNqn: "nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D9", Portal: "192.168.1.9", TargetType: "tcp"}
+)
+
+// newTestMonitor returns a monitor whose clock is advanced by the returned function
+func newTestMonitor(client NVMEinterface, opts MonitorOptions) (*Monitor, func(time.Duration)) {
+	GONVMEMock.InduceGetSessionsError = false
+	GONVMEMock.InduceTCPLoginError = false
+	GONVMEMock.InduceLogoutError = false
+	monitor := NewMonitor(client, opts)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	monitor.now = func() time.Time { return now }
+	return monitor, func(d time.Duration) { now = now.Add(d) }
+}
+
+func drainEvents(monitor *Monitor) []PathEvent {
+	var events []PathEvent
+	for {
+		select {
+		case event := <-monitor.Events():
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestMonitorReconnectsMissingPath(t *testing.T) {
+	var callbacks []PathEvent
+	monitor, _ := newTestMonitor(NewMockNVMe(nil), MonitorOptions{OnEvent: func(e PathEvent) { callbacks = append(callbacks, e) }})
+	monitor.Register(monitorTestLive, monitorTestMissing)
+
+	monitor.check(context.Background())
+	events := drainEvents(monitor)
+	assert.Equal(t, callbacks, events)
+	assert.Len(t, events, 3)
+
+	states := map[string]PathState{}
+	reconnects := 0
+	for _, event := range events {
+		if event.Reconnect {
+			reconnects++
+			assert.Equal(t, monitorTestMissing, event.Target)
+			assert.NoError(t, event.Err)
+			continue
+		}
+		assert.Equal(t, PathStateUnknown, event.Previous)
+		states[event.Target.Portal] = event.Current
+	}
+	assert.Equal(t, 1, reconnects)
+	assert.Equal(t, map[string]PathState{"192.168.1.0": PathStateLive, "192.168.1.9": PathStateMissing}, states)
+
+	// no transition and still within the backoff
+	monitor.check(context.Background())
+	assert.Empty(t, drainEvents(monitor))
+}
+
+func TestMonitorBackoff(t *testing.T) {
+	monitor, advance := newTestMonitor(NewMockNVMe(nil), MonitorOptions{InitialBackoff: time.Second, MaxBackoff: 3 * time.Second})
+	GONVMEMock.InduceTCPLoginError = true
+	defer func() { GONVMEMock.InduceTCPLoginError = false }()
+	monitor.Register(monitorTestMissing)
+
+	reconnects := func() int {
+		count := 0
+		for _, event := range drainEvents(monitor) {
+			if event.Reconnect {
+				assert.Error(t, event.Err)
+				count++
+			}
+		}
+		return count
+	}
+
+	monitor.check(context.Background())
+	assert.Equal(t, 1, reconnects())
+
+	// second attempt after 1s, third after 2s, then capped at 3s
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second} {
+		advance(delay - time.Millisecond)
+		monitor.check(context.Background())
+		assert.Equal(t, 0, reconnects(), "before %s", delay)
+		advance(time.Millisecond)
+		monitor.check(context.Background())
+		assert.Equal(t, 1, reconnects(), "after %s", delay)
+	}
+}
+
+func TestMonitorStuckPath(t *testing.T) {
+	commands := recordReconcile(t, "")
+	stuck := NVMeTarget{TargetNqn: disconnectTestNQN, Portal: "10.1.1.1", HostAdr: "10.1.1.11", TargetType: "tcp"}
+	monitor, advance := newTestMonitor(NewNVMe(nil), MonitorOptions{StuckTimeout: time.Minute})
+	monitor.Register(stuck)
+
+	monitor.check(context.Background())
+	events := drainEvents(monitor)
+	assert.Len(t, events, 1)
+	assert.Equal(t, PathStateConnecting, events[0].Current)
+	assert.Empty(t, *commands)
+
+	advance(time.Minute)
+	monitor.check(context.Background())
+	events = drainEvents(monitor)
+	assert.Len(t, events, 1)
+	assert.True(t, events[0].Reconnect)
+	assert.NoError(t, events[0].Err)
+	assert.Len(t, *commands, 2)
+	assert.Equal(t, "disconnect -d nvme2", (*commands)[0])
+	assert.True(t, strings.HasPrefix((*commands)[1], "connect"), (*commands)[1])
+
+	// the stuck timeout starts again from the reconnect
+	advance(time.Minute - time.Second)
+	monitor.check(context.Background())
+	assert.Empty(t, drainEvents(monitor))
+	advance(time.Second)
+	monitor.check(context.Background())
+	events = drainEvents(monitor)
+	assert.Len(t, events, 1)
+	assert.True(t, events[0].Reconnect)
+}
+
+func TestMonitorCallbackRegisters(t *testing.T) {
+	var monitor *Monitor
+	monitor, _ = newTestMonitor(NewMockNVMe(nil), MonitorOptions{OnEvent: func(e PathEvent) {
+		if e.Target == monitorTestMissing {
+			monitor.Unregister(monitorTestMissing)
+			monitor.Register(monitorTestLive)
+		}
+	}})
+	monitor.Register(monitorTestMissing)
+
+	done := make(chan struct{})
+	go func() {
+		monitor.check(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("check did not return, OnEvent blocked on the monitor lock")
+	}
+
+	events := drainEvents(monitor)
+	assert.Len(t, events, 2)
+	assert.Equal(t, PathStateMissing, events[0].Current)
+	assert.True(t, events[1].Reconnect)
+
+	monitor.check(context.Background())
+	events = drainEvents(monitor)
+	assert.Len(t, events, 1)
+	assert.Equal(t, monitorTestLive, events[0].Target)
+	assert.Equal(t, PathStateLive, events[0].Current)
+}
+
+func TestMonitorUnregister(t *testing.T) {
+	monitor, _ := newTestMonitor(NewMockNVMe(nil), MonitorOptions{})
+	monitor.Register(monitorTestMissing)
+	monitor.Unregister(monitorTestMissing)
+
+	monitor.check(context.Background())
+	assert.Empty(t, drainEvents(monitor))
+}
+
+func TestMonitorGetSessionsError(t *testing.T) {
+	monitor, _ := newTestMonitor(NewMockNVMe(nil), MonitorOptions{})
+	monitor.Register(monitorTestMissing)
+	GONVMEMock.InduceGetSessionsError = true
+	defer func() { GONVMEMock.InduceGetSessionsError = false }()
+
+	monitor.check(context.Background())
+	assert.Empty(t, drainEvents(monitor))
+}
+
+func TestMonitorRun(t *testing.T) {
+	GONVMEMock.InduceGetSessionsError = false
+	monitor := NewMonitor(NewMockNVMe(nil), MonitorOptions{Interval: time.Millisecond})
+	monitor.Register(monitorTestLive)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- monitor.Run(ctx) }()
+
+	event := <-monitor.Events()
+	assert.Equal(t, PathStateLive, event.Current)
+	cancel()
+
+	assert.ErrorIs(t, <-done, context.Canceled)
+	for range monitor.Events() {
+	}
+
+	assert.ErrorIs(t, monitor.Run(context.Background()), ErrMonitorStarted)
+}
+
+func TestNewMonitorNegativeDurations(t *testing.T) {
+	monitor := NewMonitor(NewMockNVMe(nil), MonitorOptions{
+		Interval: -time.Second, StuckTimeout: -time.Second, InitialBackoff: -time.Second, MaxBackoff: -time.Second,
+	})
+	assert.Equal(t, defaultMonitorInterval, monitor.opts.Interval)
+	assert.Equal(t, defaultMonitorStuckTimeout, monitor.opts.StuckTimeout)
+	assert.Equal(t, defaultMonitorInitialBackoff, monitor.opts.InitialBackoff)
+	assert.Equal(t, defaultMonitorMaxBackoff, monitor.opts.MaxBackoff)
+}
+
+func TestMonitorEventBufferFull(t *testing.T) {
+	monitor, _ := newTestMonitor(NewMockNVMe(nil), MonitorOptions{EventBuffer: 1})
+	monitor.Register(monitorTestMissing)
+
+	monitor.check(context.Background())
+	assert.Len(t, drainEvents(monitor), 1)
+}
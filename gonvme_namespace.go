@@ -0,0 +1,167 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ListNamespacesOptions controls which namespaces nvme list-ns reports
+type ListNamespacesOptions struct {
+	// All lists every allocated namespace of the subsystem instead of only the active ones
+	All bool
+	// StartNSID lists namespaces with an ID greater than StartNSID
+	StartNSID uint32
+	// EndNSID, when non-zero, drops namespaces with an ID greater than EndNSID
+	EndNSID uint32
+}
+
+// ControllerNamespaces holds the namespace IDs reported by a single controller
+type ControllerNamespaces struct {
+	Controller string
+	NSIDs      []uint32
+	Err        error
+}
+
+// ControllerErrors collects the errors of an operation run against several controllers, keyed by controller.
+// Such operations try every controller rather than stopping at the first failure, and return a ControllerErrors
+// naming the controllers which failed along with the results of the others.
+type ControllerErrors map[string]error
+
+func (e ControllerErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	msgs := make([]string, 0, len(names))
+	for _, name := range names {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", name, e[name]))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// nvmeListNSOutput is the output of nvme list-ns -o json
+//
+//	{
+//	  "nsid_list":[
+//	    {
+//	      "nsid":1
+//	    },
+//	    {
+//	      "nsid":2
+//	    }
+//	  ]
+//	}
+type nvmeListNSOutput struct {
+	NSIDList []struct {
+		NSID uint32 `json:"nsid"`
+	} `json:"nsid_list"`
+}
+
+// ListNamespaces returns the namespace IDs reported by each of the given controllers, failing with a ControllerErrors
+func (nvme *NVMe) ListNamespaces(controllers []string, opts ListNamespacesOptions) ([]ControllerNamespaces, error) {
+	result := make([]ControllerNamespaces, 0, len(controllers))
+	failed := ControllerErrors{}
+
+	for _, ctrl := range controllers {
+		nsids, err := nvme.listNamespaceIDs(devPath(ctrl), opts)
+		if err != nil {
+			nvme.log().With(controllerField(ctrl)).Errorf("Error listing namespaces: %v", err)
+			failed[ctrl] = err
+		}
+		result = append(result, ControllerNamespaces{Controller: ctrl, NSIDs: nsids, Err: err})
+	}
+
+	if len(failed) > 0 {
+		return result, failed
+	}
+	return result, nil
+}
+
+func (nvme *NVMe) listNamespaceIDs(device string, opts ListNamespacesOptions) ([]uint32, error) {
+	args := append([]string{nvme.NVMeCommand, "list-ns", device}, nvme.jsonOutputArgs("list-ns")...)
+	if opts.All {
+		args = append(args, "--all")
+	}
+	if opts.StartNSID > 0 {
+		args = append(args, fmt.Sprintf("--namespace-id=%d", opts.StartNSID))
+	}
+
+	output, err := nvme.output(args)
+	if err != nil {
+		return nil, err
+	}
+
+	nsids, err := parseListNS(output)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.EndNSID == 0 {
+		return nsids, nil
+	}
+	filtered := make([]uint32, 0, len(nsids))
+	for _, nsid := range nsids {
+		if nsid <= opts.EndNSID {
+			filtered = append(filtered, nsid)
+		}
+	}
+	return filtered, nil
+}
+
+// parseListNS decodes the JSON output of nvme list-ns, falling back to the
+// plain output of the nvme-cli releases without JSON output for list-ns:
+//
+//	[   0]:0x2401
+//	[   1]:0x2406
+func parseListNS(output []byte) ([]uint32, error) {
+	trimmed := strings.TrimSpace(string(output))
+	nsids := []uint32{}
+	if trimmed == "" {
+		return nsids, nil
+	}
+
+	if strings.HasPrefix(trimmed, "{") {
+		var list nvmeListNSOutput
+		if err := json.Unmarshal([]byte(trimmed), &list); err != nil {
+			return nil, fmt.Errorf("could not unmarshal nvme list-ns output: %w", err)
+		}
+		for _, entry := range list.NSIDList {
+			nsids = append(nsids, entry.NSID)
+		}
+		return nsids, nil
+	}
+
+	for _, line := range strings.Split(trimmed, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		nsid, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 0, 32)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected nvme list-ns output %q: %w", line, err)
+		}
+		nsids = append(nsids, uint32(nsid))
+	}
+	return nsids, nil
+}
@@ -0,0 +1,161 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseListNS(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []uint32
+		wantErr bool
+	}{
+		{
+			"json output",
+			`{
+			  "nsid_list":[
+			    {
+			      "nsid":1
+			    },
+			    {
+			      "nsid":9217
+			    }
+			  ]
+			}`,
+			[]uint32{1, 9217},
+			false,
+		},
+		{
+			"plain output",
+			`
+		[   0]:0x2401
+		[   1]:0x2406`,
+			[]uint32{0x2401, 0x2406},
+			false,
+		},
+		{
+			"empty output",
+			"",
+			[]uint32{},
+			false,
+		},
+		{
+			"malformed json",
+			`{"nsid_list":[`,
+			nil,
+			true,
+		},
+		{
+			"malformed plain output",
+			`[   0]:nsid`,
+			nil,
+			true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseListNS([]byte(tc.input))
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.want, got)
+			}
+		})
+	}
+}
+
+func TestListNamespaces(t *testing.T) {
+	var gotArgs [][]string
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		if args[0] == "version" {
+			return mockCommand{out: []byte("nvme version 2.8 (git 2.8)\n")}
+		}
+		gotArgs = append(gotArgs, args)
+		if args[1] == "/dev/nvme1" {
+			return mockCommand{outErr: errors.New("controller gone")}
+		}
+		return mockCommand{out: []byte(`{"nsid_list":[{"nsid":1},{"nsid":2},{"nsid":3}]}`)}
+	})
+	defer func() { defaultExecutor = originalExecutor }()
+
+	nvme := NewNVMe(nil)
+	result, err := nvme.ListNamespaces([]string{"nvme0", "/dev/nvme1"}, ListNamespacesOptions{All: true, StartNSID: 1, EndNSID: 2})
+
+	assert.Error(t, err)
+	var ctrlErrs ControllerErrors
+	assert.True(t, errors.As(err, &ctrlErrs))
+	assert.Len(t, ctrlErrs, 1)
+	assert.Contains(t, err.Error(), "/dev/nvme1: controller gone")
+
+	assert.Equal(t, []ControllerNamespaces{
+		{Controller: "nvme0", NSIDs: []uint32{1, 2}},
+		{Controller: "/dev/nvme1", Err: ctrlErrs["/dev/nvme1"]},
+	}, result)
+	assert.Equal(t, []string{"list-ns", "/dev/nvme0", "-o", "json", "--all", "--namespace-id=1"}, gotArgs[0])
+}
+
+func TestListNamespacesPlainOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		version mockCommand
+	}{
+		{"nvme-cli 1.x", mockCommand{out: []byte("nvme version 1.16\n")}},
+		{"unknown version", mockCommand{outErr: &ExitError{Code: 1}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotArgs []string
+			originalExecutor := defaultExecutor
+			defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+				if args[0] == "version" || args[0] == "--version" {
+					return tc.version
+				}
+				gotArgs = args
+				return mockCommand{out: []byte("[   0]:0x1\n[   1]:0x2401\n")}
+			})
+			defer func() { defaultExecutor = originalExecutor }()
+
+			nvme := NewNVMe(nil)
+			result, err := nvme.ListNamespaces([]string{"nvme0"}, ListNamespacesOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, []ControllerNamespaces{{Controller: "nvme0", NSIDs: []uint32{1, 0x2401}}}, result)
+			assert.Equal(t, []string{"list-ns", "/dev/nvme0"}, gotArgs)
+		})
+	}
+}
+
+func TestListNamespacesSuccess(t *testing.T) {
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		return mockCommand{out: []byte(`{"nsid_list":[{"nsid":7}]}`)}
+	})
+	defer func() { defaultExecutor = originalExecutor }()
+
+	nvme := NewNVMe(nil)
+	result, err := nvme.ListNamespaces([]string{"nvme0"}, ListNamespacesOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []ControllerNamespaces{{Controller: "nvme0", NSIDs: []uint32{7}}}, result)
+}
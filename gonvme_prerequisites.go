@@ -0,0 +1,161 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadModules set to "true" makes CheckPrerequisites load the missing kernel modules with modprobe
+const LoadModules = "loadModules"
+
+const (
+	sysModulePath    = "/sys/module"
+	procModulesPath  = "/proc/modules"
+	fabricsDevice    = "/dev/nvme-fabrics"
+	multipathParam   = "/sys/module/nvme_core/parameters/multipath"
+	moduleNVMeCore   = "nvme-core"
+	moduleNVMeFabric = "nvme-fabrics"
+)
+
+// transportModules are the kernel modules of each transport, besides nvme-core and nvme-fabrics
+var transportModules = map[string]string{
+	NVMeTransportTypeTCP:  "nvme-tcp",
+	NVMeTransportTypeFC:   "nvme-fc",
+	NVMeTransportTypeRDMA: "nvme-rdma",
+}
+
+// ModuleStatus is the state of a kernel module
+type ModuleStatus struct {
+	Name   string
+	Loaded bool
+	// Probed is set when the module was loaded by CheckPrerequisites
+	Probed bool
+	// Error is the error of modprobe when the module could not be loaded
+	Error string
+}
+
+// PrerequisiteReport is the readiness of the host to connect NVMe over fabrics
+type PrerequisiteReport struct {
+	Modules []ModuleStatus
+	// FabricsDevice is set when /dev/nvme-fabrics, through which nvme-cli connects, exists
+	FabricsDevice bool
+	// Multipath is the multipath parameter of nvme_core, empty when nvme_core is not loaded
+	Multipath string
+	HostNQN   string
+	HostID    string
+	// Problems are the prerequisites which are not met, as actionable messages
+	Problems []string
+	// Warnings are the settings which are not expected but do not prevent connecting
+	Warnings []string
+}
+
+// Ready returns true when every prerequisite is met
+func (r PrerequisiteReport) Ready() bool {
+	return len(r.Problems) == 0
+}
+
+// CheckPrerequisites reports whether the host can connect NVMe over the transports: tcp, fc or rdma.
+// The missing kernel modules are loaded through the executor when LoadModules is set. An error is only
+// returned for an unknown transport, the unmet prerequisites being reported.
+func (nvme *NVMe) CheckPrerequisites(transports []string) (PrerequisiteReport, error) {
+	modules := []string{moduleNVMeCore, moduleNVMeFabric}
+	for _, transport := range transports {
+		module, ok := transportModules[transport]
+		if !ok {
+			return PrerequisiteReport{}, fmt.Errorf("unknown NVMe transport %q", transport)
+		}
+		modules = append(modules, module)
+	}
+
+	report := PrerequisiteReport{}
+	load, _ := strconv.ParseBool(nvme.options[LoadModules])
+	loaded := nvme.loadedModules()
+	for _, module := range modules {
+		status := ModuleStatus{Name: module, Loaded: loaded[moduleKey(module)]}
+		if !status.Loaded && load {
+			if _, stderr, err := nvme.executor().Run("modprobe", module); err != nil {
+				status.Error = strings.TrimSpace(fmt.Sprintf("%v: %s", err, stderr))
+				nvme.logOperation("checkPrerequisites").Errorf("Failed to load kernel module %s: %s", module, status.Error)
+			} else {
+				status.Loaded = nvme.loadedModules()[moduleKey(module)]
+				status.Probed = status.Loaded
+			}
+		}
+		if !status.Loaded {
+			report.Problems = append(report.Problems, fmt.Sprintf("kernel module %s is not loaded, load it with modprobe %s", module, module))
+		}
+		report.Modules = append(report.Modules, status)
+	}
+
+	if _, err := os.Stat(nvme.hostPath(fabricsDevice)); err == nil {
+		report.FabricsDevice = true
+	} else {
+		report.Problems = append(report.Problems, fmt.Sprintf("%s is missing, nvme-fabrics is not loaded or /dev is not the one of the host", fabricsDevice))
+	}
+
+	if data, err := os.ReadFile(filepath.Clean(nvme.hostPath(multipathParam))); err == nil {
+		report.Multipath = strings.TrimSpace(string(data))
+		if report.Multipath != "Y" {
+			report.Warnings = append(report.Warnings, "native NVMe multipath is disabled, set nvme_core.multipath=Y to use it")
+		}
+	}
+
+	if nqns, err := nvme.getInitiators(""); err == nil && len(nqns) > 0 {
+		report.HostNQN = nqns[0]
+	} else {
+		report.Problems = append(report.Problems, fmt.Sprintf("%s is missing or empty, create it with nvme gen-hostnqn", DefaultInitiatorNameFile))
+	}
+	if hostID, err := nvme.getHostID(); err == nil {
+		report.HostID = hostID
+	} else {
+		report.Problems = append(report.Problems, fmt.Sprintf("%s is missing or empty, create it with a UUID", DefaultHostIDFile))
+	}
+
+	return report, nil
+}
+
+// loadedModules returns the modules found in /sys/module, which lists the built-in ones with parameters,
+// and in /proc/modules, keyed by their names with underscores
+func (nvme *NVMe) loadedModules() map[string]bool {
+	loaded := map[string]bool{}
+	if entries, err := os.ReadDir(nvme.hostPath(sysModulePath)); err == nil {
+		for _, entry := range entries {
+			loaded[moduleKey(entry.Name())] = true
+		}
+	}
+	if data, err := os.ReadFile(filepath.Clean(nvme.hostPath(procModulesPath))); err == nil {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+				loaded[moduleKey(fields[0])] = true
+			}
+		}
+	}
+	return loaded
+}
+
+// moduleKey returns the name of a module as listed by the kernel, nvme_tcp for nvme-tcp
+func moduleKey(name string) string {
+	return strings.ReplaceAll(name, "-", "_")
+}
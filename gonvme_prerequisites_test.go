@@ -0,0 +1,112 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prerequisiteRoot returns a host root with nvme_core and nvme_fabrics built in, nvme_tcp loaded,
+// /dev/nvme-fabrics and the host identity
+func prerequisiteRoot(t *testing.T) string {
+	root := t.TempDir()
+	files := map[string]string{
+		"/sys/module/nvme_core/parameters/multipath": "Y\n",
+		"/sys/module/nvme_fabrics/uevent":            "",
+		"/proc/modules":                              "nvme_tcp 53248 0 - Live 0x0000000000000000\nnvme_keyring 20480 1 nvme_tcp, Live 0x0000000000000000\n",
+		"/dev/nvme-fabrics":                          "",
+		"/etc/nvme/hostnqn":                          "nqn.2014-08.org.nvmexpress:uuid:host\n",
+		"/etc/nvme/hostid":                           "1a11111a-aa11-11aa-1111-a10aa1a11111\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	return root
+}
+
+func TestCheckPrerequisites(t *testing.T) {
+	root := prerequisiteRoot(t)
+	nvme := NewNVMe(map[string]string{ChrootDirectory: root})
+
+	report, err := nvme.CheckPrerequisites([]string{NVMeTransportTypeTCP})
+	require.NoError(t, err)
+	assert.True(t, report.Ready(), report.Problems)
+	assert.Equal(t, []ModuleStatus{
+		{Name: "nvme-core", Loaded: true},
+		{Name: "nvme-fabrics", Loaded: true},
+		{Name: "nvme-tcp", Loaded: true},
+	}, report.Modules)
+	assert.True(t, report.FabricsDevice)
+	assert.Equal(t, "Y", report.Multipath)
+	assert.Equal(t, "nqn.2014-08.org.nvmexpress:uuid:host", report.HostNQN)
+	assert.Equal(t, "1a11111a-aa11-11aa-1111-a10aa1a11111", report.HostID)
+	assert.Empty(t, report.Warnings)
+
+	report, err = nvme.CheckPrerequisites([]string{NVMeTransportTypeTCP, NVMeTransportTypeFC})
+	require.NoError(t, err)
+	assert.False(t, report.Ready())
+	assert.Equal(t, []string{"kernel module nvme-fc is not loaded, load it with modprobe nvme-fc"}, report.Problems)
+
+	_, err = nvme.CheckPrerequisites([]string{"iscsi"})
+	assert.ErrorContains(t, err, "unknown NVMe transport")
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, multipathParam), []byte("N\n"), 0o600))
+	require.NoError(t, os.Remove(filepath.Join(root, fabricsDevice)))
+	require.NoError(t, os.Remove(filepath.Join(root, DefaultHostIDFile)))
+	report, err = nvme.CheckPrerequisites(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "N", report.Multipath)
+	assert.Len(t, report.Warnings, 1)
+	assert.False(t, report.FabricsDevice)
+	assert.Empty(t, report.HostID)
+	assert.Len(t, report.Problems, 2)
+}
+
+func TestCheckPrerequisitesLoadModules(t *testing.T) {
+	root := prerequisiteRoot(t)
+	var probed []string
+	originalExecutor := defaultExecutor
+	defer func() { defaultExecutor = originalExecutor }()
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		// chroot <root> modprobe <module>
+		if args[1] != "modprobe" {
+			return mockCommand{outErr: &ExitError{Code: 1}}
+		}
+		probed = append(probed, args[2])
+		if args[2] == "nvme-rdma" {
+			return mockCommand{outErr: &ExitError{Code: 1}, stdErr: []byte("modprobe: FATAL: Module nvme-rdma not found in directory /lib/modules/6.8.0\n")}
+		}
+		require.NoError(t, os.MkdirAll(filepath.Join(root, sysModulePath, moduleKey(args[2])), 0o755))
+		return mockCommand{}
+	})
+
+	nvme := NewNVMe(map[string]string{ChrootDirectory: root, LoadModules: "true"})
+	report, err := nvme.CheckPrerequisites([]string{NVMeTransportTypeTCP, NVMeTransportTypeFC, NVMeTransportTypeRDMA})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nvme-fc", "nvme-rdma"}, probed)
+	assert.Equal(t, ModuleStatus{Name: "nvme-fc", Loaded: true, Probed: true}, report.Modules[3])
+	assert.False(t, report.Modules[4].Loaded)
+	assert.Contains(t, report.Modules[4].Error, "Module nvme-rdma not found")
+	assert.Equal(t, []string{"kernel module nvme-rdma is not loaded, load it with modprobe nvme-rdma"}, report.Problems)
+}
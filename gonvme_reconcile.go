@@ -0,0 +1,228 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// defaultReconcileConcurrency is used when ReconcileOptions.MaxConcurrency is not set
+const defaultReconcileConcurrency = 4
+
+// ReconcileAction is the action planned by Reconcile for a path
+type ReconcileAction string
+
+const (
+	// ReconcileActionNone indicates a desired path which is already live
+	ReconcileActionNone ReconcileAction = "none"
+	// ReconcileActionWait indicates a desired path which the kernel is still connecting
+	ReconcileActionWait ReconcileAction = "wait"
+	// ReconcileActionConnect indicates a desired path which has to be connected
+	ReconcileActionConnect ReconcileAction = "connect"
+	// ReconcileActionDisconnect indicates a live path which is not desired
+	ReconcileActionDisconnect ReconcileAction = "disconnect"
+)
+
+// ReconcileOptions controls how Reconcile converges the connections
+type ReconcileOptions struct {
+	// DryRun computes the plan without connecting or disconnecting anything
+	DryRun bool
+	// RemoveExtra disconnects the paths of managed subsystems which are not desired
+	RemoveExtra bool
+	// ManagedNQNs are subsystems, in addition to those of the desired paths, whose extra paths are removed
+	ManagedNQNs []string
+	// MaxConcurrency bounds the number of connects and disconnects run at once
+	MaxConcurrency int
+	// DuplicateConnect is passed to the connect calls
+	DuplicateConnect bool
+}
+
+// ReconcileStep is a single entry of the reconcile plan and its outcome
+type ReconcileStep struct {
+	Action ReconcileAction
+	// Target is the desired path, unset for disconnects
+	Target NVMeTarget
+	// Sessions are the existing sessions matching the path, whatever their state
+	Sessions []NVMESession
+	// Executed is set once the action has been run
+	Executed bool
+	Err      error
+}
+
+// ReconcileReport is the plan computed by Reconcile and, unless DryRun, its result
+type ReconcileReport struct {
+	DryRun bool
+	Steps  []ReconcileStep
+}
+
+// Failed returns the steps which could not be executed
+func (r ReconcileReport) Failed() []ReconcileStep {
+	var failed []ReconcileStep
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			failed = append(failed, step)
+		}
+	}
+	return failed
+}
+
+// Reconcile converges the NVMe connections to the desired paths
+func (nvme *NVMe) Reconcile(ctx context.Context, desired []NVMeTarget, opts ReconcileOptions) (ReconcileReport, error) {
+	return reconcile(ctx, nvme, desired, opts)
+}
+
+// reconcile implements Reconcile on top of the NVMEinterface so the mock shares the same logic
+func reconcile(ctx context.Context, client NVMEinterface, desired []NVMeTarget, opts ReconcileOptions) (ReconcileReport, error) {
+	report := ReconcileReport{DryRun: opts.DryRun}
+
+	sessions, err := client.GetSessions()
+	if err != nil {
+		return report, err
+	}
+	report.Steps = planReconcile(desired, sessions, opts)
+	if opts.DryRun {
+		return report, nil
+	}
+
+	concurrency := opts.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = defaultReconcileConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i := range report.Steps {
+		step := &report.Steps[i]
+		if step.Action != ReconcileActionConnect && step.Action != ReconcileActionDisconnect {
+			continue
+		}
+
+		if err := ctx.Err(); err != nil {
+			step.Err = err
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			step.Err = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			step.Err = runReconcileStep(client, step, opts)
+			step.Executed = true
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, step := range report.Failed() {
+		errs = append(errs, step.Err)
+	}
+	return report, errors.Join(errs...)
+}
+
+// planReconcile computes the steps converging sessions to the desired paths
+func planReconcile(desired []NVMeTarget, sessions []NVMESession, opts ReconcileOptions) []ReconcileStep {
+	var steps []ReconcileStep
+	matched := make([]bool, len(sessions))
+	managed := map[string]bool{}
+	for _, nqn := range opts.ManagedNQNs {
+		managed[nqn] = true
+	}
+
+	for _, target := range desired {
+		managed[target.TargetNqn] = true
+		filter := targetFilter(target)
+
+		step := ReconcileStep{Action: ReconcileActionConnect, Target: target}
+		for i, session := range sessions {
+			if !filter.Match(session) {
+				continue
+			}
+			matched[i] = true
+			step.Sessions = append(step.Sessions, session)
+			switch session.NVMESessionState {
+			case NVMESessionStateLive:
+				step.Action = ReconcileActionNone
+			case NVMESessionStateDeleting, NVMESessionStateDead:
+				// the controller is going away or was given up by the kernel, a new connection is needed;
+				// nvme connect does not take such controllers as duplicates
+			default:
+				// connecting or resetting, the kernel keeps retrying on its own
+				if step.Action == ReconcileActionConnect {
+					step.Action = ReconcileActionWait
+				}
+			}
+		}
+		steps = append(steps, step)
+	}
+
+	if !opts.RemoveExtra {
+		return steps
+	}
+	for i, session := range sessions {
+		if matched[i] || !managed[session.Target] || session.NVMESessionState == NVMESessionStateDeleting {
+			continue
+		}
+		steps = append(steps, ReconcileStep{Action: ReconcileActionDisconnect, Sessions: []NVMESession{session}})
+	}
+	return steps
+}
+
+// targetFilter selects the sessions of a desired path
+func targetFilter(target NVMeTarget) SessionFilter {
+	return SessionFilter{
+		TargetNqn:   target.TargetNqn,
+		Portal:      target.Portal,
+		HostAddress: target.HostAdr,
+	}
+}
+
+func runReconcileStep(client NVMEinterface, step *ReconcileStep, opts ReconcileOptions) error {
+	if step.Action == ReconcileActionDisconnect {
+		session := step.Sessions[0]
+		client.log().With(logField(logKeyOperation, "reconcile"), controllerField(session.Name), nqnField(session.Target), portalField(session.Portal)).
+			Infof("Reconcile: disconnecting controller")
+		return client.DisconnectController(session.Name)
+	}
+
+	client.log().With(append([]LogField{logField(logKeyOperation, "reconcile")}, targetFields(step.Target)...)...).Infof("Reconcile: connecting target")
+	return connectTarget(client, step.Target, opts.DuplicateConnect)
+}
+
+// connectTarget connects the target using the transport it was discovered on
+func connectTarget(client NVMEinterface, target NVMeTarget, duplicateConnect bool) error {
+	transport := target.TargetType
+	if transport == "" {
+		transport = target.TrType
+	}
+	switch transport {
+	case NVMeTransportTypeTCP:
+		return client.NVMeTCPConnect(target, duplicateConnect)
+	case NVMeTransportTypeFC:
+		return client.NVMeFCConnect(target, duplicateConnect)
+	default:
+		return fmt.Errorf("unsupported transport %q for target %s at %s", transport, target.TargetNqn, target.Portal)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const reconcileTestFCNQN = "nqn.1988-11.com.dell:powermax:00:000120001647"
+
+var reconcileTestDesired = []NVMeTarget{
+	{TargetNqn: disconnectTestNQN, Portal: "10.1.1.1", HostAdr: "10.1.1.10", TargetType: "tcp"},
+	{TargetNqn: disconnectTestNQN, Portal: "10.1.1.1", HostAdr: "10.1.1.11", TargetType: "tcp"},
+	{TargetNqn: disconnectTestNQN, Portal: "10.1.1.2", HostAdr: "10.1.1.11", TargetType: "tcp"},
+	{TargetNqn: disconnectTestNQN, Portal: "10.1.1.3", TrType: "tcp"},
+}
+
+// recordReconcile replaces the default executor, answering list-subsys with disconnectTestSessions and
+// recording every other command; commands containing failOn fail
+func recordReconcile(t *testing.T, failOn string) *[]string {
+	var mu sync.Mutex
+	commands := []string{}
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		if args[0] == "list-subsys" {
+			return mockCommand{out: []byte(disconnectTestSessions)}
+		}
+		cmdline := strings.Join(args, " ")
+		mu.Lock()
+		commands = append(commands, cmdline)
+		mu.Unlock()
+		if failOn != "" && strings.Contains(cmdline, failOn) {
+			return mockCommand{outErr: errors.New("command failed"), waitErr: errors.New("command failed")}
+		}
+		return mockCommand{}
+	})
+	t.Cleanup(func() { defaultExecutor = originalExecutor })
+	return &commands
+}
+
+func stepActions(report ReconcileReport) []ReconcileAction {
+	var actions []ReconcileAction
+	for _, step := range report.Steps {
+		actions = append(actions, step.Action)
+	}
+	return actions
+}
+
+func TestPlanReconcileStates(t *testing.T) {
+	target := NVMeTarget{TargetNqn: disconnectTestNQN, Portal: "10.1.1.1", HostAdr: "10.1.1.10", TargetType: "tcp"}
+	session := func(name string, state NVMESessionState) NVMESession {
+		return NVMESession{Name: name, Target: disconnectTestNQN, Portal: "10.1.1.1", HostAddress: "10.1.1.10", NVMESessionState: state}
+	}
+	tests := []struct {
+		name     string
+		sessions []NVMESession
+		want     ReconcileAction
+	}{
+		{"missing", nil, ReconcileActionConnect},
+		{"live", []NVMESession{session("nvme0", NVMESessionStateLive)}, ReconcileActionNone},
+		{"connecting", []NVMESession{session("nvme0", NVMESessionStateConnecting)}, ReconcileActionWait},
+		{"resetting", []NVMESession{session("nvme0", NVMESessionStateResetting)}, ReconcileActionWait},
+		{"deleting", []NVMESession{session("nvme0", NVMESessionStateDeleting)}, ReconcileActionConnect},
+		{"dead", []NVMESession{session("nvme0", NVMESessionStateDead)}, ReconcileActionConnect},
+		{"dead and connecting", []NVMESession{session("nvme0", NVMESessionStateDead), session("nvme1", NVMESessionStateConnecting)}, ReconcileActionWait},
+		{"dead and live", []NVMESession{session("nvme0", NVMESessionStateDead), session("nvme1", NVMESessionStateLive)}, ReconcileActionNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := planReconcile([]NVMeTarget{target}, tt.sessions, ReconcileOptions{})
+			assert.Len(t, steps, 1)
+			assert.Equal(t, tt.want, steps[0].Action)
+			assert.Equal(t, tt.sessions, steps[0].Sessions)
+		})
+	}
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	commands := recordReconcile(t, "")
+	nvme := NewNVMe(nil)
+
+	report, err := nvme.Reconcile(context.Background(), reconcileTestDesired, ReconcileOptions{DryRun: true, RemoveExtra: true})
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, []ReconcileAction{
+		ReconcileActionNone,
+		ReconcileActionWait,
+		ReconcileActionConnect,
+		ReconcileActionConnect,
+		ReconcileActionDisconnect,
+	}, stepActions(report))
+	assert.Equal(t, "nvme3", report.Steps[2].Sessions[0].Name)
+	assert.Equal(t, "nvme1", report.Steps[4].Sessions[0].Name)
+	assert.Empty(t, *commands)
+}
+
+func TestReconcile(t *testing.T) {
+	commands := recordReconcile(t, "")
+	nvme := NewNVMe(nil)
+
+	report, err := nvme.Reconcile(context.Background(), reconcileTestDesired, ReconcileOptions{
+		RemoveExtra:    true,
+		ManagedNQNs:    []string{reconcileTestFCNQN},
+		MaxConcurrency: 2,
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, report.Failed())
+	assert.Len(t, report.Steps, 6)
+	for _, step := range report.Steps {
+		executed := step.Action == ReconcileActionConnect || step.Action == ReconcileActionDisconnect
+		assert.Equal(t, executed, step.Executed)
+	}
+	assert.Len(t, *commands, 4)
+	assert.Contains(t, *commands, "disconnect -d nvme1")
+	assert.Contains(t, *commands, "disconnect -d nvme4")
+}
+
+func TestReconcileKeepExtra(t *testing.T) {
+	commands := recordReconcile(t, "")
+	nvme := NewNVMe(nil)
+
+	report, err := nvme.Reconcile(context.Background(), reconcileTestDesired[:1], ReconcileOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []ReconcileAction{ReconcileActionNone}, stepActions(report))
+	assert.Empty(t, *commands)
+}
+
+func TestReconcileErrors(t *testing.T) {
+	recordReconcile(t, "10.1.1.3")
+	nvme := NewNVMe(nil)
+
+	desired := append([]NVMeTarget{}, reconcileTestDesired...)
+	desired = append(desired, NVMeTarget{TargetNqn: disconnectTestNQN, Portal: "10.1.1.4", TargetType: "rdma"})
+	report, err := nvme.Reconcile(context.Background(), desired, ReconcileOptions{MaxConcurrency: 1})
+	assert.Error(t, err)
+	failed := report.Failed()
+	assert.Len(t, failed, 2)
+	assert.Contains(t, err.Error(), "unsupported transport")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err = nvme.Reconcile(ctx, reconcileTestDesired, ReconcileOptions{})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, report.Failed(), 2)
+
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		return mockCommand{outErr: errors.New("list-subsys failed")}
+	})
+	_, err = nvme.Reconcile(context.Background(), reconcileTestDesired, ReconcileOptions{})
+	assert.ErrorContains(t, err, "list-subsys failed")
+}
@@ -0,0 +1,141 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var nvmeClassPath = "/sys/class/nvme"
+
+// rescanConcurrency bounds the controllers rescanned at once
+var rescanConcurrency = 4
+
+// RescanSubsystem rescans the namespaces of every controller connected to the subsystem NQN, failing with a ControllerErrors
+func (nvme *NVMe) RescanSubsystem(nqn string) error {
+	controllers, err := nvme.subsystemControllers(nqn)
+	if err != nil {
+		return err
+	}
+	if len(controllers) == 0 {
+		return fmt.Errorf("no controllers found for subsystem %s", nqn)
+	}
+	return nvme.rescanControllers(controllers)
+}
+
+// RescanAll rescans the namespaces of every NVMe controller on the host, failing with a ControllerErrors
+func (nvme *NVMe) RescanAll() error {
+	controllers, err := nvme.allControllers()
+	if err != nil {
+		return err
+	}
+	return nvme.rescanControllers(controllers)
+}
+
+// rescanControllers runs nvme ns-rescan on each controller, rescanConcurrency at a time
+func (nvme *NVMe) rescanControllers(controllers []string) error {
+	sem := make(chan struct{}, rescanConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	failed := ControllerErrors{}
+
+	for _, ctrl := range controllers {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(ctrl string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := nvme.DeviceRescan(devPath(ctrl)); err != nil {
+				nvme.logOperation("rescan", controllerField(ctrl)).Errorf("Error rescanning controller: %v", err)
+				mu.Lock()
+				failed[ctrl] = err
+				mu.Unlock()
+			}
+		}(ctrl)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
+
+// subsystemControllers returns the controllers of the subsystem NQN, read from
+// /sys/class/nvme-subsystem or, when sysfs is not available, from nvme list-subsys
+func (nvme *NVMe) subsystemControllers(nqn string) ([]string, error) {
+	subsystems, _ := filepath.Glob(filepath.Join(nvme.hostPath(nvmeSubsystemClassPath), "*"))
+	if len(subsystems) == 0 {
+		return nvme.sessionControllers(func(session NVMESession) bool { return session.Target == nqn })
+	}
+
+	var controllers []string
+	for _, subsystem := range subsystems {
+		data, err := os.ReadFile(filepath.Clean(filepath.Join(subsystem, "subsysnqn")))
+		if err != nil || strings.TrimSpace(string(data)) != nqn {
+			continue
+		}
+		entries, err := os.ReadDir(subsystem)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if controllerNameRegexp.MatchString(entry.Name()) {
+				controllers = append(controllers, entry.Name())
+			}
+		}
+	}
+	return controllers, nil
+}
+
+// allControllers returns every controller of /sys/class/nvme or, when sysfs is not available, of nvme list-subsys
+func (nvme *NVMe) allControllers() ([]string, error) {
+	entries, err := os.ReadDir(nvme.hostPath(nvmeClassPath))
+	if err != nil {
+		nvme.log().Infof("Unable to read %s, falling back to nvme list-subsys: %v", nvmeClassPath, err)
+		return nvme.sessionControllers(func(NVMESession) bool { return true })
+	}
+
+	var controllers []string
+	for _, entry := range entries {
+		if controllerNameRegexp.MatchString(entry.Name()) {
+			controllers = append(controllers, entry.Name())
+		}
+	}
+	return controllers, nil
+}
+
+func (nvme *NVMe) sessionControllers(match func(NVMESession) bool) ([]string, error) {
+	sessions, err := nvme.GetSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	var controllers []string
+	for _, session := range sessions {
+		if session.Name != "" && match(session) {
+			controllers = append(controllers, session.Name)
+		}
+	}
+	sort.Strings(controllers)
+	return controllers, nil
+}
@@ -0,0 +1,184 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	rescanTestNQN0 = "nqn.1988-11.com.dell:powerstore:00:1a1111a1111aAA11111A"
+	rescanTestNQN1 = "nqn.1988-11.com.dell:powermax:00:000120001647"
+)
+
+// setupRescanSysfs builds a fake sysfs with nvme0 and nvme1 connected to one subsystem and nvme2 to another
+func setupRescanSysfs(t *testing.T) {
+	root := t.TempDir()
+	subsystems := map[string][]string{
+		"nvme-subsys0": {"nvme0", "nvme1", "nvme0n1"},
+		"nvme-subsys1": {"nvme2"},
+	}
+	nqns := map[string]string{"nvme-subsys0": rescanTestNQN0, "nvme-subsys1": rescanTestNQN1}
+	for subsystem, entries := range subsystems {
+		dir := filepath.Join(root, "nvme-subsystem", subsystem)
+		assert.NoError(t, os.MkdirAll(dir, 0o755))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "subsysnqn"), []byte(nqns[subsystem]+"\n"), 0o600))
+		for _, entry := range entries {
+			assert.NoError(t, os.MkdirAll(filepath.Join(dir, entry), 0o755))
+		}
+	}
+	for _, ctrl := range []string{"nvme0", "nvme1", "nvme2", "nvme-fabrics"} {
+		assert.NoError(t, os.MkdirAll(filepath.Join(root, "nvme", ctrl), 0o755))
+	}
+
+	originalSubsystem, originalClass := nvmeSubsystemClassPath, nvmeClassPath
+	nvmeSubsystemClassPath = filepath.Join(root, "nvme-subsystem")
+	nvmeClassPath = filepath.Join(root, "nvme")
+	t.Cleanup(func() { nvmeSubsystemClassPath, nvmeClassPath = originalSubsystem, originalClass })
+}
+
+// recordRescans replaces the default executor, recording the rescanned devices and failing those in failing
+func recordRescans(t *testing.T, failing ...string) *[]string {
+	var mu sync.Mutex
+	rescanned := []string{}
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		mu.Lock()
+		defer mu.Unlock()
+		rescanned = append(rescanned, args[1])
+		for _, f := range failing {
+			if args[1] == f {
+				return mockCommand{outErr: errors.New("rescan failed")}
+			}
+		}
+		return mockCommand{}
+	})
+	t.Cleanup(func() { defaultExecutor = originalExecutor })
+	return &rescanned
+}
+
+func TestRescanSubsystem(t *testing.T) {
+	setupRescanSysfs(t)
+	rescanned := recordRescans(t)
+
+	nvme := NewNVMe(nil)
+	err := nvme.RescanSubsystem(rescanTestNQN0)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/dev/nvme0", "/dev/nvme1"}, *rescanned)
+}
+
+func TestRescanSubsystemErrors(t *testing.T) {
+	setupRescanSysfs(t)
+	recordRescans(t, "/dev/nvme1")
+
+	nvme := NewNVMe(nil)
+	err := nvme.RescanSubsystem(rescanTestNQN0)
+	var ctrlErrs ControllerErrors
+	assert.True(t, errors.As(err, &ctrlErrs))
+	assert.Len(t, ctrlErrs, 1)
+	assert.Contains(t, ctrlErrs, "nvme1")
+
+	err = nvme.RescanSubsystem("nqn.unknown")
+	assert.ErrorContains(t, err, "no controllers found")
+}
+
+func TestRescanSubsystemFromSessions(t *testing.T) {
+	originalSubsystem := nvmeSubsystemClassPath
+	nvmeSubsystemClassPath = "testdata/bad/nvme-subsystem"
+	defer func() { nvmeSubsystemClassPath = originalSubsystem }()
+
+	var mu sync.Mutex
+	var rescanned []string
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		if args[0] == "list-subsys" {
+			data, err := os.ReadFile("testdata/session_info_valid")
+			assert.NoError(t, err)
+			return mockCommand{out: data}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		rescanned = append(rescanned, args[1])
+		return mockCommand{}
+	})
+	defer func() { defaultExecutor = originalExecutor }()
+
+	nvme := NewNVMe(nil)
+	err := nvme.RescanSubsystem("nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/dev/nvme0", "/dev/nvme1"}, rescanned)
+}
+
+func TestRescanAll(t *testing.T) {
+	setupRescanSysfs(t)
+	rescanned := recordRescans(t)
+
+	nvme := NewNVMe(nil)
+	err := nvme.RescanAll()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/dev/nvme0", "/dev/nvme1", "/dev/nvme2"}, *rescanned)
+}
+
+func TestRescanAllConcurrency(t *testing.T) {
+	setupRescanSysfs(t)
+	originalConcurrency := rescanConcurrency
+	rescanConcurrency = 2
+	defer func() { rescanConcurrency = originalConcurrency }()
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return mockCommand{}
+	})
+	defer func() { defaultExecutor = originalExecutor }()
+
+	nvme := NewNVMe(nil)
+	assert.NoError(t, nvme.RescanAll())
+	assert.LessOrEqual(t, maxRunning, 2)
+}
+
+func TestRescanAllFromSessionsError(t *testing.T) {
+	originalClass := nvmeClassPath
+	nvmeClassPath = "testdata/bad/nvme"
+	defer func() { nvmeClassPath = originalClass }()
+
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		return mockCommand{outErr: errors.New("list-subsys failed")}
+	})
+	defer func() { defaultExecutor = originalExecutor }()
+
+	nvme := NewNVMe(nil)
+	err := nvme.RescanAll()
+	assert.ErrorContains(t, err, "list-subsys failed")
+}
@@ -18,25 +18,25 @@ package gonvme
 
 import (
 	"bufio"
-	"encoding/json"
+	"bytes"
+	"errors"
 	"fmt"
-	"io"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
-	"syscall"
-
-	log "github.com/sirupsen/logrus"
+	"sync"
 )
 
 const (
 	// ChrootDirectory allows the nvme commands to be run within a chrooted path, helpful for containerized services
 	ChrootDirectory = "chrootDirectory"
 
+	// FCIncludeOfflinePorts set to "true" discovers and connects from local FC ports which are not online
+	FCIncludeOfflinePorts = "fcIncludeOfflinePorts"
+
 	// NVMePort - port number
 	NVMePort = "4420"
 
@@ -55,17 +55,6 @@ var (
 	DefaultHostIDFile = "/etc/nvme/hostid"
 )
 
-type command interface {
-	Output() ([]byte, error)
-	Start() error
-	Wait() error
-	StderrPipe() (io.ReadCloser, error)
-}
-
-var getCommand = func(name string, arg ...string) command {
-	return exec.Command(name, arg...)
-}
-
 var getPaths = func() []string {
 	return []string{"/sbin/nvme", "/usr/sbin/nvme"}
 }
@@ -74,7 +63,16 @@ var getPaths = func() []string {
 type NVMe struct {
 	NVMeType
 	sessionParser NVMeSessionParser
-	NVMeCommand   string
+	// NVMeCommand is the path of nvme-cli on the host, resolved by NewNVMe
+	NVMeCommand string
+	// Executor runs the nvme-cli commands, on the local host when nil. NewNVMe sets it from the
+	// ExecutorOption and ChrootDirectory options; it may be replaced by any Executor, an ExecutorFunc for instance.
+	Executor Executor
+
+	commandErr     error
+	cliVersionOnce sync.Once
+	cliVersion     NVMeCLIVersion
+	cliVersionErr  error
 }
 
 // NewNVMe - returns a new NVMe client
@@ -86,28 +84,17 @@ func NewNVMe(opts map[string]string) *NVMe {
 		},
 	}
 	nvme.sessionParser = &sessionParser{}
+	nvme.Executor = nvme.optionExecutor()
 
-	paths := getPaths()
-	for _, path := range paths {
-		pathCopy := path
-		if nvme.getChrootDirectory() != "/" {
-			path = nvme.getChrootDirectory() + path
-		}
-
-		info, err := os.Stat(path)
-		if os.IsNotExist(err) {
-			log.Errorf("Error: Path %s does not exist\n", path)
-		} else if err != nil {
-			log.Errorf("Error: Unable to access path %s: %v\n", path, err)
-		} else if info.IsDir() {
-			log.Errorf("Error: Path %s is a directory, not an executable\n", path)
-		} else {
-			log.Infof("Success: Path %s exists and is an executable\n", path)
-			nvme.NVMeCommand = pathCopy
-			log.Infof("nvme.NVMeCommand: %s", nvme.NVMeCommand)
-			break
-		}
+	command, err := nvme.resolveCommand()
+	if err != nil {
+		// left to the executor to find, Available reports the error
+		nvme.log().Errorf("nvme-cli not found: %v", err)
+		command = "nvme"
 	}
+	nvme.NVMeCommand = command
+	nvme.commandErr = err
+	nvme.log().With(LogField{Key: logKeyCommand, Value: nvme.NVMeCommand}).Infof("nvme-cli resolved")
 
 	return &nvme
 }
@@ -120,34 +107,28 @@ func (nvme *NVMe) getChrootDirectory() string {
 	return s
 }
 
-func (nvme *NVMe) buildNVMeCommand(cmd []string) []string {
-	if nvme.getChrootDirectory() == "/" {
-		return cmd
-	}
-	command := []string{"chroot", nvme.getChrootDirectory()}
-	command = append(command, cmd...)
-	return command
-}
-
+// getFCHostInfo returns every local FC port, whatever its state. Ports whose names cannot be
+// read are skipped and reported in a ControllerErrors keyed by host, along with the other ports.
 func (nvme *NVMe) getFCHostInfo() ([]FCHBAInfo, error) {
-	match, err := filepath.Glob(fcHostPath)
+	match, err := filepath.Glob(nvme.hostPath(fcHostPath))
 	if err != nil {
-		log.Errorf("Error gathering fc hosts: %v", err)
+		nvme.log().Errorf("Error gathering FC hosts: %v", err)
 		return []FCHBAInfo{}, err
 	}
 	if len(match) == 0 {
-		log.Errorf("The fc_host path doesn't exist")
+		nvme.log().Errorf("The fc_host path doesn't exist")
 		return []FCHBAInfo{}, err
 	}
 
 	var FCHostsInfo []FCHBAInfo
+	failed := ControllerErrors{}
 	for _, m := range match {
-
-		var FCHostInfo FCHBAInfo
+		FCHostInfo := FCHBAInfo{Host: filepath.Base(m)}
 		portNamePath := path.Join(m, "port_name")
 		data, err := os.ReadFile(filepath.Clean(portNamePath))
 		if err != nil {
-			log.Errorf("match: %s failed to read port_name file: %s", match, err.Error())
+			nvme.log().Errorf("Failed to read the port_name of FC host %s: %v", m, err)
+			failed[FCHostInfo.Host] = err
 			continue
 		}
 		FCHostInfo.PortName = strings.TrimSpace(string(data))
@@ -155,19 +136,83 @@ func (nvme *NVMe) getFCHostInfo() ([]FCHBAInfo, error) {
 		nodeNamePath := path.Join(m, "node_name")
 		data, err = os.ReadFile(filepath.Clean(nodeNamePath))
 		if err != nil {
-			log.Errorf("match: %s failed to read node_name file: %s", match, err.Error())
+			nvme.log().Errorf("Failed to read the node_name of FC host %s: %v", m, err)
+			failed[FCHostInfo.Host] = err
 			continue
 		}
 		FCHostInfo.NodeName = strings.TrimSpace(string(data))
+
+		// the other attributes depend on the driver and are informational
+		attrs := controllerAttributes{dir: m}
+		FCHostInfo.PortState = attrs.string("port_state")
+		FCHostInfo.PortType = attrs.string("port_type")
+		FCHostInfo.Speed = attrs.string("speed")
+		FCHostInfo.FabricName = attrs.string("fabric_name")
+		FCHostInfo.SupportedClasses = attrs.string("supported_classes")
+		FCHostInfo.SymbolicName = attrs.string("symbolic_name")
 		FCHostsInfo = append(FCHostsInfo, FCHostInfo)
 	}
 
+	if len(failed) > 0 {
+		if FCHostsInfo == nil {
+			FCHostsInfo = []FCHBAInfo{}
+		}
+		return FCHostsInfo, failed
+	}
 	if len(FCHostsInfo) == 0 {
-		return []FCHBAInfo{}, err
+		return []FCHBAInfo{}, nil
 	}
 	return FCHostsInfo, nil
 }
 
+// getUsableFCHostInfo returns the local FC ports to discover and connect from, that is the online
+// ports unless FCIncludeOfflinePorts is set. Ports which cannot be read are logged and skipped.
+func (nvme *NVMe) getUsableFCHostInfo() ([]FCHBAInfo, error) {
+	FCHostsInfo, err := nvme.getFCHostInfo()
+	if err != nil {
+		if _, partial := err.(ControllerErrors); !partial || len(FCHostsInfo) == 0 {
+			return []FCHBAInfo{}, err
+		}
+		nvme.log().Errorf("Ignoring unreadable NVMe/FC Hosts: %v", err)
+	}
+
+	usable := make([]FCHBAInfo, 0, len(FCHostsInfo))
+	for _, FCHostInfo := range FCHostsInfo {
+		if !nvme.isUsableFCHost(FCHostInfo) {
+			nvme.log().Infof("Skipping FC host %s in state %s", FCHostInfo.Host, FCHostInfo.PortState)
+			continue
+		}
+		usable = append(usable, FCHostInfo)
+	}
+	return usable, nil
+}
+
+// isUsableFCHost reports whether the port is online; ports which do not report a state are assumed online
+func (nvme *NVMe) isUsableFCHost(FCHostInfo FCHBAInfo) bool {
+	if includeOffline, _ := strconv.ParseBool(nvme.options[FCIncludeOfflinePorts]); includeOffline {
+		return true
+	}
+	return FCHostInfo.PortState == "" || FCHostInfo.PortState == FCPortStateOnline
+}
+
+// checkFCHostOnline returns an error if the local port of the NVMe/FC host address is known and not usable
+func (nvme *NVMe) checkFCHostOnline(hostAddress string) error {
+	// hosts which cannot be read are skipped; the connect itself reports a port which is really down
+	FCHostsInfo, err := nvme.getFCHostInfo()
+	if err != nil {
+		nvme.log().Debugf("Error reading FC hosts, checking only the readable ones: %v", err)
+	}
+	for _, FCHostInfo := range FCHostsInfo {
+		if fcTransportAddress(FCHostInfo.NodeName, FCHostInfo.PortName) != hostAddress {
+			continue
+		}
+		if !nvme.isUsableFCHost(FCHostInfo) {
+			return fmt.Errorf("FC host %s (%s) is %s", FCHostInfo.Host, hostAddress, FCHostInfo.PortState)
+		}
+	}
+	return nil
+}
+
 // DiscoverNVMeTCPTargets - runs nvme discovery and returns a list of NVMeTCP targets.
 func (nvme *NVMe) DiscoverNVMeTCPTargets(address string, login bool) ([]NVMeTarget, error) {
 	return nvme.discoverNVMeTCPTargets(address, login)
@@ -177,12 +222,10 @@ func (nvme *NVMe) discoverNVMeTCPTargets(address string, login bool) ([]NVMeTarg
 	// TODO: add injection check on address
 	// nvme discovery is done via nvme cli
 	// nvme discover -t tcp -a <NVMe interface IP> -s <port>
-	exe := nvme.buildNVMeCommand([]string{nvme.NVMeCommand, "discover", "-t", "tcp", "-a", address, "-s", NVMePort})
-	cmd := getCommand(exe[0], exe[1:]...) // #nosec G204
-
-	out, err := cmd.Output()
+	exe := []string{nvme.NVMeCommand, "discover", "-t", "tcp", "-a", address, "-s", NVMePort}
+	out, err := nvme.output(exe)
 	if err != nil {
-		log.Errorf("\nError discovering %s: %v", address, err)
+		nvme.logOperation("discover", portalField(address)).Errorf("Error discovering NVMe/TCP targets: %v", err)
 		return []NVMeTarget{}, err
 	}
 
@@ -287,7 +330,7 @@ func (nvme *NVMe) discoverNVMeTCPTargets(address string, login bool) ([]NVMeTarg
 		for _, t := range targets {
 			err = nvme.NVMeTCPConnect(t, false)
 			if err != nil {
-				log.Errorf("Error during NVMeTCP connect")
+				nvme.logOperation("discover", targetFields(t)...).Errorf("Error during NVMe/TCP connect: %v", err)
 			}
 		}
 	}
@@ -306,123 +349,26 @@ func (nvme *NVMe) discoverNVMeFCTargets(targetAddress string, login bool) ([]NVM
 	// nvme discover -t fc -a traddr -w host_traddr
 	// where traddr = nn-<Target_WWNN>:pn-<Target_WWPN> and host_traddr = nn-<Initiator_WWNN>:pn-<Initiator_WWPN>
 
-	var out []byte
-	FCHostsInfo, err := nvme.getFCHostInfo()
+	FCHostsInfo, err := nvme.getUsableFCHostInfo()
 	if err != nil || len(FCHostsInfo) == 0 {
-		log.Errorf("Error gathering NVMe/FC Hosts on the host side: %v", err)
+		nvme.logOperation("discover", portalField(targetAddress)).Errorf("Error gathering NVMe/FC Hosts on the host side: %v", err)
 		return []NVMeTarget{}, err
 	}
 
 	targets := make([]NVMeTarget, 0)
 	for _, FCHostInfo := range FCHostsInfo {
-
 		// host_traddr = nn-<Initiator_WWNN>:pn-<Initiator_WWPN>
-		initiatorAddress := strings.Replace(fmt.Sprintf("nn-%s:pn-%s", FCHostInfo.NodeName, FCHostInfo.PortName), "\n", "", -1)
-		exe := nvme.buildNVMeCommand([]string{nvme.NVMeCommand, "discover", "-t", "fc", "-a", targetAddress, "-w", initiatorAddress})
-		cmd := getCommand(exe[0], exe[1:]...) // #nosec G204
-
-		out, err = cmd.Output()
+		initiatorAddress := fcTransportAddress(FCHostInfo.NodeName, FCHostInfo.PortName)
+		var found []NVMeTarget
+		found, err = nvme.discoverFCTargetsOn(targetAddress, initiatorAddress)
 		if err != nil {
 			continue
 		}
-
-		nvmeTarget := NVMeTarget{}
-		entryCount := 0
-		skipIteration := false
-
-		for _, line := range strings.Split(string(out), "\n") {
-
-			// Output should look like:
-
-			// Discovery Log Number of Records 2, Generation counter 2
-			// =====Discovery Log Entry 0======
-			// trtype:  fc
-			// adrfam:  fibre-channel
-			// subtype: nvme subsystem
-			// treq:    not specified
-			// portid:  0
-			// trsvcid: none
-			// subnqn:  nqn.1111-11.com.dell:powerstore:00:a1a1a1a111a1111a111a
-			// traddr:  nn-0x11aaa111a1111a11:aa-0x11aaa11111111a11
-			//
-			// =====Discovery Log Entry 1======
-			// trtype:  tcp
-			// adrfam:  ipv4
-			// subtype: nvme subsystem
-			// treq:    not specified
-			// portid:  2304
-			// trsvcid: 4420
-			// subnqn:  nqn.1111-11.com.dell:powerstore:00:a1a1a1a111a1111a111a
-			// traddr:  1.1.1.1
-			// sectype: none
-
-			tokens := strings.Fields(line)
-			if len(tokens) < 2 {
-				continue
-			}
-			key := tokens[0]
-			value := strings.Join(tokens[1:], "")
-			switch key {
-
-			case "=====Discovery":
-				// add to array
-				if entryCount != 0 && !skipIteration && nvmeTarget.Portal == targetAddress {
-					targets = append(targets, nvmeTarget)
-				}
-				nvmeTarget = NVMeTarget{}
-				nvmeTarget.HostAdr = initiatorAddress
-				skipIteration = false
-				entryCount++
-				continue
-
-			case "trtype:":
-				nvmeTarget.TargetType = value
-				if value != NVMeTransportTypeFC {
-					skipIteration = true
-				}
-				break
-
-			case "traddr:":
-				nvmeTarget.Portal = value
-				break
-
-			case "subnqn:":
-				nvmeTarget.TargetNqn = value
-				break
-
-			case "adrfam:":
-				nvmeTarget.AdrFam = value
-				break
-
-			case "subtype:":
-				nvmeTarget.SubType = value
-				break
-
-			case "treq:":
-				nvmeTarget.Treq = value
-				break
-
-			case "portid:":
-				nvmeTarget.PortID = value
-				break
-
-			case "trsvcid:":
-				nvmeTarget.TrsvcID = value
-				break
-
-			case "sectype:":
-				nvmeTarget.SecType = value
-				break
-
-			}
-		}
-		if !skipIteration && nvmeTarget.TargetNqn != "" && nvmeTarget.Portal == targetAddress {
-			targets = append(targets, nvmeTarget)
-		}
+		targets = append(targets, found...)
 	}
 
 	if len(targets) == 0 {
-		log.Errorf("Error discovering NVMe/FC targets: %v", err)
+		nvme.logOperation("discover", portalField(targetAddress)).Errorf("Error discovering NVMe/FC targets: %v", err)
 		return []NVMeTarget{}, err
 	}
 
@@ -432,7 +378,7 @@ func (nvme *NVMe) discoverNVMeFCTargets(targetAddress string, login bool) ([]NVM
 		for _, t := range targets {
 			err = nvme.NVMeFCConnect(t, false)
 			if err != nil {
-				log.Errorf("Error during NVMeFC connect")
+				nvme.logOperation("discover", targetFields(t)...).Errorf("Error during NVMe/FC connect: %v", err)
 			}
 		}
 	}
@@ -440,6 +386,117 @@ func (nvme *NVMe) discoverNVMeFCTargets(targetAddress string, login bool) ([]NVM
 	return targets, nil
 }
 
+// discoverFCTargetsOn runs an NVMe/FC discovery of the target port from the initiator port and
+// returns the NVMe/FC log entries of the target port
+func (nvme *NVMe) discoverFCTargetsOn(targetAddress string, initiatorAddress string) ([]NVMeTarget, error) {
+	exe := []string{nvme.NVMeCommand, "discover", "-t", "fc", "-a", targetAddress, "-w", initiatorAddress}
+	out, err := nvme.output(exe)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]NVMeTarget, 0)
+	nvmeTarget := NVMeTarget{}
+	entryCount := 0
+	skipIteration := false
+
+	for _, line := range strings.Split(string(out), "\n") {
+
+		// Output should look like:
+
+		// Discovery Log Number of Records 2, Generation counter 2
+		// =====Discovery Log Entry 0======
+		// trtype:  fc
+		// adrfam:  fibre-channel
+		// subtype: nvme subsystem
+		// treq:    not specified
+		// portid:  0
+		// trsvcid: none
+		// subnqn:  nqn.1111-11.com.dell:powerstore:00:a1a1a1a111a1111a111a
+		// traddr:  nn-0x11aaa111a1111a11:aa-0x11aaa11111111a11
+		//
+		// =====Discovery Log Entry 1======
+		// trtype:  tcp
+		// adrfam:  ipv4
+		// subtype: nvme subsystem
+		// treq:    not specified
+		// portid:  2304
+		// trsvcid: 4420
+		// subnqn:  nqn.1111-11.com.dell:powerstore:00:a1a1a1a111a1111a111a
+		// traddr:  1.1.1.1
+		// sectype: none
+
+		tokens := strings.Fields(line)
+		if len(tokens) < 2 {
+			continue
+		}
+		key := tokens[0]
+		value := strings.Join(tokens[1:], "")
+		switch key {
+
+		case "=====Discovery":
+			// add to array
+			if entryCount != 0 && !skipIteration && nvmeTarget.Portal == targetAddress {
+				targets = append(targets, nvmeTarget)
+			}
+			nvmeTarget = NVMeTarget{}
+			nvmeTarget.HostAdr = initiatorAddress
+			skipIteration = false
+			entryCount++
+			continue
+
+		case "trtype:":
+			nvmeTarget.TargetType = value
+			if value != NVMeTransportTypeFC {
+				skipIteration = true
+			}
+			break
+
+		case "traddr:":
+			nvmeTarget.Portal = value
+			break
+
+		case "subnqn:":
+			nvmeTarget.TargetNqn = value
+			break
+
+		case "adrfam:":
+			nvmeTarget.AdrFam = value
+			break
+
+		case "subtype:":
+			nvmeTarget.SubType = value
+			break
+
+		case "treq:":
+			nvmeTarget.Treq = value
+			break
+
+		case "portid:":
+			nvmeTarget.PortID = value
+			break
+
+		case "trsvcid:":
+			nvmeTarget.TrsvcID = value
+			break
+
+		case "sectype:":
+			nvmeTarget.SecType = value
+			break
+
+		}
+	}
+	if !skipIteration && nvmeTarget.TargetNqn != "" && nvmeTarget.Portal == targetAddress {
+		targets = append(targets, nvmeTarget)
+	}
+	return targets, nil
+}
+
+// fcTransportAddress returns the NVMe/FC transport address, nn-<WWNN>:pn-<WWPN>, of a port
+func fcTransportAddress(nodeName string, portName string) string {
+	return strings.Replace(fmt.Sprintf("nn-%s:pn-%s", nodeName, portName), "\n", "", -1)
+}
+
 // GetInitiators returns a list of initiators on the local system.
 func (nvme *NVMe) GetInitiators(filename string) ([]string, error) {
 	return nvme.getInitiators(filename)
@@ -458,8 +515,8 @@ func (nvme *NVMe) getInitiators(filename string) ([]string, error) {
 	if filename == "" {
 		// add default filename(s) here
 		// /etc/nvme/hostnqn is the proper file for CentOS, RedHat, Sles, Ubuntu
-		if nvme.getChrootDirectory() != "/" {
-			initiatorConfig = append(initiatorConfig, nvme.getChrootDirectory()+"/"+DefaultInitiatorNameFile)
+		if nvme.hostRoot() != "/" {
+			initiatorConfig = append(initiatorConfig, nvme.hostRoot()+"/"+DefaultInitiatorNameFile)
 		} else {
 			initiatorConfig = append(initiatorConfig, DefaultInitiatorNameFile)
 		}
@@ -479,7 +536,7 @@ func (nvme *NVMe) getInitiators(filename string) ([]string, error) {
 		// get the contents of the initiator config file
 		out, err := os.ReadFile(filepath.Clean(init))
 		if err != nil {
-			log.Errorf("Error gathering initiator names: %v", err)
+			nvme.log().Errorf("Error gathering initiator names: %v", err)
 		}
 		lines := strings.Split(string(out), "\n")
 
@@ -499,7 +556,7 @@ func (nvme *NVMe) getInitiators(filename string) ([]string, error) {
 
 func (nvme *NVMe) getHostID() (string, error) {
 	// /etc/nvme/hostid is the proper file for CentOS, RedHat, Sles, Ubuntu
-	hostIDFile := filepath.Clean(nvme.getChrootDirectory() + "/" + DefaultHostIDFile)
+	hostIDFile := filepath.Clean(nvme.hostRoot() + "/" + DefaultHostIDFile)
 
 	// Check if file exists
 	_, err := os.Stat(hostIDFile)
@@ -534,67 +591,59 @@ func (nvme *NVMe) nvmeTCPConnect(target NVMeTarget, duplicateConnect bool) error
 	// nvme connect is done via the nvme cli
 	// nvme connect -t tcp -n <target NQN> -a <NVMe interface IP> -s 4420
 	// D allows duplicate connections between same transport host and subsystem port
+	security, err := nvme.connectSecurityArgs(target)
+	if err != nil {
+		return fmt.Errorf("error connecting to nvme target %s at %s: %w", target.TargetNqn, target.Portal, err)
+	}
 	var exe []string
 	if duplicateConnect {
-		exe = nvme.buildNVMeCommand([]string{nvme.NVMeCommand, "connect", "-t", "tcp", "-n", target.TargetNqn, "-a", target.Portal, "-s", NVMePort, "--ctrl-loss-tmo=-1", "-D"})
+		exe = []string{nvme.NVMeCommand, "connect", "-t", "tcp", "-n", target.TargetNqn, "-a", target.Portal, "-s", NVMePort, "--ctrl-loss-tmo=-1", "-D"}
 	} else {
-		exe = nvme.buildNVMeCommand([]string{nvme.NVMeCommand, "connect", "-t", "tcp", "-n", target.TargetNqn, "-a", target.Portal, "-s", NVMePort, "--ctrl-loss-tmo=-1"})
+		exe = []string{nvme.NVMeCommand, "connect", "-t", "tcp", "-n", target.TargetNqn, "-a", target.Portal, "-s", NVMePort, "--ctrl-loss-tmo=-1"}
 	}
-	cmd := getCommand(exe[0], exe[1:]...) // #nosec G204
+	exe = append(exe, security...)
+	_, stderr, err := nvme.run(exe)
 	var Output string
-	stderr, _ := cmd.StderrPipe()
-	err := cmd.Start()
-	if err != nil {
-		return fmt.Errorf("starting nvme connect %s at %s: %v", target.TargetNqn, target.Portal, err)
-	}
-
-	scanner := bufio.NewScanner(stderr)
+	scanner := bufio.NewScanner(bytes.NewReader(stderr))
 	for scanner.Scan() {
 		Output = scanner.Text()
 	}
-	log.Debugf("connect output: %s", Output)
-	err = cmd.Wait()
+	log := nvme.logOperation("connect", targetFields(target)...)
+	log.Debugf("nvme connect output: %s", Output)
 
 	// NVMEAlreadyConnected contains output holder for nvme connect
 	// TODO previous version of nvme lib contained a typo (connnected)
 	NVMEAlreadyConnected := regexp.MustCompile(`already con+nected`)
 	if err != nil {
-		if exiterr, ok := err.(*exec.ExitError); ok {
+		if code, ok := exitCode(err); ok {
 			// nvme connect exited with an exit code != 0
-			nvmeConnectResult := -1
-			nvmeConnectResult = exiterr.ExitCode()
+			nvmeConnectResult := code
 
 			if nvmeConnectResult == 114 || nvmeConnectResult == 70 {
 				// session already exists
 				// do not treat this as a failure
 				// this is applicable if nvme cli version 1.16 or below
 				if Output == "Failed to write to /dev/nvme-fabrics: Operation already in progress" || Output == "" {
-					log.Infof("NVMe connection already exists\n")
+					log.Infof("NVMe connection already exists")
 					err = nil
 				} else {
-					msg := fmt.Sprintf("error connecting to nvme target %s at %s: %v: %s", target.TargetNqn, target.Portal, err, Output)
-					log.Errorf("\n%s", msg)
-					return fmt.Errorf("%s", msg)
+					log.Errorf("nvme connect failed: %v: %s", err, Output)
+					return fmt.Errorf("error connecting to nvme target %s at %s: %v: %s", target.TargetNqn, target.Portal, err, Output)
 				}
 			} else if nvmeConnectResult == 1 && NVMEAlreadyConnected.MatchString(Output) {
 				// session already exists
 				// this is applicable if nvme cli version is 2.0 and above
-				log.Infof("NVMe connection already exists\n")
+				log.Infof("NVMe connection already exists")
 				err = nil
-			} else {
-				log.Errorf("\nnvme connect failure: %v, %s", err, err.Error())
 			}
-		} else {
-			log.Errorf("\nError during nvme connect %s at %s: %v", target.TargetNqn, target.Portal, err)
 		}
 
 		if err != nil {
-			msg := fmt.Sprintf("error connecting to nvme target %s at %s: %v: %s", target.TargetNqn, target.Portal, err, Output)
-			log.Errorf("\n%s", msg)
-			return fmt.Errorf("%s", msg)
+			log.Errorf("nvme connect failed: %v: %s", err, Output)
+			return fmt.Errorf("error connecting to nvme target %s at %s: %v: %s", target.TargetNqn, target.Portal, err, Output)
 		}
 	} else {
-		log.Infof("\nnvme connect successful: %s", target.TargetNqn)
+		log.Infof("nvme connect successful")
 	}
 
 	return nil
@@ -610,65 +659,64 @@ func (nvme *NVMe) nvmeFCConnect(target NVMeTarget, duplicateConnect bool) error
 	// nvme connect -t fc -a traddr -w host_traddr -n target_nqn
 	// where traddr = nn-<Target_WWNN>:pn-<Target_WWPN> and host_traddr = nn-<Initiator_WWNN>:pn-<Initiator_WWPN>
 	// D allows duplicate connections between same transport host and subsystem port
+	if err := nvme.checkFCHostOnline(target.HostAdr); err != nil {
+		return fmt.Errorf("not connecting %s at %s: %w", target.TargetNqn, target.Portal, err)
+	}
+
+	if target.TLS {
+		return fmt.Errorf("not connecting %s at %s: TLS is only supported by NVMe/TCP", target.TargetNqn, target.Portal)
+	}
+	security, err := nvme.connectSecurityArgs(target)
+	if err != nil {
+		return fmt.Errorf("not connecting %s at %s: %w", target.TargetNqn, target.Portal, err)
+	}
 	var exe []string
 	if duplicateConnect {
-		exe = nvme.buildNVMeCommand([]string{nvme.NVMeCommand, "connect", "-t", "fc", "-a", target.Portal, "-w", target.HostAdr, "-n", target.TargetNqn, "--ctrl-loss-tmo=-1", "-D"})
+		exe = []string{nvme.NVMeCommand, "connect", "-t", "fc", "-a", target.Portal, "-w", target.HostAdr, "-n", target.TargetNqn, "--ctrl-loss-tmo=-1", "-D"}
 	} else {
-		exe = nvme.buildNVMeCommand([]string{nvme.NVMeCommand, "connect", "-t", "fc", "-a", target.Portal, "-w", target.HostAdr, "-n", target.TargetNqn, "--ctrl-loss-tmo=-1"})
+		exe = []string{nvme.NVMeCommand, "connect", "-t", "fc", "-a", target.Portal, "-w", target.HostAdr, "-n", target.TargetNqn, "--ctrl-loss-tmo=-1"}
 	}
-	cmd := getCommand(exe[0], exe[1:]...) // #nosec G204
+	exe = append(exe, security...)
+	_, stderr, err := nvme.run(exe)
 	var Output string
-	stderr, _ := cmd.StderrPipe()
-	err := cmd.Start()
-	if err != nil {
-		return fmt.Errorf("starting nvme connect %s at %s: %v", target.TargetNqn, target.Portal, err)
-	}
-
-	scanner := bufio.NewScanner(stderr)
+	scanner := bufio.NewScanner(bytes.NewReader(stderr))
 	for scanner.Scan() {
 		Output = scanner.Text()
 	}
-	err = cmd.Wait()
+	log := nvme.logOperation("connect", targetFields(target)...)
 
 	// NVMEAlreadyConnected contains output holder for nvme connect
 	// TODO previous version of nvme lib contained a typo (connnected)
 	NVMEAlreadyConnected := regexp.MustCompile(`already con+nected`)
 	if err != nil {
-		if exiterr, ok := err.(*exec.ExitError); ok {
+		if code, ok := exitCode(err); ok {
 			// nvme connect exited with an exit code != 0
-			nvmeConnectResult := -1
-			if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
-				nvmeConnectResult = status.ExitStatus()
-			}
+			nvmeConnectResult := code
 			if nvmeConnectResult == 114 || nvmeConnectResult == 70 {
 				// session already exists
 				// do not treat this as a failure
 				// this is applicable if nvme cli version 1.16 or below
 				if Output == "Failed to write to /dev/nvme-fabrics: Operation already in progress" || Output == "" {
-					log.Infof("NVMe connection already exists\n")
+					log.Infof("NVMe connection already exists")
 					err = nil
 				} else {
-					log.Errorf("\nError during nvme connect %s at %s: %v", target.TargetNqn, target.Portal, err)
+					log.Errorf("NVMe/FC connect failed: %v: %s", err, Output)
 					return err
 				}
 			} else if nvmeConnectResult == 1 && NVMEAlreadyConnected.MatchString(Output) {
 				// session already exists
 				// this is applicable if nvme cli version is 2.0 and above
-				log.Infof("NVMe connection already exists\n")
+				log.Infof("NVMe connection already exists")
 				err = nil
-			} else {
-				log.Errorf("NVMe/FC connect failure: %v", err)
 			}
-		} else {
-			log.Errorf("Error during NVMe/FC connect %s at %s for %s host: %v", target.TargetNqn, target.Portal, target.HostAdr, err)
 		}
 
 		if err != nil {
-			log.Errorf("Error during NVMe/FC connect %s at %s for %s host: %v", target.TargetNqn, target.Portal, target.HostAdr, err)
+			log.Errorf("NVMe/FC connect failed: %v: %s", err, Output)
 			return err
 		}
 	} else {
-		log.Infof("NVMe/FC connect successful: %s", target.TargetNqn)
+		log.Infof("NVMe/FC connect successful")
 	}
 
 	return nil
@@ -682,131 +730,78 @@ func (nvme *NVMe) NVMeDisconnect(target NVMeTarget) error {
 func (nvme *NVMe) nvmeDisconnect(target NVMeTarget) error {
 	// nvme disconnect is done via the nvme cli
 	// nvme disconnect -n <target NQN>
-	exe := nvme.buildNVMeCommand([]string{nvme.NVMeCommand, "disconnect", "-n", target.TargetNqn})
-	cmd := getCommand(exe[0], exe[1:]...) // #nosec G204
-
-	_, err := cmd.Output()
+	exe := []string{nvme.NVMeCommand, "disconnect", "-n", target.TargetNqn}
+	_, err := nvme.output(exe)
 
+	log := nvme.logOperation("disconnect", targetFields(target)...)
 	if err != nil {
-		log.Errorf("\nError during NVMe disconnect %s at %s: %v", target.TargetNqn, target.Portal, err)
+		log.Errorf("NVMe disconnect failed: %v", err)
 	} else {
-		log.Infof("\nnvme disconnect successful: %s", target.TargetNqn)
+		log.Infof("NVMe disconnect successful")
 	}
 
 	return err
 }
 
 // ListNVMeDeviceAndNamespace returns the NVMe device paths and namespace of each of the NVMe device.
+// As in earlier releases, devices of an unrecognised format are not returned: when no device of the
+// output is recognised a warning is logged and no device is returned, without an error.
+// Use GetInventory to get ErrUnknownListFormat instead.
 func (nvme *NVMe) ListNVMeDeviceAndNamespace() ([]DevicePathAndNamespace, error) {
-	exe := nvme.buildNVMeCommand([]string{"nvme", "list", "-o", "json"})
-	cmd := getCommand(exe[0], exe[1:]...) // #nosec G204
-
-	output, err := cmd.Output()
+	exe := append([]string{nvme.NVMeCommand, "list"}, nvme.jsonOutputArgs("list")...)
+	output, err := nvme.output(exe)
 	if err != nil {
 		return []DevicePathAndNamespace{}, err
 	}
 
-	type NvmeResult struct {
-		Devices []interface{}
+	// Both the pre nvme-cli 2.11 format (NameSpace and DevicePath per device) and the
+	// 2.11 format (Devices -> Subsystems -> Namespaces, with NameSpace holding the
+	// device name and NSID the namespace ID) are handled by parseNVMeList.
+	// See the UT for the different formats.
+	inventory, err := parseNVMeList(output)
+	if errors.Is(err, ErrUnknownListFormat) {
+		nvme.log().Warnf("Ignoring nvme list output of an unknown format: %q", output)
+		return nil, nil
 	}
-
-	var nvmeResult NvmeResult
-	err = json.Unmarshal(output, &nvmeResult)
 	if err != nil {
-		log.Errorf("Could not unmarshal nvme list output: %v\nnvme list output: '%s'", err, output)
+		nvme.log().Errorf("Could not parse nvme list output %q: %v", output, err)
 		return []DevicePathAndNamespace{}, err
 	}
 
 	var result []DevicePathAndNamespace
-	for _, unknownDevice := range nvmeResult.Devices {
-		// This format appears in nvme-cli 2.11. In this format the Namespace
-		// field has the device ID and the NSID is the namespace ID.
-		// See the UT for the different formats.
-		//
-		//
-		// {
-		// 	"Devices":[
-		// 		{
-		// 		"HostNQN":"nqn.2014-08.org.nvmexpress:uuid:a66f1c42-4bce-a619-9c59-9ae6ac2ccb8a",
-		// 		"HostID":"a2d57d74-a198-4e6b-aa78-97af9cd00f31",
-		// 		"Subsystems":[
-		//          ...
-		// 			"Namespaces":[
-		// 				{
-		// 				"NameSpace":"nvme0n1",
-		// 				"Generic":"ng0n1",
-		// 				"NSID":293,
-		// 				"UsedBytes":620130304,
-		// 				"MaximumLBA":6291456,
-		// 				"PhysicalSize":3221225472,
-		// 				"SectorSize":512
-		// 				}
-		// 			]
-		// 			}
-		// 		]
-		// 		}
-		// 	]
-		// }
-		subsystems, hasSubsystems := unknownDevice.(map[string]interface{})["Subsystems"].([]interface{})
-		if hasSubsystems {
-			for _, subsystem := range subsystems {
-				subsystem := subsystem.(map[string]interface{})
-				namespaces, hasNamespaces := subsystem["Namespaces"].([]interface{})
-				if hasNamespaces {
-					for _, namespace := range namespaces {
-						namespace := namespace.(map[string]interface{})
-						ns, hasNs := namespace["NameSpace"].(string)
-						nsid, hasNsid := namespace["NSID"].(float64)
-
-						if hasNs && hasNsid {
-							devicePath := ns
-							if !strings.HasPrefix(ns, "/dev/") {
-								devicePath = "/dev/" + ns
-							}
-							pathAndNameSpace := DevicePathAndNamespace{
-								Namespace:  strconv.FormatFloat(nsid, 'f', -1, 64),
-								DevicePath: devicePath,
-							}
-							result = append(result, pathAndNameSpace)
-						}
-					}
-				}
-			}
-		} else {
-			// Releases prior to nvme-cli 2.11 use NameSpace and DevicePath.
-			// {
-			// 	"Devices" : [
-			// 	  {
-			// 		"NameSpace" : 9217,
-			// 		"DevicePath" : "/dev/nvme0n1",
-			// 		"Firmware" : "2.1.0.0",
-			// 		"Index" : 0,
-			// 		"ModelNumber" : "dellemc",
-			// 		"SerialNumber" : "FP08RZ2",
-			// 		"UsedBytes" : 0,
-			// 		"MaximumLBA" : 10485760,
-			// 		"PhysicalSize" : 5368709120,
-			// 		"SectorSize" : 512
-			// 	  }
-			//  ]
-			device := unknownDevice.(map[string]interface{})
-			nameSpace, hasNameSpace := device["NameSpace"].(float64)
-			devicePath, hasDevice := device["DevicePath"].(string)
-
-			if hasNameSpace && hasDevice {
-				pathAndNameSpace := DevicePathAndNamespace{
-					Namespace:  strconv.FormatFloat(nameSpace, 'f', -1, 64),
-					DevicePath: devicePath,
-				}
-				result = append(result, pathAndNameSpace)
-			}
+	for _, ns := range inventory.AllNamespaces() {
+		if ns.DevicePath == "" {
+			continue
 		}
+		result = append(result, DevicePathAndNamespace{
+			DevicePath: ns.DevicePath,
+			Namespace:  strconv.FormatUint(uint64(ns.NSID), 10),
+		})
 	}
 
 	return result, nil
 }
 
-// ListNVMeNamespaceID returns the namespace IDs for each NVME device path
+// GetInventory returns the host, subsystem, controller and namespace view of nvme list
+func (nvme *NVMe) GetInventory() (Inventory, error) {
+	exe := append([]string{nvme.NVMeCommand, "list", "-v"}, nvme.jsonOutputArgs("list")...)
+	output, err := nvme.output(exe)
+	if err != nil {
+		return Inventory{}, err
+	}
+
+	inventory, err := parseNVMeList(output)
+	if err != nil {
+		nvme.log().Errorf("Could not parse nvme list output: %v", err)
+		return Inventory{}, err
+	}
+	return inventory, nil
+}
+
+// ListNVMeNamespaceID returns the namespace IDs for each NVME device path.
+// The JSON output of nvme list-ns is only asked from the nvme-cli releases providing it.
+// Devices which cannot be listed are logged and left out, and the error is always nil;
+// use ListNamespaces to know which controllers failed.
 func (nvme *NVMe) ListNVMeNamespaceID(NVMeDeviceAndNamespace []DevicePathAndNamespace) (map[DevicePathAndNamespace][]string, error) {
 	/* ListNVMeNamespaceID Output
 	{devicePath namespace} [namespaceId1 namespaceId2]
@@ -817,42 +812,22 @@ func (nvme *NVMe) ListNVMeNamespaceID(NVMeDeviceAndNamespace []DevicePathAndName
 	*/
 	namespaceIDs := make(map[DevicePathAndNamespace][]string)
 
-	var err error
 	for _, devicePathAndNamespace := range NVMeDeviceAndNamespace {
-
 		devicePath := devicePathAndNamespace.DevicePath
 
-		exe := nvme.buildNVMeCommand([]string{"nvme", "list-ns", devicePath})
-		/* nvme list-ns /dev/nvme0n1
-		[   0]:0x2401
-		[   1]:0x2406
-		*/
-		cmd := getCommand(exe[0], exe[1:]...) // #nosec G204
-		output, err := cmd.Output()
+		nsids, err := nvme.listNamespaceIDs(devicePath, ListNamespacesOptions{})
 		if err != nil {
+			nvme.log().With(deviceField(devicePath)).Errorf("Error listing namespaces: %v", err)
 			continue
 		}
 
-		str := string(output)
-		lines := strings.Split(str, "\n")
-
 		var namespaceDevice []string
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line != "" {
-				if len(strings.Split(line, ":")) >= 2 {
-					nsDevice := strings.Split(line, ":")[1]
-					namespaceDevice = append(namespaceDevice, nsDevice)
-				}
-			}
+		for _, nsid := range nsids {
+			namespaceDevice = append(namespaceDevice, fmt.Sprintf("0x%x", nsid))
 		}
 		namespaceIDs[devicePathAndNamespace] = namespaceDevice
 	}
 
-	if len(namespaceIDs) == 0 {
-		return map[DevicePathAndNamespace][]string{}, err
-	}
-
 	return namespaceIDs, nil
 }
 
@@ -861,9 +836,7 @@ func (nvme *NVMe) GetNVMeDeviceData(path string) (string, string, error) {
 	var nguid string
 	var namespace string
 
-	exe := nvme.buildNVMeCommand([]string{"nvme", "id-ns", path})
-	cmd := getCommand(exe[0], exe[1:]...) // #nosec G204
-
+	exe := []string{nvme.NVMeCommand, "id-ns", path}
 	/*
 		nvme id-ns /dev/nvme3n1 0x95
 		NVME Identify Namespace 149:
@@ -900,7 +873,7 @@ func (nvme *NVMe) GetNVMeDeviceData(path string) (string, string, error) {
 		lbaf  0 : ms:0   lbads:9  rp:0 (in use)
 	*/
 
-	output, err := cmd.Output()
+	output, err := nvme.output(exe)
 	if err != nil {
 		return "", "", err
 	}
@@ -928,9 +901,7 @@ func (nvme *NVMe) GetNVMeDeviceData(path string) (string, string, error) {
 
 // GetSessions queries information about  NVMe sessions
 func (nvme *NVMe) GetSessions() ([]NVMESession, error) {
-	exe := nvme.buildNVMeCommand([]string{"nvme", "list-subsys", "-o", "json"})
-	cmd := getCommand(exe[0], exe[1:]...) // #nosec G204
-
+	exe := append([]string{nvme.NVMeCommand, "list-subsys"}, nvme.jsonOutputArgs("list-subsys")...)
 	/*
 		[
 		  {
@@ -961,7 +932,7 @@ func (nvme *NVMe) GetSessions() ([]NVMESession, error) {
 		]
 	*/
 
-	output, err := cmd.Output()
+	output, err := nvme.output(exe)
 	if err != nil {
 		if isNoObjsExitCode(err) {
 			return []NVMESession{}, nil
@@ -972,19 +943,14 @@ func (nvme *NVMe) GetSessions() ([]NVMESession, error) {
 }
 
 func isNoObjsExitCode(err error) bool {
-	if err != nil {
-		if exitError, ok := err.(*exec.ExitError); ok {
-			return exitError.ExitCode() == NVMeNoObjsFoundExitCode
-		}
-	}
-	return false
+	code, ok := exitCode(err)
+	return ok && code == NVMeNoObjsFoundExitCode
 }
 
 // DeviceRescan rescan the NVMe controller device
 func (nvme *NVMe) DeviceRescan(device string) error {
-	exe := nvme.buildNVMeCommand([]string{"nvme", "ns-rescan", device})
-	cmd := getCommand(exe[0], exe[1:]...) // #nosec G204
-	_, err := cmd.Output()
+	exe := []string{nvme.NVMeCommand, "ns-rescan", device}
+	_, err := nvme.output(exe)
 	if err != nil {
 		return err
 	}
@@ -17,10 +17,8 @@
 package gonvme
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
-	"io"
 	"os"
 	"os/exec"
 	"testing"
@@ -133,59 +131,54 @@ func TestGetChrootDirectory(t *testing.T) {
 	assert.Equal(t, "/", chrootDir)
 }
 
-func TestBuildNVMeCommand(t *testing.T) {
-	opts := map[string]string{
-		"chrootDirectory": "/test",
-	}
-	nvme := NewNVMe(opts)
-
-	cmd := []string{"nvme", "list"}
-	builtCmd := nvme.buildNVMeCommand(cmd)
-	expectedCmd := []string{"chroot", "/test", "nvme", "list"}
-	assert.Equal(t, expectedCmd, builtCmd)
-
-	opts = map[string]string{}
-	nvme = NewNVMe(opts)
-
-	builtCmd = nvme.buildNVMeCommand(cmd)
-	assert.Equal(t, cmd, builtCmd)
-}
-
 func TestGetFCHostInfo(t *testing.T) {
 	tests := []struct {
 		name          string
 		fcHostPattern string
 		want          []FCHBAInfo
 		wantErr       bool
+		// wantFailed are the FC hosts named in the ControllerErrors returned
+		wantFailed []string
 	}{
 		{
 			"successfully gets fibre channel hosts",
 			"testdata/fc_host/host*",
 			[]FCHBAInfo{
 				{
-					NodeName: "00:00:00:00:00:00:00:01",
-					PortName: "00:00:00:00:00:00:00:01",
+					Host:             "host1",
+					NodeName:         "00:00:00:00:00:00:00:01",
+					PortName:         "00:00:00:00:00:00:00:01",
+					PortState:        "Online",
+					PortType:         "NPort (fabric via point-to-point)",
+					Speed:            "32 Gbit",
+					FabricName:       "0x100000051e000001",
+					SupportedClasses: "Class 3",
+					SymbolicName:     "QLE2742 FW:v9.06.02 DVR:v10.02.07.400-k",
 				},
 			},
 			false,
+			nil,
 		},
 		{
 			"no fibre channel hosts due to path doesn't exist",
 			"testdata/bad/fc_host/host*",
 			[]FCHBAInfo{},
 			false,
+			nil,
 		},
 		{
 			"no fibre channel hosts due to malformed hosts",
 			"testdata/fc_host_bad/host*",
 			[]FCHBAInfo{},
-			false,
+			true,
+			[]string{"host1", "host2"},
 		},
 		{
 			"error reading path due to malformed path",
 			"**/[invalid",
 			[]FCHBAInfo{},
 			true,
+			nil,
 		},
 	}
 
@@ -197,15 +190,98 @@ func TestGetFCHostInfo(t *testing.T) {
 
 			nvme := NewNVMe(nil)
 			got, err := nvme.getFCHostInfo()
-			if tc.wantErr {
-				assert.Error(t, err)
-			} else {
+			if !tc.wantErr {
+				assert.NoError(t, err)
 				assert.Equal(t, tc.want, got)
+				return
+			}
+			assert.Error(t, err)
+			if tc.wantFailed != nil {
+				assert.Equal(t, tc.want, got)
+				var ctrlErrs ControllerErrors
+				assert.True(t, errors.As(err, &ctrlErrs))
+				var failed []string
+				for host, hostErr := range ctrlErrs {
+					failed = append(failed, host)
+					assert.ErrorIs(t, hostErr, os.ErrNotExist, host)
+				}
+				assert.ElementsMatch(t, tc.wantFailed, failed)
 			}
 		})
 	}
 }
 
+func TestGetFCHostInfoPartialErrors(t *testing.T) {
+	setupFCSysfs(t, map[string]map[string]string{
+		"host1": fcTestHosts["host1"],
+		"host2": {"port_state": "Online"},
+	}, nil)
+
+	got, err := NewNVMe(nil).getFCHostInfo()
+	assert.Len(t, got, 1)
+	assert.Equal(t, "host1", got[0].Host)
+	failed, ok := err.(ControllerErrors)
+	assert.True(t, ok)
+	assert.Contains(t, failed, "host2")
+
+	// the readable ports are still used
+	usable, err := NewNVMe(nil).getUsableFCHostInfo()
+	assert.NoError(t, err)
+	assert.Len(t, usable, 1)
+}
+
+func TestGetUsableFCHostInfo(t *testing.T) {
+	setupFCSysfs(t, map[string]map[string]string{
+		"host1": fcTestHosts["host1"],
+		"host2": {"port_name": "0x100000109b6460e2", "node_name": "0x200000109b6460e2", "port_state": "Linkdown"},
+		"host3": {"port_name": "0x100000109b6460e3", "node_name": "0x200000109b6460e3"},
+	}, nil)
+
+	usable, err := NewNVMe(nil).getUsableFCHostInfo()
+	assert.NoError(t, err)
+	var hosts []string
+	for _, hba := range usable {
+		hosts = append(hosts, hba.Host)
+	}
+	assert.Equal(t, []string{"host1", "host3"}, hosts)
+
+	usable, err = NewNVMe(map[string]string{FCIncludeOfflinePorts: "true"}).getUsableFCHostInfo()
+	assert.NoError(t, err)
+	assert.Len(t, usable, 3)
+
+	// only unreadable ports
+	setupFCSysfs(t, map[string]map[string]string{"host1": {"port_state": "Online"}}, nil)
+	usable, err = NewNVMe(nil).getUsableFCHostInfo()
+	assert.Error(t, err)
+	assert.Empty(t, usable)
+}
+
+func TestNVMeFCConnectOfflineHost(t *testing.T) {
+	setupFCSysfs(t, map[string]map[string]string{
+		"host1": {"port_name": "0x100000109b6460e1", "node_name": "0x200000109b6460e1", "port_state": "Linkdown"},
+	}, nil)
+	connects := 0
+	originalExecutor := defaultExecutor
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		connects++
+		return mockCommand{}
+	})
+	defer func() { defaultExecutor = originalExecutor }()
+
+	target := NVMeTarget{
+		Portal:    "nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf",
+		TargetNqn: "nqn.1988-11.com.dell:powermax:00:000120001647",
+		HostAdr:   "nn-0x200000109b6460e1:pn-0x100000109b6460e1",
+	}
+	err := NewNVMe(nil).nvmeFCConnect(target, false)
+	assert.ErrorContains(t, err, "Linkdown")
+	assert.Equal(t, 0, connects)
+
+	err = NewNVMe(map[string]string{FCIncludeOfflinePorts: "true"}).nvmeFCConnect(target, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, connects)
+}
+
 func TestGetInitiators(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -330,14 +406,14 @@ func TestGetHostID(t *testing.T) {
 func TestListNVMeDeviceAndNamespace(t *testing.T) {
 	tests := []struct {
 		name         string
-		getCommandFn func(_ string, _ ...string) command
+		getCommandFn func(_ string, _ ...string) mockCommand
 		want         []DevicePathAndNamespace
 		wantErr      bool
 	}{
 		{
 			"nvme-cli pre 2_11 format",
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					out: []byte(`{
 						"Devices" : [
 						  {
@@ -382,8 +458,8 @@ func TestListNVMeDeviceAndNamespace(t *testing.T) {
 		},
 		{
 			"nvme-cli 2_11 format",
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					out: []byte(`{
 						"Devices":[
 							{
@@ -459,8 +535,8 @@ func TestListNVMeDeviceAndNamespace(t *testing.T) {
 		},
 		{
 			"powermax devices",
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					out: []byte(`{
 						"Devices":[
 							{
@@ -518,8 +594,8 @@ func TestListNVMeDeviceAndNamespace(t *testing.T) {
 		},
 		{
 			"error listing devices",
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					outErr: errors.New("error listing devices"),
 				}
 			},
@@ -528,8 +604,8 @@ func TestListNVMeDeviceAndNamespace(t *testing.T) {
 		},
 		{
 			"error on unmarshalling json",
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					out: []byte(`{
 						"Devices" : [
 						  {
@@ -540,10 +616,20 @@ func TestListNVMeDeviceAndNamespace(t *testing.T) {
 			nil,
 			true,
 		},
+		{
+			"device entry of unexpected type",
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
+					out: []byte(`{"Devices" : ["nvme0n1"]}`),
+				}
+			},
+			nil,
+			true,
+		},
 		{
 			"unknown data format",
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					out: []byte(`{
 						"Devices" : [
 						  {
@@ -560,9 +646,9 @@ func TestListNVMeDeviceAndNamespace(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			originalGetCommand := getCommand
-			getCommand = tc.getCommandFn
-			defer func() { getCommand = originalGetCommand }()
+			originalExecutor := defaultExecutor
+			defaultExecutor = mockExecutor(tc.getCommandFn)
+			defer func() { defaultExecutor = originalExecutor }()
 
 			nvme := NewNVMe(nil)
 			got, err := nvme.ListNVMeDeviceAndNamespace()
@@ -575,18 +661,93 @@ func TestListNVMeDeviceAndNamespace(t *testing.T) {
 	}
 }
 
+func TestListNVMeDeviceAndNamespaceUnknownFormat(t *testing.T) {
+	originalExecutor := defaultExecutor
+	defer func() { defaultExecutor = originalExecutor }()
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		return mockCommand{out: []byte(`{"Devices":[{"ValidButNotWhatWeExpect":"value"}]}`)}
+	})
+
+	recorder := &fieldRecorder{}
+	nvme := NewNVMe(nil)
+	nvme.Logger = recorder
+	got, err := nvme.ListNVMeDeviceAndNamespace()
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+	last := recorder.messages[len(recorder.messages)-1]
+	assert.Equal(t, LogLevelWarn, last.level)
+	assert.Contains(t, last.msg, "unknown format")
+}
+
+func TestGetInventory(t *testing.T) {
+	tests := []struct {
+		name         string
+		getCommandFn func(_ string, _ ...string) mockCommand
+		want         int
+		wantErr      bool
+	}{
+		{
+			"successfully gets inventory",
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
+					out: []byte(`{"Devices":[{"HostNQN":"nqn.2014-08.org.nvmexpress:uuid:a66f1c42","Subsystems":[{"Subsystem":"nvme-subsys0","Namespaces":[{"NameSpace":"nvme0n1","NSID":1},{"NameSpace":"nvme0n2","NSID":2}]}]}]}`),
+				}
+			},
+			2,
+			false,
+		},
+		{
+			"error running nvme list",
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
+					outErr: errors.New("error listing devices"),
+				}
+			},
+			0,
+			true,
+		},
+		{
+			"unknown data format",
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
+					out: []byte(`{"Devices":[{"ValidButNotWhatWeExpect":"value"}]}`),
+				}
+			},
+			0,
+			true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			originalExecutor := defaultExecutor
+			defaultExecutor = mockExecutor(tc.getCommandFn)
+			defer func() { defaultExecutor = originalExecutor }()
+
+			nvme := NewNVMe(nil)
+			got, err := nvme.GetInventory()
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, got.AllNamespaces(), tc.want)
+			}
+		})
+	}
+}
+
 func TestListNVMeNamespaceID(t *testing.T) {
 	tests := []struct {
 		name         string
-		getCommandFn func(_ string, _ ...string) command
+		getCommandFn func(_ string, _ ...string) mockCommand
 		devices      []DevicePathAndNamespace
 		want         map[DevicePathAndNamespace][]string
 		wantErr      bool
 	}{
 		{
 			"successfully lists device IDs",
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					out: []byte(`
 		[   0]:0x2401
 		[   1]:0x2406`),
@@ -606,10 +767,37 @@ func TestListNVMeNamespaceID(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"nvme-cli 1.x list-ns without output format",
+			func(_ string, args ...string) mockCommand {
+				if args[0] == "version" {
+					return mockCommand{out: []byte("nvme version 1.16\n")}
+				}
+				for _, arg := range args {
+					if arg == "-o" {
+						return mockCommand{outErr: &ExitError{Code: 1}, stdErr: []byte("list-ns: unrecognized option '-o'\n")}
+					}
+				}
+				return mockCommand{out: []byte("[   0]:0x36\n")}
+			},
+			[]DevicePathAndNamespace{
+				{
+					DevicePath: "/dev/nvme0n1",
+					Namespace:  "54",
+				},
+			},
+			map[DevicePathAndNamespace][]string{
+				{
+					DevicePath: "/dev/nvme0n1",
+					Namespace:  "54",
+				}: {"0x36"},
+			},
+			false,
+		},
 		{
 			"empty resposne from error listing",
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					outErr: errors.New("error listing devices"),
 				}
 			},
@@ -626,14 +814,15 @@ func TestListNVMeNamespaceID(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			originalGetCommand := getCommand
-			getCommand = tc.getCommandFn
-			defer func() { getCommand = originalGetCommand }()
+			originalExecutor := defaultExecutor
+			defaultExecutor = mockExecutor(tc.getCommandFn)
+			defer func() { defaultExecutor = originalExecutor }()
 
 			nvme := NewNVMe(nil)
 			got, err := nvme.ListNVMeNamespaceID(tc.devices)
 			if tc.wantErr {
 				assert.Error(t, err)
+				assert.Equal(t, tc.want, got)
 			} else {
 				assert.Equal(t, tc.want, got)
 			}
@@ -644,14 +833,14 @@ func TestListNVMeNamespaceID(t *testing.T) {
 func TestGetSessions(t *testing.T) {
 	tests := []struct {
 		name         string
-		getCommandFn func(_ string, _ ...string) command
+		getCommandFn func(_ string, _ ...string) mockCommand
 		want         []NVMESession
 		wantErr      bool
 	}{
 		{
 			"successfully gets sessions",
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					out: []byte(`[
 		  {
 		    "HostNQN":"nqn.2014-08.org.nvmexpress:uuid:1a11111a-aa11-11aa-1111-a11aa1a11111",
@@ -688,6 +877,7 @@ func TestGetSessions(t *testing.T) {
 					Name:              "nvme3",
 					NVMETransportName: "tcp",
 					NVMESessionState:  "live",
+					HostAddress:       "10.1.1.2",
 				},
 				{
 					Target:            "nqn.1988-11.com.dell:mock:00:1a1111a1111aAA11111A",
@@ -695,14 +885,15 @@ func TestGetSessions(t *testing.T) {
 					Name:              "nvme2",
 					NVMETransportName: "tcp",
 					NVMESessionState:  "live",
+					HostAddress:       "10.1.1.2",
 				},
 			},
 			false,
 		},
 		{
 			"error listing sessions",
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					outErr: errors.New("error"),
 				}
 			},
@@ -713,9 +904,9 @@ func TestGetSessions(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			originalGetCommand := getCommand
-			getCommand = tc.getCommandFn
-			defer func() { getCommand = originalGetCommand }()
+			originalExecutor := defaultExecutor
+			defaultExecutor = mockExecutor(tc.getCommandFn)
+			defer func() { defaultExecutor = originalExecutor }()
 
 			nvme := NewNVMe(nil)
 			got, err := nvme.GetSessions()
@@ -736,23 +927,17 @@ type mockCommand struct {
 	stdErr   []byte
 }
 
-func (m mockCommand) Output() ([]byte, error) {
-	if m.outErr != nil {
-		return nil, m.outErr
-	}
-	return m.out, nil
-}
+// mockExecutor runs the commands as described by the mockCommand returned for each
+type mockExecutor func(name string, args ...string) mockCommand
 
-func (m mockCommand) Start() error {
-	return m.startErr
-}
-
-func (m mockCommand) Wait() error {
-	return m.waitErr
-}
-
-func (m mockCommand) StderrPipe() (io.ReadCloser, error) {
-	return io.NopCloser(bytes.NewReader(m.stdErr)), nil
+func (m mockExecutor) Run(name string, args ...string) ([]byte, []byte, error) {
+	c := m(name, args...)
+	for _, err := range []error{c.startErr, c.outErr, c.waitErr} {
+		if err != nil {
+			return nil, c.stdErr, err
+		}
+	}
+	return c.out, c.stdErr, nil
 }
 
 func TestDiscoverNVMeTCPTargets(t *testing.T) {
@@ -769,15 +954,15 @@ traddr:  10.0.0.1
 sectype: none
 `
 	reset()
-	originalGetCommand := getCommand
-	getCommandFunc := func(_ string, _ ...string) command {
-		return &mockCommand{
+	originalExecutor := defaultExecutor
+	getCommandFunc := func(_ string, _ ...string) mockCommand {
+		return mockCommand{
 			out:    []byte(mockOutput),
 			outErr: nil,
 		}
 	}
-	getCommand = getCommandFunc
-	defer func() { getCommand = originalGetCommand }()
+	defaultExecutor = mockExecutor(getCommandFunc)
+	defer func() { defaultExecutor = originalExecutor }()
 	_, err := nvme.discoverNVMeTCPTargets(tcpTestPortal, false)
 	if err != nil {
 		t.Error(err.Error())
@@ -799,15 +984,15 @@ trsvcid: none
 subnqn:  nqn.1111-11.com.dell:powerstore:00:a1a1a1a111a1111a111a
 traddr:  nn-0x11aaa111a1111a11:aa-0x11aaa11111111a11
 `
-	originalGetCommand := getCommand
-	getCommandFunc := func(_ string, _ ...string) command {
-		return &mockCommand{
+	originalExecutor := defaultExecutor
+	getCommandFunc := func(_ string, _ ...string) mockCommand {
+		return mockCommand{
 			out:    []byte(mockOutput),
 			outErr: nil,
 		}
 	}
-	getCommand = getCommandFunc
-	defer func() { getCommand = originalGetCommand }()
+	defaultExecutor = mockExecutor(getCommandFunc)
+	defer func() { defaultExecutor = originalExecutor }()
 
 	originalFCHostPattern := fcHostPath
 	fcHostPath = "testdata/fc_host/host*"
@@ -859,15 +1044,15 @@ nguid   : 111111aaaa11a1111aaa1111111a1a11
 eui64   : 0000000000000000
 lbaf  0 : ms:0   lbads:9  rp:0 (in use)
 	`
-	originalGetCommand := getCommand
-	getCommandFunc := func(_ string, _ ...string) command {
-		return &mockCommand{
+	originalExecutor := defaultExecutor
+	getCommandFunc := func(_ string, _ ...string) mockCommand {
+		return mockCommand{
 			out:    []byte(mockOutput),
 			outErr: nil,
 		}
 	}
-	getCommand = getCommandFunc
-	defer func() { getCommand = originalGetCommand }()
+	defaultExecutor = mockExecutor(getCommandFunc)
+	defer func() { defaultExecutor = originalExecutor }()
 
 	guid, namespace, err := c.GetNVMeDeviceData("testdata/device_data")
 	if err != nil {
@@ -888,14 +1073,14 @@ func TestGetNVMeDeviceDataError(t *testing.T) {
 	opts := map[string]string{}
 	c = NewNVMe(opts)
 
-	originalGetCommand := getCommand
-	getCommandFunc := func(_ string, _ ...string) command {
-		return &mockCommand{
+	originalExecutor := defaultExecutor
+	getCommandFunc := func(_ string, _ ...string) mockCommand {
+		return mockCommand{
 			outErr: errors.New("error"),
 		}
 	}
-	getCommand = getCommandFunc
-	defer func() { getCommand = originalGetCommand }()
+	defaultExecutor = mockExecutor(getCommandFunc)
+	defer func() { defaultExecutor = originalExecutor }()
 
 	_, _, err := c.GetNVMeDeviceData("/nvmeMock/0n1")
 	if err == nil {
@@ -909,7 +1094,7 @@ func TestNVMeTCPConnect(t *testing.T) {
 		name             string
 		nvmeTarget       NVMeTarget
 		duplicateConnect bool
-		getCommandFn     func(_ string, _ ...string) command
+		getCommandFn     func(_ string, _ ...string) mockCommand
 		wantErr          bool
 		errContains      string
 	}{
@@ -920,8 +1105,8 @@ func TestNVMeTCPConnect(t *testing.T) {
 				TargetNqn: "nqn.1988-11.com.mock:00:a1a1a1a111a1111A111A",
 			},
 			false,
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					startErr: nil,
 					waitErr:  nil,
 				}
@@ -936,8 +1121,8 @@ func TestNVMeTCPConnect(t *testing.T) {
 				TargetNqn: "nqn.1988-11.com.mock:00:a1a1a1a111a1111A111A",
 			},
 			true,
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					startErr: nil,
 					waitErr:  nil,
 				}
@@ -952,8 +1137,8 @@ func TestNVMeTCPConnect(t *testing.T) {
 				TargetNqn: "nqn.1988-11.com.mock:00:a1a1a1a111a1111A111A",
 			},
 			false,
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					startErr: nil,
 					waitErr:  errors.New("error should be in output"),
 				}
@@ -968,8 +1153,8 @@ func TestNVMeTCPConnect(t *testing.T) {
 				TargetNqn: "nqn.1988-11.com.mock:00:a1a1a1a111a1111A111A",
 			},
 			false,
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					startErr: nil,
 					waitErr:  &exec.ExitError{ProcessState: &os.ProcessState{}},
 				}
@@ -981,9 +1166,9 @@ func TestNVMeTCPConnect(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			originalGetCommand := getCommand
-			getCommand = tc.getCommandFn
-			defer func() { getCommand = originalGetCommand }()
+			originalExecutor := defaultExecutor
+			defaultExecutor = mockExecutor(tc.getCommandFn)
+			defer func() { defaultExecutor = originalExecutor }()
 
 			c := NewNVMe(map[string]string{})
 			err := c.NVMeTCPConnect(tc.nvmeTarget, tc.duplicateConnect)
@@ -1002,7 +1187,7 @@ func TestNVMeFCConnect(t *testing.T) {
 		name             string
 		nvmeTarget       NVMeTarget
 		duplicateConnect bool
-		getCommandFn     func(_ string, _ ...string) command
+		getCommandFn     func(_ string, _ ...string) mockCommand
 		wantErr          bool
 	}{
 		{
@@ -1012,8 +1197,8 @@ func TestNVMeFCConnect(t *testing.T) {
 				TargetNqn: "nqn.1988-11.com.mock:00:a1a1a1a111a1111A111A",
 			},
 			false,
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					startErr: nil,
 					waitErr:  nil,
 				}
@@ -1027,8 +1212,8 @@ func TestNVMeFCConnect(t *testing.T) {
 				TargetNqn: "nqn.1988-11.com.mock:00:a1a1a1a111a1111A111A",
 			},
 			true,
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					startErr: nil,
 					waitErr:  nil,
 				}
@@ -1042,8 +1227,8 @@ func TestNVMeFCConnect(t *testing.T) {
 				TargetNqn: "nqn.1988-11.com.mock:00:a1a1a1a111a1111A111A",
 			},
 			false,
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					startErr: nil,
 					waitErr:  errors.New("error"),
 				}
@@ -1057,8 +1242,8 @@ func TestNVMeFCConnect(t *testing.T) {
 				TargetNqn: "nqn.1988-11.com.mock:00:a1a1a1a111a1111A111A",
 			},
 			false,
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					startErr: nil,
 					waitErr:  &exec.ExitError{ProcessState: &os.ProcessState{}},
 				}
@@ -1068,9 +1253,9 @@ func TestNVMeFCConnect(t *testing.T) {
 	}
 
 	for _, tc := range tests {
-		originalGetCommand := getCommand
-		getCommand = tc.getCommandFn
-		defer func() { getCommand = originalGetCommand }()
+		originalExecutor := defaultExecutor
+		defaultExecutor = mockExecutor(tc.getCommandFn)
+		defer func() { defaultExecutor = originalExecutor }()
 
 		c := NewNVMe(map[string]string{})
 		err := c.NVMeFCConnect(tc.nvmeTarget, tc.duplicateConnect)
@@ -1086,7 +1271,7 @@ func TestNVMeDisconnect(t *testing.T) {
 	tests := []struct {
 		name         string
 		nvmeTarget   NVMeTarget
-		getCommandFn func(_ string, _ ...string) command
+		getCommandFn func(_ string, _ ...string) mockCommand
 		wantErr      bool
 	}{
 		{
@@ -1095,8 +1280,8 @@ func TestNVMeDisconnect(t *testing.T) {
 				Portal:    "1.1.1.1",
 				TargetNqn: "nqn.1988-11.com.mock:00:a1a1a1a111a1111A111A",
 			},
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					outErr: nil,
 				}
 			},
@@ -1108,8 +1293,8 @@ func TestNVMeDisconnect(t *testing.T) {
 				Portal:    "1.1.1.1",
 				TargetNqn: "nqn.1988-11.com.mock:00:a1a1a1a111a1111A111A",
 			},
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					outErr: errors.New("error"),
 				}
 			},
@@ -1118,9 +1303,9 @@ func TestNVMeDisconnect(t *testing.T) {
 	}
 
 	for _, tc := range tests {
-		originalGetCommand := getCommand
-		getCommand = tc.getCommandFn
-		defer func() { getCommand = originalGetCommand }()
+		originalExecutor := defaultExecutor
+		defaultExecutor = mockExecutor(tc.getCommandFn)
+		defer func() { defaultExecutor = originalExecutor }()
 
 		c := NewNVMe(map[string]string{})
 		err := c.NVMeDisconnect(tc.nvmeTarget)
@@ -1135,13 +1320,13 @@ func TestNVMeDisconnect(t *testing.T) {
 func TestDeviceRescan(t *testing.T) {
 	tests := []struct {
 		name         string
-		getCommandFn func(_ string, _ ...string) command
+		getCommandFn func(_ string, _ ...string) mockCommand
 		wantErr      bool
 	}{
 		{
 			"successfully rescans",
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					outErr: nil,
 				}
 			},
@@ -1149,8 +1334,8 @@ func TestDeviceRescan(t *testing.T) {
 		},
 		{
 			"error rescanning",
-			func(_ string, _ ...string) command {
-				return &mockCommand{
+			func(_ string, _ ...string) mockCommand {
+				return mockCommand{
 					outErr: errors.New("error"),
 				}
 			},
@@ -1159,9 +1344,9 @@ func TestDeviceRescan(t *testing.T) {
 	}
 
 	for _, tc := range tests {
-		originalGetCommand := getCommand
-		getCommand = tc.getCommandFn
-		defer func() { getCommand = originalGetCommand }()
+		originalExecutor := defaultExecutor
+		defaultExecutor = mockExecutor(tc.getCommandFn)
+		defer func() { defaultExecutor = originalExecutor }()
 
 		c := NewNVMe(map[string]string{})
 		err := c.DeviceRescan("device")
@@ -35,6 +35,10 @@ func TestSetTimeouts(t *testing.T) {
 	// Test with non-zero value, should set to value
 	setTimeouts(&prop, 5*time.Second, 10*time.Second)
 	assert.Equal(t, 5*time.Second, prop)
+
+	// Test with negative value, should set to defaultVal
+	setTimeouts(&prop, -time.Second, 10*time.Second)
+	assert.Equal(t, 10*time.Second, prop)
 }
 
 func TestNVMeType_isMock(t *testing.T) {
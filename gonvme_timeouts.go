@@ -0,0 +1,181 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+// ErrInvalidTimeouts is returned when controller timeouts are rejected before being written
+var ErrInvalidTimeouts = errors.New("invalid controller timeouts")
+
+// ControllerTimeouts are the fabrics timeouts, in seconds, updated on a live controller.
+// Nil fields are left unchanged; CtrlLossTmo and FastIOFailTmo accept ControllerTimeoutOff.
+type ControllerTimeouts struct {
+	ReconnectDelay *int
+	CtrlLossTmo    *int
+	FastIOFailTmo  *int
+}
+
+// UpdateControllerTimeouts writes the timeouts of a fabrics controller, given as nvme0 or /dev/nvme0,
+// without reconnecting it. The values are read back and the controller returned as updated.
+// The kernel keeps ctrl_loss_tmo as a number of reconnects: it reads back rounded up to a multiple
+// of reconnect_delay.
+func (nvme *NVMe) UpdateControllerTimeouts(name string, timeouts ControllerTimeouts) (Controller, error) {
+	current, err := nvme.GetController(name)
+	if err != nil {
+		return Controller{}, err
+	}
+	if err := validateTimeouts(current, timeouts); err != nil {
+		return current, err
+	}
+
+	// the kernel derives the number of reconnects from ctrl_loss_tmo and reconnect_delay when
+	// ctrl_loss_tmo is written, so it is written after reconnect_delay, again if unchanged
+	ctrlLossTmo := timeouts.CtrlLossTmo
+	if timeouts.ReconnectDelay != nil && ctrlLossTmo == nil {
+		ctrlLossTmo = &current.CtrlLossTmo
+	}
+	writes := []struct {
+		attr  string
+		value *int
+	}{
+		{"reconnect_delay", timeouts.ReconnectDelay},
+		{"ctrl_loss_tmo", ctrlLossTmo},
+		{"fast_io_fail_tmo", timeouts.FastIOFailTmo},
+	}
+
+	dir := filepath.Join(nvme.hostPath(nvmeClassPath), current.Name)
+	for _, w := range writes {
+		if w.value == nil {
+			continue
+		}
+		if err := writeSysfsAttribute(filepath.Join(dir, w.attr), strconv.Itoa(*w.value)); err != nil {
+			return current, fmt.Errorf("failed to update %s of controller %s: %w", w.attr, current.Name, err)
+		}
+	}
+
+	updated, err := readController(dir, current.Name)
+	if err != nil {
+		return current, err
+	}
+	if err := verifyTimeouts(updated, timeouts); err != nil {
+		return updated, err
+	}
+	nvme.logOperation("updateTimeouts", controllerField(updated.Name)).Infof("Controller timeouts updated: reconnect_delay %d, ctrl_loss_tmo %d, fast_io_fail_tmo %d",
+		updated.ReconnectDelay, updated.CtrlLossTmo, updated.FastIOFailTmo)
+	return updated, nil
+}
+
+// UpdateSubsystemTimeouts updates the timeouts of every controller, that is every path, of the subsystem NQN,
+// failing with a ControllerErrors
+func (nvme *NVMe) UpdateSubsystemTimeouts(nqn string, timeouts ControllerTimeouts) error {
+	controllers, err := nvme.subsystemControllers(nqn)
+	if err != nil {
+		return err
+	}
+	if len(controllers) == 0 {
+		return fmt.Errorf("no controllers found for subsystem %s", nqn)
+	}
+
+	failed := ControllerErrors{}
+	for _, ctrl := range controllers {
+		if _, err := nvme.UpdateControllerTimeouts(ctrl, timeouts); err != nil {
+			nvme.logOperation("updateTimeouts", nqnField(nqn), controllerField(ctrl)).Errorf("Error updating controller timeouts: %v", err)
+			failed[ctrl] = err
+		}
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+	return nil
+}
+
+// validateTimeouts checks the timeouts, merged with the current ones, the way the kernel would use them
+func validateTimeouts(current Controller, timeouts ControllerTimeouts) error {
+	if current.Transport == "pcie" || current.Transport == "" {
+		return fmt.Errorf("%w: controller %s is not a fabrics controller", ErrInvalidTimeouts, current.Name)
+	}
+
+	merged := current
+	if timeouts.ReconnectDelay != nil {
+		merged.ReconnectDelay = *timeouts.ReconnectDelay
+		if merged.ReconnectDelay <= 0 {
+			return fmt.Errorf("%w: reconnect_delay must be positive, got %d", ErrInvalidTimeouts, merged.ReconnectDelay)
+		}
+	}
+	if timeouts.CtrlLossTmo != nil {
+		merged.CtrlLossTmo = *timeouts.CtrlLossTmo
+		if merged.CtrlLossTmo < ControllerTimeoutOff {
+			return fmt.Errorf("%w: ctrl_loss_tmo must be positive or off, got %d", ErrInvalidTimeouts, merged.CtrlLossTmo)
+		}
+	}
+	if timeouts.FastIOFailTmo != nil {
+		merged.FastIOFailTmo = *timeouts.FastIOFailTmo
+		if merged.FastIOFailTmo < ControllerTimeoutOff {
+			return fmt.Errorf("%w: fast_io_fail_tmo must be positive or off, got %d", ErrInvalidTimeouts, merged.FastIOFailTmo)
+		}
+	}
+
+	if merged.CtrlLossTmo == ControllerTimeoutOff {
+		return nil
+	}
+	if merged.ReconnectDelay > merged.CtrlLossTmo {
+		return fmt.Errorf("%w: reconnect_delay %d exceeds ctrl_loss_tmo %d, the controller would not reconnect",
+			ErrInvalidTimeouts, merged.ReconnectDelay, merged.CtrlLossTmo)
+	}
+	if merged.FastIOFailTmo != ControllerTimeoutOff && merged.FastIOFailTmo >= merged.CtrlLossTmo {
+		return fmt.Errorf("%w: fast_io_fail_tmo %d must be lower than ctrl_loss_tmo %d",
+			ErrInvalidTimeouts, merged.FastIOFailTmo, merged.CtrlLossTmo)
+	}
+	return nil
+}
+
+// verifyTimeouts checks that the controller reads back the timeouts which were written
+func verifyTimeouts(controller Controller, timeouts ControllerTimeouts) error {
+	checks := []struct {
+		attr string
+		want *int
+		got  int
+	}{
+		{"reconnect_delay", timeouts.ReconnectDelay, controller.ReconnectDelay},
+		{"ctrl_loss_tmo", timeouts.CtrlLossTmo, controller.CtrlLossTmo},
+		{"fast_io_fail_tmo", timeouts.FastIOFailTmo, controller.FastIOFailTmo},
+	}
+	if timeouts.CtrlLossTmo != nil {
+		rounded := roundCtrlLossTmo(*timeouts.CtrlLossTmo, controller.ReconnectDelay)
+		checks[1].want = &rounded
+	}
+	for _, check := range checks {
+		if check.want != nil && *check.want != check.got {
+			return fmt.Errorf("controller %s %s reads back %d after writing %d", controller.Name, check.attr, check.got, *check.want)
+		}
+	}
+	return nil
+}
+
+// roundCtrlLossTmo returns ctrl_loss_tmo as the kernel reads it back, the number of reconnects,
+// DIV_ROUND_UP(ctrl_loss_tmo, reconnect_delay), times reconnect_delay
+func roundCtrlLossTmo(ctrlLossTmo int, reconnectDelay int) int {
+	if ctrlLossTmo <= 0 || reconnectDelay <= 0 {
+		return ctrlLossTmo
+	}
+	return (ctrlLossTmo + reconnectDelay - 1) / reconnectDelay * reconnectDelay
+}
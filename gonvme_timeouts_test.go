@@ -0,0 +1,177 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func readTestAttribute(t *testing.T, root string, controller string, attr string) string {
+	data, err := os.ReadFile(filepath.Join(root, "sys/class/nvme", controller, attr))
+	assert.NoError(t, err)
+	return strings.TrimSpace(string(data))
+}
+
+func TestUpdateControllerTimeouts(t *testing.T) {
+	tests := []struct {
+		name       string
+		controller string
+		timeouts   ControllerTimeouts
+		wantAttrs  map[string]string
+		wantErr    error
+	}{
+		{
+			name:       "all timeouts",
+			controller: "nvme0",
+			timeouts:   ControllerTimeouts{ReconnectDelay: intPtr(5), CtrlLossTmo: intPtr(30), FastIOFailTmo: intPtr(10)},
+			wantAttrs:  map[string]string{"reconnect_delay": "5", "ctrl_loss_tmo": "30", "fast_io_fail_tmo": "10"},
+		},
+		{
+			name:       "reconnect delay rewrites ctrl_loss_tmo",
+			controller: "/dev/nvme0",
+			timeouts:   ControllerTimeouts{ReconnectDelay: intPtr(20)},
+			wantAttrs:  map[string]string{"reconnect_delay": "20", "ctrl_loss_tmo": "600", "fast_io_fail_tmo": "off"},
+		},
+		{
+			name:       "ctrl_loss_tmo off",
+			controller: "nvme0",
+			timeouts:   ControllerTimeouts{CtrlLossTmo: intPtr(ControllerTimeoutOff)},
+			wantAttrs:  map[string]string{"ctrl_loss_tmo": "-1"},
+		},
+		{
+			name:       "non positive reconnect delay",
+			controller: "nvme0",
+			timeouts:   ControllerTimeouts{ReconnectDelay: intPtr(0)},
+			wantErr:    ErrInvalidTimeouts,
+		},
+		{
+			name:       "negative ctrl_loss_tmo",
+			controller: "nvme0",
+			timeouts:   ControllerTimeouts{CtrlLossTmo: intPtr(-2)},
+			wantErr:    ErrInvalidTimeouts,
+		},
+		{
+			name:       "reconnect delay above ctrl_loss_tmo",
+			controller: "nvme0",
+			timeouts:   ControllerTimeouts{CtrlLossTmo: intPtr(5)},
+			wantErr:    ErrInvalidTimeouts,
+		},
+		{
+			name:       "fast_io_fail_tmo not below ctrl_loss_tmo",
+			controller: "nvme1",
+			timeouts:   ControllerTimeouts{CtrlLossTmo: intPtr(5)},
+			wantErr:    ErrInvalidTimeouts,
+		},
+		{
+			name:       "PCIe controller",
+			controller: "nvme2",
+			timeouts:   ControllerTimeouts{FastIOFailTmo: intPtr(5)},
+			wantErr:    ErrInvalidTimeouts,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nvme, root := newWritableSysfsTestNVMe(t)
+			before := readTestAttribute(t, root, filepath.Base(tt.controller), "state")
+
+			controller, err := nvme.UpdateControllerTimeouts(tt.controller, tt.timeouts)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			for attr, want := range tt.wantAttrs {
+				assert.Equal(t, want, readTestAttribute(t, root, controller.Name, attr), attr)
+			}
+			assert.Equal(t, before, string(controller.State))
+			if tt.timeouts.ReconnectDelay != nil {
+				assert.Equal(t, *tt.timeouts.ReconnectDelay, controller.ReconnectDelay)
+			}
+			if tt.timeouts.CtrlLossTmo != nil {
+				assert.Equal(t, *tt.timeouts.CtrlLossTmo, controller.CtrlLossTmo)
+			}
+		})
+	}
+}
+
+func TestVerifyTimeouts(t *testing.T) {
+	tests := []struct {
+		name       string
+		controller Controller
+		timeouts   ControllerTimeouts
+		wantErr    bool
+	}{
+		{"multiple of reconnect_delay", Controller{ReconnectDelay: 10, CtrlLossTmo: 60}, ControllerTimeouts{CtrlLossTmo: intPtr(60)}, false},
+		{"rounded up to reconnect_delay", Controller{ReconnectDelay: 10, CtrlLossTmo: 70}, ControllerTimeouts{CtrlLossTmo: intPtr(65)}, false},
+		{"rounded with the new reconnect_delay", Controller{ReconnectDelay: 7, CtrlLossTmo: 602}, ControllerTimeouts{ReconnectDelay: intPtr(7), CtrlLossTmo: intPtr(600)}, false},
+		{"off", Controller{ReconnectDelay: 10, CtrlLossTmo: ControllerTimeoutOff}, ControllerTimeouts{CtrlLossTmo: intPtr(ControllerTimeoutOff)}, false},
+		{"not rounded", Controller{ReconnectDelay: 10, CtrlLossTmo: 65}, ControllerTimeouts{CtrlLossTmo: intPtr(65)}, true},
+		{"not updated", Controller{ReconnectDelay: 10, CtrlLossTmo: 600}, ControllerTimeouts{CtrlLossTmo: intPtr(65)}, true},
+		{"reconnect_delay not updated", Controller{ReconnectDelay: 10, CtrlLossTmo: 600}, ControllerTimeouts{ReconnectDelay: intPtr(5)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyTimeouts(tt.controller, tt.timeouts)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUpdateControllerTimeoutsMissingAttribute(t *testing.T) {
+	nvme, root := newWritableSysfsTestNVMe(t)
+	assert.NoError(t, os.Remove(filepath.Join(root, "sys/class/nvme/nvme10/fast_io_fail_tmo")))
+
+	_, err := nvme.UpdateControllerTimeouts("nvme10", ControllerTimeouts{FastIOFailTmo: intPtr(5)})
+	assert.ErrorContains(t, err, "fast_io_fail_tmo")
+
+	_, err = nvme.UpdateControllerTimeouts("nvme3", ControllerTimeouts{FastIOFailTmo: intPtr(5)})
+	assert.Error(t, err)
+}
+
+func TestUpdateSubsystemTimeouts(t *testing.T) {
+	nvme, root := newWritableSysfsTestNVMe(t)
+
+	err := nvme.UpdateSubsystemTimeouts(rescanTestNQN0, ControllerTimeouts{CtrlLossTmo: intPtr(120), FastIOFailTmo: intPtr(15)})
+	assert.NoError(t, err)
+	for _, ctrl := range []string{"nvme0", "nvme10"} {
+		assert.Equal(t, "120", readTestAttribute(t, root, ctrl, "ctrl_loss_tmo"), ctrl)
+		assert.Equal(t, "15", readTestAttribute(t, root, ctrl, "fast_io_fail_tmo"), ctrl)
+	}
+	assert.Equal(t, "off", readTestAttribute(t, root, "nvme1", "ctrl_loss_tmo"))
+
+	// fast_io_fail_tmo 5 of nvme1 is not lower than ctrl_loss_tmo
+	err = nvme.UpdateSubsystemTimeouts(rescanTestNQN1, ControllerTimeouts{CtrlLossTmo: intPtr(3)})
+	failed, ok := err.(ControllerErrors)
+	assert.True(t, ok)
+	assert.Contains(t, failed, "nvme1")
+
+	err = nvme.UpdateSubsystemTimeouts("nqn.unknown", ControllerTimeouts{CtrlLossTmo: intPtr(3)})
+	assert.Error(t, err)
+}
@@ -0,0 +1,181 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// nvmeCoreParametersPath holds the parameters of the nvme_core module
+var nvmeCoreParametersPath = "/sys/module/nvme_core/parameters"
+
+// ErrInvalidQueueSettings is returned when namespace queue settings are rejected before being written
+var ErrInvalidQueueSettings = errors.New("invalid namespace queue settings")
+
+// NamespaceQueueSettings are the block layer settings of a namespace; nil or empty fields are left unchanged
+type NamespaceQueueSettings struct {
+	// IOTimeout is applied to each multipath path of the namespace, where the I/Os time out, and to the
+	// namespace itself unless it is a native multipath head, which has no io_timeout
+	IOTimeout   *time.Duration
+	NrRequests  *int
+	Scheduler   string
+	ReadAheadKB *int
+	Rotational  *bool
+}
+
+// ResetController resets the controller, given as nvme0 or /dev/nvme0, through its sysfs reset_controller
+// attribute, or nvme reset on kernels which do not expose it
+func (nvme *NVMe) ResetController(name string) error {
+	name = path.Base(name)
+	if !controllerNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid controller name %q", name)
+	}
+
+	err := writeSysfsAttribute(filepath.Join(nvme.hostPath(nvmeClassPath), name, "reset_controller"), "1")
+	if err == nil {
+		nvme.logOperation("reset", controllerField(name)).Infof("Controller reset")
+		return nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to reset controller %s: %w", name, err)
+	}
+
+	exe := []string{nvme.NVMeCommand, "reset", devPath(name)}
+	if _, err := nvme.output(exe); err != nil {
+		return fmt.Errorf("failed to reset controller %s: %w", name, err)
+	}
+	nvme.logOperation("reset", controllerField(name)).Infof("Controller reset")
+	return nil
+}
+
+// TuneNamespaceQueue applies the block layer settings to the namespace device, /dev/nvme0n1 or nvme0n1.
+// The settings are validated before anything is written and applied in order, stopping at the first failure.
+func (nvme *NVMe) TuneNamespaceQueue(device string, settings NamespaceQueueSettings) error {
+	name := path.Base(device)
+	if !blockDeviceNameRegexp.MatchString(name) {
+		return fmt.Errorf("invalid namespace device %q", device)
+	}
+	if err := settings.validate(); err != nil {
+		return err
+	}
+
+	queue := filepath.Join(nvme.hostPath(sysBlockPath), name, "queue")
+	if _, err := os.Stat(queue); err != nil {
+		return fmt.Errorf("namespace %s not found: %w", name, err)
+	}
+	if settings.Scheduler != "" {
+		if err := checkScheduler(queue, settings.Scheduler); err != nil {
+			return err
+		}
+	}
+
+	var writes [][2]string
+	if settings.IOTimeout != nil {
+		timeout := strconv.FormatInt(settings.IOTimeout.Milliseconds(), 10)
+		paths, err := filepath.Glob(filepath.Join(nvme.hostPath(sysBlockPath), name, "multipath", "*"))
+		if err != nil {
+			return fmt.Errorf("failed to list the paths of namespace %s: %w", name, err)
+		}
+		// the multipath head is bio-based, without io_timeout; the I/Os time out on its paths
+		_, err = os.Stat(filepath.Join(queue, "io_timeout"))
+		if err == nil || len(paths) == 0 {
+			writes = append(writes, [2]string{filepath.Join(queue, "io_timeout"), timeout})
+		}
+		for _, p := range paths {
+			writes = append(writes, [2]string{filepath.Join(p, "queue", "io_timeout"), timeout})
+		}
+	}
+	if settings.NrRequests != nil {
+		writes = append(writes, [2]string{filepath.Join(queue, "nr_requests"), strconv.Itoa(*settings.NrRequests)})
+	}
+	if settings.Scheduler != "" {
+		writes = append(writes, [2]string{filepath.Join(queue, "scheduler"), settings.Scheduler})
+	}
+	if settings.ReadAheadKB != nil {
+		writes = append(writes, [2]string{filepath.Join(queue, "read_ahead_kb"), strconv.Itoa(*settings.ReadAheadKB)})
+	}
+	if settings.Rotational != nil {
+		rotational := "0"
+		if *settings.Rotational {
+			rotational = "1"
+		}
+		writes = append(writes, [2]string{filepath.Join(queue, "rotational"), rotational})
+	}
+
+	for _, w := range writes {
+		if err := writeSysfsAttribute(w[0], w[1]); err != nil {
+			return fmt.Errorf("failed to tune namespace %s: %w", name, err)
+		}
+	}
+	nvme.logOperation("tuneQueue", deviceField(devPath(name))).Infof("Namespace queue tuned with %d settings", len(writes))
+	return nil
+}
+
+func (s NamespaceQueueSettings) validate() error {
+	if s.IOTimeout != nil && *s.IOTimeout < time.Millisecond {
+		return fmt.Errorf("%w: io_timeout must be at least 1ms, got %s", ErrInvalidQueueSettings, *s.IOTimeout)
+	}
+	if s.NrRequests != nil && *s.NrRequests <= 0 {
+		return fmt.Errorf("%w: nr_requests must be positive, got %d", ErrInvalidQueueSettings, *s.NrRequests)
+	}
+	if s.ReadAheadKB != nil && *s.ReadAheadKB < 0 {
+		return fmt.Errorf("%w: read_ahead_kb must not be negative, got %d", ErrInvalidQueueSettings, *s.ReadAheadKB)
+	}
+	return nil
+}
+
+// SetCoreMaxRetries sets the max_retries parameter of the nvme_core module, the number of times a failed
+// I/O is retried. It is a host setting, applying to every NVMe namespace, including those of other volumes.
+func (nvme *NVMe) SetCoreMaxRetries(retries int) error {
+	if err := validateMaxRetries(retries); err != nil {
+		return err
+	}
+	if err := writeSysfsAttribute(filepath.Join(nvme.hostPath(nvmeCoreParametersPath), "max_retries"), strconv.Itoa(retries)); err != nil {
+		return fmt.Errorf("failed to set nvme_core max_retries: %w", err)
+	}
+	nvme.logOperation("setCoreMaxRetries").Infof("nvme_core max_retries set to %d", retries)
+	return nil
+}
+
+func validateMaxRetries(retries int) error {
+	if retries < 0 || retries > 255 {
+		return fmt.Errorf("%w: max_retries must be between 0 and 255, got %d", ErrInvalidQueueSettings, retries)
+	}
+	return nil
+}
+
+// checkScheduler checks that the scheduler is one of those listed by the queue, "[none] mq-deadline kyber"
+func checkScheduler(queue string, scheduler string) error {
+	data, err := os.ReadFile(filepath.Clean(filepath.Join(queue, "scheduler")))
+	if err != nil {
+		return err
+	}
+	for _, available := range strings.Fields(string(data)) {
+		if strings.Trim(available, "[]") == scheduler {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: scheduler %q is not available, the queue supports %s",
+		ErrInvalidQueueSettings, scheduler, strings.TrimSpace(string(data)))
+}
@@ -0,0 +1,140 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readSysfsTestFile(t *testing.T, root string, file string) string {
+	data, err := os.ReadFile(filepath.Join(root, file))
+	assert.NoError(t, err)
+	return strings.TrimSpace(string(data))
+}
+
+func TestResetController(t *testing.T) {
+	var commands []string
+	originalExecutor := defaultExecutor
+	defer func() { defaultExecutor = originalExecutor }()
+	failReset := false
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		commands = append(commands, strings.Join(args, " "))
+		if failReset {
+			return mockCommand{outErr: errors.New("reset failed")}
+		}
+		return mockCommand{}
+	})
+
+	nvme, root := newWritableSysfsTestNVMe(t)
+
+	// through sysfs
+	assert.NoError(t, nvme.ResetController("/dev/nvme0"))
+	assert.Equal(t, "1", readSysfsTestFile(t, root, "sys/class/nvme/nvme0/reset_controller"))
+	assert.Empty(t, commands)
+
+	// no reset_controller attribute, falling back to nvme reset
+	assert.NoError(t, nvme.ResetController("nvme1"))
+	assert.Len(t, commands, 1)
+	assert.True(t, strings.HasSuffix(commands[0], "nvme reset /dev/nvme1"), commands[0])
+
+	failReset = true
+	assert.ErrorContains(t, nvme.ResetController("nvme1"), "reset failed")
+	assert.Error(t, nvme.ResetController("nvme-fabrics"))
+}
+
+func TestTuneNamespaceQueue(t *testing.T) {
+	timeout := 45 * time.Second
+	nrRequests := 256
+	readAhead := 0
+	rotational := false
+
+	nvme, root := newWritableSysfsTestNVMe(t)
+	err := nvme.TuneNamespaceQueue("/dev/nvme0n1", NamespaceQueueSettings{
+		IOTimeout:   &timeout,
+		NrRequests:  &nrRequests,
+		Scheduler:   "mq-deadline",
+		ReadAheadKB: &readAhead,
+		Rotational:  &rotational,
+	})
+	assert.NoError(t, err)
+
+	for file, want := range map[string]string{
+		"sys/block/nvme0c0n1/queue/io_timeout":  "45000",
+		"sys/block/nvme0c10n1/queue/io_timeout": "45000",
+		"sys/block/nvme0n1/queue/nr_requests":   "256",
+		"sys/block/nvme0n1/queue/scheduler":     "mq-deadline",
+		"sys/block/nvme0n1/queue/read_ahead_kb": "0",
+		"sys/block/nvme0n1/queue/rotational":    "0",
+	} {
+		assert.Equal(t, want, readSysfsTestFile(t, root, file), file)
+	}
+	// the multipath head has no io_timeout
+	assert.NoFileExists(t, filepath.Join(root, "sys/block/nvme0n1/queue/io_timeout"))
+
+	// a namespace without multipath, its io_timeout being its own
+	assert.NoError(t, nvme.TuneNamespaceQueue("nvme0c0n1", NamespaceQueueSettings{IOTimeout: &timeout}))
+	assert.NoError(t, os.Remove(filepath.Join(root, "sys/block/nvme0c0n1/queue/io_timeout")))
+	err = nvme.TuneNamespaceQueue("nvme0c0n1", NamespaceQueueSettings{IOTimeout: &timeout})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestSetCoreMaxRetries(t *testing.T) {
+	nvme, root := newWritableSysfsTestNVMe(t)
+	assert.NoError(t, nvme.SetCoreMaxRetries(2))
+	assert.Equal(t, "2", readSysfsTestFile(t, root, "sys/module/nvme_core/parameters/max_retries"))
+
+	assert.ErrorIs(t, nvme.SetCoreMaxRetries(256), ErrInvalidQueueSettings)
+	assert.ErrorIs(t, nvme.SetCoreMaxRetries(-1), ErrInvalidQueueSettings)
+}
+
+func TestTuneNamespaceQueueErrors(t *testing.T) {
+	negative := -1
+	zeroTimeout := time.Duration(0)
+	nrRequests := 256
+
+	tests := []struct {
+		name     string
+		device   string
+		settings NamespaceQueueSettings
+		wantErr  error
+	}{
+		{"io timeout", "nvme0n1", NamespaceQueueSettings{IOTimeout: &zeroTimeout}, ErrInvalidQueueSettings},
+		{"nr requests", "nvme0n1", NamespaceQueueSettings{NrRequests: &negative}, ErrInvalidQueueSettings},
+		{"read ahead", "nvme0n1", NamespaceQueueSettings{ReadAheadKB: &negative}, ErrInvalidQueueSettings},
+		{"unknown scheduler", "nvme0n1", NamespaceQueueSettings{Scheduler: "cfq"}, ErrInvalidQueueSettings},
+		{"partition", "/dev/nvme0n1p1", NamespaceQueueSettings{}, nil},
+		{"unknown namespace", "/dev/nvme5n1", NamespaceQueueSettings{}, os.ErrNotExist},
+		{"missing attribute", "nvme0c0n1", NamespaceQueueSettings{NrRequests: &nrRequests}, os.ErrNotExist},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nvme, _ := newWritableSysfsTestNVMe(t)
+			err := nvme.TuneNamespaceQueue(tt.device, tt.settings)
+			assert.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -29,6 +29,10 @@ type NVMeTarget struct {
 	SecType    string // sectype
 	TargetType string // trtype
 	HostAdr    string // host_traddr
+	// DHCHAPSecret is the DH-HMAC-CHAP secret of the host, connecting with --dhchap-secret when set
+	DHCHAPSecret string
+	// TLS connects with --tls, NVMe/TCP only
+	TLS bool
 }
 
 // NVMESessionState defines the NVMe connection state
@@ -44,12 +48,19 @@ const (
 	// NVMeTransportTypeFC - Placeholder for NVMe Transport type FC
 	NVMeTransportTypeFC = "fc"
 
+	// NVMeTransportTypeRDMA - Placeholder for NVMe Transport type RDMA
+	NVMeTransportTypeRDMA = "rdma"
+
 	// NVMESessionStateLive indicates the NVMe connection state as live
 	NVMESessionStateLive NVMESessionState = "live"
 	// NVMESessionStateDeleting indicates the NVMe connection state as deleting
 	NVMESessionStateDeleting NVMESessionState = "deleting"
 	// NVMESessionStateConnecting indicates the NVMe connection state as connecting
 	NVMESessionStateConnecting NVMESessionState = "connecting"
+	// NVMESessionStateResetting indicates the NVMe controller is being reset
+	NVMESessionStateResetting NVMESessionState = "resetting"
+	// NVMESessionStateDead indicates the NVMe controller failed and will not be used anymore
+	NVMESessionStateDead NVMESessionState = "dead"
 
 	// NVMETransportNameTCP indicates the NVMe protocol as tcp
 	NVMETransportNameTCP NVMETransportName = "tcp"
@@ -72,6 +83,7 @@ type NVMESession struct {
 	Name              string
 	NVMESessionState  NVMESessionState
 	NVMETransportName NVMETransportName
+	HostAddress       string // host_traddr or src_addr
 }
 
 // NVMeSessionParser defines an NVMe session parser
@@ -81,6 +93,13 @@ type NVMeSessionParser interface {
 
 // FCHBAInfo holds information about host NVMe/FC ports
 type FCHBAInfo struct {
-	PortName string
-	NodeName string
+	Host             string // fc_host name, host1
+	PortName         string
+	NodeName         string
+	PortState        string // Online, Linkdown, ...
+	PortType         string
+	Speed            string
+	FabricName       string
+	SupportedClasses string
+	SymbolicName     string
 }
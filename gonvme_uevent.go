@@ -0,0 +1,325 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NVMeEventType is the kind of an NVMe kernel event
+type NVMeEventType string
+
+const (
+	// NVMeEventControllerAdded is sent when a controller device is created
+	NVMeEventControllerAdded NVMeEventType = "controller-added"
+	// NVMeEventControllerRemoved is sent when a controller device is deleted
+	NVMeEventControllerRemoved NVMeEventType = "controller-removed"
+	// NVMeEventControllerChanged is sent for any other change of a controller
+	NVMeEventControllerChanged NVMeEventType = "controller-changed"
+	// NVMeEventControllerConnected is sent when a fabrics controller (re)connects, NVME_EVENT=connected
+	NVMeEventControllerConnected NVMeEventType = "controller-connected"
+	// NVMeEventRediscover is sent when a discovery controller asks for a new discovery, NVME_EVENT=rediscover
+	NVMeEventRediscover NVMeEventType = "rediscover"
+	// NVMeEventAEN is sent when a controller reports an Asynchronous Event Notification, NVME_AEN
+	NVMeEventAEN NVMeEventType = "aen"
+	// NVMeEventSubsystemAdded is sent when a subsystem is created
+	NVMeEventSubsystemAdded NVMeEventType = "subsystem-added"
+	// NVMeEventSubsystemRemoved is sent when a subsystem is deleted
+	NVMeEventSubsystemRemoved NVMeEventType = "subsystem-removed"
+	// NVMeEventSubsystemChanged is sent for any other change of a subsystem
+	NVMeEventSubsystemChanged NVMeEventType = "subsystem-changed"
+	// NVMeEventNamespaceAdded is sent when a namespace or path block device is created
+	NVMeEventNamespaceAdded NVMeEventType = "namespace-added"
+	// NVMeEventNamespaceRemoved is sent when a namespace or path block device is deleted
+	NVMeEventNamespaceRemoved NVMeEventType = "namespace-removed"
+	// NVMeEventNamespaceChanged is sent when a namespace block device changes, a resize for instance
+	NVMeEventNamespaceChanged NVMeEventType = "namespace-changed"
+)
+
+const (
+	ueventActionAdd    = "add"
+	ueventActionRemove = "remove"
+	ueventActionChange = "change"
+
+	ueventSubsystemNVMe      = "nvme"
+	ueventSubsystemSubsystem = "nvme-subsystem"
+	ueventSubsystemBlock     = "block"
+)
+
+var (
+	// blockDeviceNameRegexp matches namespace heads, nvme0n1, and per-controller paths, nvme0c1n1
+	blockDeviceNameRegexp = regexp.MustCompile(`^nvme[0-9]+(c[0-9]+)?n[0-9]+$`)
+	subsystemNameRegexp   = regexp.MustCompile(`^nvme-subsys[0-9]+$`)
+)
+
+// UEvent is a kernel uevent, as sent on the NETLINK_KOBJECT_UEVENT socket
+type UEvent struct {
+	Action    string
+	DevPath   string
+	Subsystem string
+	DevName   string
+	DevType   string
+	Seqnum    uint64
+	// Env holds every KEY=VALUE pair of the message
+	Env map[string]string
+}
+
+// NVMeEvent is an NVMe controller, subsystem or namespace event
+type NVMeEvent struct {
+	Type NVMeEventType
+	// Controller is the controller name, nvme0, if known
+	Controller string
+	// Subsystem is the subsystem name, nvme-subsys0, if known
+	Subsystem string
+	// Device is the block device, /dev/nvme0n1, of namespace events
+	Device string
+	// AEN is the completion dword 0 of NVMeEventAEN events
+	AEN uint32
+	// Transport, Address, ServiceID and HostAddress are sent by fabrics controllers
+	Transport   string
+	Address     string
+	ServiceID   string
+	HostAddress string
+	UEvent      UEvent
+}
+
+// UEventSource returns raw uevent messages, one per Read.
+// Read returns an empty message when no event is available yet and io.EOF when the source is exhausted.
+type UEventSource interface {
+	Read() ([]byte, error)
+	Close() error
+}
+
+// replayUEventSource returns recorded messages
+type replayUEventSource struct {
+	messages [][]byte
+}
+
+// NewReplayUEventSource returns a UEventSource replaying recorded uevent messages, then io.EOF
+func NewReplayUEventSource(messages [][]byte) UEventSource {
+	return &replayUEventSource{messages: messages}
+}
+
+func (s *replayUEventSource) Read() ([]byte, error) {
+	if len(s.messages) == 0 {
+		return nil, io.EOF
+	}
+	msg := s.messages[0]
+	s.messages = s.messages[1:]
+	return msg, nil
+}
+
+func (s *replayUEventSource) Close() error {
+	s.messages = nil
+	return nil
+}
+
+// UEventListener turns the uevents of a source into NVMe events
+type UEventListener struct {
+	source UEventSource
+	events chan NVMeEvent
+}
+
+// NewUEventListener returns a listener reading source, usually NewNetlinkUEventSource
+func NewUEventListener(source UEventSource) *UEventListener {
+	return &UEventListener{
+		source: source,
+		events: make(chan NVMeEvent, defaultMonitorEventBuffer),
+	}
+}
+
+// Events returns the channel the NVMe events are published on; it is closed when Run returns
+func (l *UEventListener) Events() <-chan NVMeEvent {
+	return l.events
+}
+
+// Run reads the source until the context is cancelled, the source is exhausted or fails.
+// The source is closed when Run returns.
+func (l *UEventListener) Run(ctx context.Context) error {
+	defer close(l.events)
+	defer l.source.Close()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msg, err := l.source.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if len(msg) == 0 {
+			continue
+		}
+
+		uevent, err := ParseUEvent(msg)
+		if err != nil {
+			packageLog().Debugf("Ignoring uevent: %v", err)
+			continue
+		}
+		event, ok := NVMeEventFromUEvent(uevent)
+		if !ok {
+			continue
+		}
+		select {
+		case l.events <- event:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ParseUEvent parses a kernel uevent message: an "action@devpath" header followed by
+// NUL separated KEY=VALUE pairs. Messages sent by udev are rejected.
+func ParseUEvent(msg []byte) (UEvent, error) {
+	fields := bytes.Split(bytes.TrimRight(msg, "\x00"), []byte{0})
+	if len(fields) == 0 || !bytes.Contains(fields[0], []byte("@")) {
+		return UEvent{}, fmt.Errorf("invalid uevent header %q", firstField(fields))
+	}
+
+	uevent := UEvent{Env: map[string]string{}}
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(string(field), "=")
+		if !ok {
+			continue
+		}
+		uevent.Env[key] = value
+	}
+	uevent.Action = uevent.Env["ACTION"]
+	uevent.DevPath = uevent.Env["DEVPATH"]
+	uevent.Subsystem = uevent.Env["SUBSYSTEM"]
+	uevent.DevName = uevent.Env["DEVNAME"]
+	uevent.DevType = uevent.Env["DEVTYPE"]
+	if seqnum, ok := uevent.Env["SEQNUM"]; ok {
+		value, err := strconv.ParseUint(seqnum, 10, 64)
+		if err != nil {
+			return UEvent{}, fmt.Errorf("invalid uevent SEQNUM %q: %w", seqnum, err)
+		}
+		uevent.Seqnum = value
+	}
+
+	if uevent.Action == "" || uevent.DevPath == "" {
+		return UEvent{}, fmt.Errorf("uevent %q has no ACTION or DEVPATH", fields[0])
+	}
+	return uevent, nil
+}
+
+func firstField(fields [][]byte) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	return string(fields[0])
+}
+
+// NVMeEventFromUEvent returns the NVMe event of a uevent, ok being false for uevents
+// which are not add, remove or change events of an NVMe controller, subsystem or namespace
+func NVMeEventFromUEvent(uevent UEvent) (NVMeEvent, bool) {
+	event := NVMeEvent{
+		Transport:   uevent.Env["NVME_TRTYPE"],
+		Address:     uevent.Env["NVME_TRADDR"],
+		ServiceID:   uevent.Env["NVME_TRSVCID"],
+		HostAddress: uevent.Env["NVME_HOST_TRADDR"],
+		UEvent:      uevent,
+	}
+	for _, component := range strings.Split(uevent.DevPath, "/") {
+		switch {
+		case controllerNameRegexp.MatchString(component):
+			event.Controller = component
+		case subsystemNameRegexp.MatchString(component):
+			event.Subsystem = component
+		}
+	}
+
+	switch uevent.Subsystem {
+	case ueventSubsystemNVMe:
+		if uevent.DevName != "" {
+			event.Controller = uevent.DevName
+		}
+		if !controllerNameRegexp.MatchString(event.Controller) {
+			return NVMeEvent{}, false
+		}
+		return event, setControllerEventType(&event, uevent)
+
+	case ueventSubsystemSubsystem:
+		if !subsystemNameRegexp.MatchString(event.Subsystem) {
+			return NVMeEvent{}, false
+		}
+		event.Type, _ = actionEventType(uevent.Action, NVMeEventSubsystemAdded, NVMeEventSubsystemRemoved, NVMeEventSubsystemChanged)
+		return event, event.Type != ""
+
+	case ueventSubsystemBlock:
+		name := uevent.DevName
+		if name == "" {
+			name = uevent.DevPath[strings.LastIndex(uevent.DevPath, "/")+1:]
+		}
+		if uevent.DevType != "disk" || !blockDeviceNameRegexp.MatchString(name) {
+			return NVMeEvent{}, false
+		}
+		event.Device = devPath(name)
+		event.Type, _ = actionEventType(uevent.Action, NVMeEventNamespaceAdded, NVMeEventNamespaceRemoved, NVMeEventNamespaceChanged)
+		return event, event.Type != ""
+	}
+	return NVMeEvent{}, false
+}
+
+// setControllerEventType sets the type of a controller event, change events being refined by NVME_AEN and NVME_EVENT
+func setControllerEventType(event *NVMeEvent, uevent UEvent) bool {
+	var ok bool
+	event.Type, ok = actionEventType(uevent.Action, NVMeEventControllerAdded, NVMeEventControllerRemoved, NVMeEventControllerChanged)
+	if !ok || uevent.Action != ueventActionChange {
+		return ok
+	}
+
+	if aen, found := uevent.Env["NVME_AEN"]; found {
+		value, err := strconv.ParseUint(aen, 0, 32)
+		if err != nil {
+			packageLog().With(controllerField(event.Controller)).Debugf("Invalid NVME_AEN %q: %v", aen, err)
+		}
+		event.Type = NVMeEventAEN
+		event.AEN = uint32(value)
+		return true
+	}
+	switch uevent.Env["NVME_EVENT"] {
+	case "connected":
+		event.Type = NVMeEventControllerConnected
+	case "rediscover":
+		event.Type = NVMeEventRediscover
+	}
+	return true
+}
+
+func actionEventType(action string, added, removed, changed NVMeEventType) (NVMeEventType, bool) {
+	switch action {
+	case ueventActionAdd:
+		return added, true
+	case ueventActionRemove:
+		return removed, true
+	case ueventActionChange:
+		return changed, true
+	default:
+		return "", false
+	}
+}
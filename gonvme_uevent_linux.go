@@ -0,0 +1,85 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// ueventKernelGroup is the multicast group of the uevents sent by the kernel, udev uses group 2
+	ueventKernelGroup = 1
+	ueventBufferSize  = 64 * 1024
+	ueventRecvBuffer  = 1024 * 1024
+)
+
+// ueventReadTimeout bounds a single Read so that the listener notices a cancelled context
+var ueventReadTimeout = time.Second
+
+type netlinkUEventSource struct {
+	fd  int
+	buf []byte
+}
+
+// NewNetlinkUEventSource returns a UEventSource subscribed to the kernel uevents
+func NewNetlinkUEventSource() (UEventSource, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open uevent socket: %w", err)
+	}
+
+	// a larger buffer reduces the events lost while the listener is busy; this is best effort
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUF, ueventRecvBuffer); err != nil {
+		packageLog().Debugf("Unable to set uevent socket buffer size: %v", err)
+	}
+	tv := unix.NsecToTimeval(ueventReadTimeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		_ = unix.Close(fd)
+		return nil, fmt.Errorf("failed to set uevent socket timeout: %w", err)
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK, Groups: ueventKernelGroup}); err != nil {
+		_ = unix.Close(fd)
+		return nil, fmt.Errorf("failed to bind uevent socket: %w", err)
+	}
+
+	return &netlinkUEventSource{fd: fd, buf: make([]byte, ueventBufferSize)}, nil
+}
+
+func (s *netlinkUEventSource) Read() ([]byte, error) {
+	n, _, err := unix.Recvfrom(s.fd, s.buf, 0)
+	switch {
+	case errors.Is(err, unix.EAGAIN), errors.Is(err, unix.EINTR):
+		return nil, nil
+	case errors.Is(err, unix.ENOBUFS):
+		packageLog().Errorf("Uevent socket overrun, some NVMe events were lost")
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read uevent socket: %w", err)
+	}
+
+	msg := make([]byte, n)
+	copy(msg, s.buf[:n])
+	return msg, nil
+}
+
+func (s *netlinkUEventSource) Close() error {
+	return unix.Close(s.fd)
+}
@@ -0,0 +1,212 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// uevent builds a kernel uevent message from its header and KEY=VALUE pairs
+func uevent(header string, env ...string) []byte {
+	return []byte(header + "\x00" + strings.Join(env, "\x00") + "\x00")
+}
+
+// recorded uevents of a TCP controller connecting to a multipath subsystem, an AEN and a path loss
+var ueventTestRecording = [][]byte{
+	uevent("add@/devices/virtual/nvme-subsystem/nvme-subsys0",
+		"ACTION=add", "DEVPATH=/devices/virtual/nvme-subsystem/nvme-subsys0", "SUBSYSTEM=nvme-subsystem", "SEQNUM=4101"),
+	uevent("add@/devices/virtual/nvme-fabrics/ctl/nvme0",
+		"ACTION=add", "DEVPATH=/devices/virtual/nvme-fabrics/ctl/nvme0", "SUBSYSTEM=nvme", "MAJOR=239", "MINOR=0", "DEVNAME=nvme0", "SEQNUM=4102"),
+	uevent("change@/devices/virtual/nvme-fabrics/ctl/nvme0",
+		"ACTION=change", "DEVPATH=/devices/virtual/nvme-fabrics/ctl/nvme0", "SUBSYSTEM=nvme", "NVME_EVENT=connected", "DEVNAME=nvme0",
+		"NVME_TRTYPE=tcp", "NVME_TRADDR=10.1.1.1", "NVME_TRSVCID=4420", "NVME_HOST_TRADDR=10.1.1.10", "SEQNUM=4103"),
+	uevent("add@/devices/virtual/nvme-subsystem/nvme-subsys0/nvme0n1",
+		"ACTION=add", "DEVPATH=/devices/virtual/nvme-subsystem/nvme-subsys0/nvme0n1", "SUBSYSTEM=block", "MAJOR=259", "MINOR=1",
+		"DEVNAME=nvme0n1", "DEVTYPE=disk", "SEQNUM=4104"),
+	uevent("add@/devices/virtual/nvme-fabrics/ctl/nvme0/nvme0c0n1",
+		"ACTION=add", "DEVPATH=/devices/virtual/nvme-fabrics/ctl/nvme0/nvme0c0n1", "SUBSYSTEM=block", "DEVTYPE=disk", "SEQNUM=4105"),
+	uevent("add@/devices/virtual/nvme-subsystem/nvme-subsys0/nvme0n1/nvme0n1p1",
+		"ACTION=add", "DEVPATH=/devices/virtual/nvme-subsystem/nvme-subsys0/nvme0n1/nvme0n1p1", "SUBSYSTEM=block", "DEVNAME=nvme0n1p1",
+		"DEVTYPE=partition", "SEQNUM=4106"),
+	uevent("change@/devices/virtual/nvme-fabrics/ctl/nvme0",
+		"ACTION=change", "DEVPATH=/devices/virtual/nvme-fabrics/ctl/nvme0", "SUBSYSTEM=nvme", "NVME_AEN=0x00040002", "DEVNAME=nvme0",
+		"NVME_TRTYPE=tcp", "NVME_TRADDR=10.1.1.1", "NVME_TRSVCID=4420", "SEQNUM=4107"),
+	uevent("add@/devices/virtual/net/lo/queues/rx-0",
+		"ACTION=add", "DEVPATH=/devices/virtual/net/lo/queues/rx-0", "SUBSYSTEM=queues", "SEQNUM=4108"),
+	[]byte("libudev\x00\xfe\xed\xca\xfe"),
+	nil,
+	uevent("remove@/devices/virtual/nvme-fabrics/ctl/nvme0/nvme0c0n1",
+		"ACTION=remove", "DEVPATH=/devices/virtual/nvme-fabrics/ctl/nvme0/nvme0c0n1", "SUBSYSTEM=block", "DEVTYPE=disk", "SEQNUM=4109"),
+	uevent("remove@/devices/virtual/nvme-fabrics/ctl/nvme0",
+		"ACTION=remove", "DEVPATH=/devices/virtual/nvme-fabrics/ctl/nvme0", "SUBSYSTEM=nvme", "DEVNAME=nvme0", "SEQNUM=4110"),
+}
+
+func TestParseUEvent(t *testing.T) {
+	parsed, err := ParseUEvent(ueventTestRecording[3])
+	assert.NoError(t, err)
+	assert.Equal(t, "add", parsed.Action)
+	assert.Equal(t, "/devices/virtual/nvme-subsystem/nvme-subsys0/nvme0n1", parsed.DevPath)
+	assert.Equal(t, "block", parsed.Subsystem)
+	assert.Equal(t, "nvme0n1", parsed.DevName)
+	assert.Equal(t, "disk", parsed.DevType)
+	assert.Equal(t, uint64(4104), parsed.Seqnum)
+	assert.Equal(t, "259", parsed.Env["MAJOR"])
+
+	tests := []struct {
+		name string
+		msg  []byte
+	}{
+		{"empty", nil},
+		{"udev message", []byte("libudev\x00\xfe\xed\xca\xfe")},
+		{"no action", uevent("add@/devices/x", "DEVPATH=/devices/x")},
+		{"invalid seqnum", uevent("add@/devices/x", "ACTION=add", "DEVPATH=/devices/x", "SEQNUM=x")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseUEvent(tt.msg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNVMeEventFromUEvent(t *testing.T) {
+	tests := []struct {
+		name   string
+		uevent UEvent
+		want   NVMeEvent
+		wantOk bool
+	}{
+		{
+			name:   "controller change without NVME_EVENT",
+			uevent: UEvent{Action: "change", DevPath: "/devices/virtual/nvme-fabrics/ctl/nvme3", Subsystem: "nvme", DevName: "nvme3"},
+			want:   NVMeEvent{Type: NVMeEventControllerChanged, Controller: "nvme3"},
+			wantOk: true,
+		},
+		{
+			name:   "rediscover",
+			uevent: UEvent{Action: "change", DevPath: "/devices/virtual/nvme-fabrics/ctl/nvme4", Subsystem: "nvme", Env: map[string]string{"NVME_EVENT": "rediscover"}},
+			want:   NVMeEvent{Type: NVMeEventRediscover, Controller: "nvme4"},
+			wantOk: true,
+		},
+		{
+			name:   "subsystem removed",
+			uevent: UEvent{Action: "remove", DevPath: "/devices/virtual/nvme-subsystem/nvme-subsys2", Subsystem: "nvme-subsystem"},
+			want:   NVMeEvent{Type: NVMeEventSubsystemRemoved, Subsystem: "nvme-subsys2"},
+			wantOk: true,
+		},
+		{
+			name:   "namespace resized on a PCIe controller",
+			uevent: UEvent{Action: "change", DevPath: "/devices/pci0000:00/0000:00:04.0/nvme/nvme1/nvme1n1", Subsystem: "block", DevName: "nvme1n1", DevType: "disk"},
+			want:   NVMeEvent{Type: NVMeEventNamespaceChanged, Controller: "nvme1", Device: "/dev/nvme1n1"},
+			wantOk: true,
+		},
+		{
+			name:   "controller bind is ignored",
+			uevent: UEvent{Action: "bind", DevPath: "/devices/virtual/nvme-fabrics/ctl/nvme3", Subsystem: "nvme", DevName: "nvme3"},
+		},
+		{
+			name:   "fabrics control device is ignored",
+			uevent: UEvent{Action: "add", DevPath: "/devices/virtual/misc/nvme-fabrics", Subsystem: "misc", DevName: "nvme-fabrics"},
+		},
+		{
+			name:   "other disk is ignored",
+			uevent: UEvent{Action: "add", DevPath: "/devices/virtual/block/dm-0", Subsystem: "block", DevName: "dm-0", DevType: "disk"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := NVMeEventFromUEvent(tt.uevent)
+			assert.Equal(t, tt.wantOk, ok)
+			if tt.wantOk {
+				tt.want.UEvent = tt.uevent
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUEventListenerReplay(t *testing.T) {
+	listener := NewUEventListener(NewReplayUEventSource(ueventTestRecording))
+	assert.NoError(t, listener.Run(context.Background()))
+
+	var events []NVMeEvent
+	for event := range listener.Events() {
+		events = append(events, event)
+	}
+
+	type summary struct {
+		Type       NVMeEventType
+		Controller string
+		Subsystem  string
+		Device     string
+	}
+	var got []summary
+	for _, event := range events {
+		got = append(got, summary{event.Type, event.Controller, event.Subsystem, event.Device})
+	}
+	assert.Equal(t, []summary{
+		{NVMeEventSubsystemAdded, "", "nvme-subsys0", ""},
+		{NVMeEventControllerAdded, "nvme0", "", ""},
+		{NVMeEventControllerConnected, "nvme0", "", ""},
+		{NVMeEventNamespaceAdded, "", "nvme-subsys0", "/dev/nvme0n1"},
+		{NVMeEventNamespaceAdded, "nvme0", "", "/dev/nvme0c0n1"},
+		{NVMeEventAEN, "nvme0", "", ""},
+		{NVMeEventNamespaceRemoved, "nvme0", "", "/dev/nvme0c0n1"},
+		{NVMeEventControllerRemoved, "nvme0", "", ""},
+	}, got)
+
+	connected := events[2]
+	assert.Equal(t, "tcp", connected.Transport)
+	assert.Equal(t, "10.1.1.1", connected.Address)
+	assert.Equal(t, "4420", connected.ServiceID)
+	assert.Equal(t, "10.1.1.10", connected.HostAddress)
+	assert.Equal(t, uint32(0x00040002), events[5].AEN)
+}
+
+type failingUEventSource struct {
+	closed bool
+}
+
+func (s *failingUEventSource) Read() ([]byte, error) { return nil, errors.New("read failed") }
+func (s *failingUEventSource) Close() error          { s.closed = true; return nil }
+
+func TestUEventListenerErrors(t *testing.T) {
+	source := &failingUEventSource{}
+	listener := NewUEventListener(source)
+	assert.EqualError(t, listener.Run(context.Background()), "read failed")
+	assert.True(t, source.closed)
+	_, open := <-listener.Events()
+	assert.False(t, open)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	listener = NewUEventListener(NewReplayUEventSource(ueventTestRecording))
+	assert.ErrorIs(t, listener.Run(ctx), context.Canceled)
+}
+
+func TestNewNetlinkUEventSource(t *testing.T) {
+	source, err := NewNetlinkUEventSource()
+	if err != nil {
+		t.Skipf("uevent socket not available: %v", err)
+	}
+	assert.NoError(t, source.Close())
+}
@@ -21,8 +21,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
-
-	log "github.com/sirupsen/logrus"
+	"unicode"
 )
 
 type sessionParser struct{}
@@ -50,7 +49,7 @@ func (sp *sessionParser) Parse(data []byte) []NVMESession {
 	var response []SubSysResponse
 	err := json.Unmarshal([]byte(str), &response)
 	if err != nil {
-		log.Error("JSON-encoded parsing error: ", err.Error())
+		packageLog().Errorf("JSON-encoded parsing error: %v", err)
 		return result
 	}
 	for _, resp := range response {
@@ -91,6 +90,10 @@ func (sp *sessionParser) Parse(data []byte) []NVMESession {
 				} else {
 					continue
 				}
+				session.HostAddress = addressField(path["Address"], "host_traddr")
+				if session.HostAddress == "" {
+					session.HostAddress = addressField(path["Address"], "src_addr")
+				}
 				session.NVMESessionState = NVMESessionState(path["State"])
 				result = append(result, session)
 			}
@@ -98,3 +101,18 @@ func (sp *sessionParser) Parse(data []byte) []NVMESession {
 	}
 	return result
 }
+
+// addressField returns the value of key in a controller address such as
+// "traddr=10.1.1.1,trsvcid=4420,src_addr=10.1.1.2" or "traddr=nn-0x1:pn-0x2 host_traddr=nn-0x3:pn-0x4"
+func addressField(address string, key string) string {
+	fields := strings.FieldsFunc(address, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	for _, field := range fields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) == 2 && parts[0] == key {
+			return strings.Trim(parts[1], "\"")
+		}
+	}
+	return ""
+}
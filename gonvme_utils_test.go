@@ -143,3 +143,13 @@ func TestSessionParser(t *testing.T) {
 		})
 	}
 }
+
+func TestAddressField(t *testing.T) {
+	tcp := "traddr=10.1.1.1,trsvcid=4420,src_addr=10.1.1.2"
+	assert.Equal(t, "10.1.1.1", addressField(tcp, "traddr"))
+	assert.Equal(t, "10.1.1.2", addressField(tcp, "src_addr"))
+	assert.Equal(t, "", addressField(tcp, "host_traddr"))
+
+	fc := "traddr=nn-0x58ccf090c9200bcf:pn-0x58ccf091492b0bcf host_traddr=nn-0x200000109b6460e1:pn-0x100000109b6460e1"
+	assert.Equal(t, "nn-0x200000109b6460e1:pn-0x100000109b6460e1", addressField(fc, "host_traddr"))
+}
@@ -0,0 +1,187 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ErrUnsupportedCapability is returned when a feature is requested from an nvme-cli too old to provide it
+var ErrUnsupportedCapability = errors.New("unsupported by nvme-cli")
+
+// Capability is a feature of nvme-cli which depends on its version. The JSON schemas which differ
+// between versions, of nvme list for instance, are told apart by their parsers.
+type Capability string
+
+const (
+	// CapabilityOutputFormat is the --output-format (-o) option of every subcommand, nvme list-ns included.
+	// Earlier releases only take it for nvme list and nvme list-subsys.
+	CapabilityOutputFormat Capability = "output-format"
+
+	// CapabilityDHCHAP is the --dhchap-secret option of nvme connect, NVMeTarget.DHCHAPSecret
+	CapabilityDHCHAP Capability = "dhchap"
+
+	// CapabilityTLS is the --tls option of nvme connect, NVMeTarget.TLS
+	CapabilityTLS Capability = "tls"
+)
+
+// capabilities are the first nvme-cli versions providing each capability
+var capabilities = map[Capability]NVMeCLIVersion{
+	CapabilityOutputFormat: {Major: 2, Minor: 0},
+	CapabilityDHCHAP:       {Major: 2, Minor: 0},
+	CapabilityTLS:          {Major: 2, Minor: 4},
+}
+
+var (
+	nvmeVersionRegexp    = regexp.MustCompile(`(?m)^nvme version (\d+)\.(\d+)(?:\.(\d+))?`)
+	libnvmeVersionRegexp = regexp.MustCompile(`(?m)^libnvme version (\S+)`)
+)
+
+// NVMeCLIVersion is the version of nvme-cli
+type NVMeCLIVersion struct {
+	Major int
+	Minor int
+	Patch int
+	// LibNVMe is the version of the libnvme nvme-cli is built with, from nvme-cli 2.0
+	LibNVMe string
+}
+
+func (v NVMeCLIVersion) String() string {
+	if v.Patch != 0 {
+		return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	}
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// AtLeast returns true when the version is the same as or later than other
+func (v NVMeCLIVersion) AtLeast(other NVMeCLIVersion) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+	return v.Patch >= other.Patch
+}
+
+// Supports returns true when the version provides the capability
+func (v NVMeCLIVersion) Supports(c Capability) bool {
+	minimum, ok := capabilities[c]
+	return ok && v.AtLeast(minimum)
+}
+
+// parseCLIVersion parses the output of nvme version, e.g.
+//
+//	nvme version 2.8 (git 2.8)
+//	libnvme version 1.8 (git 1.8)
+func parseCLIVersion(output []byte) (NVMeCLIVersion, error) {
+	match := nvmeVersionRegexp.FindSubmatch(output)
+	if match == nil {
+		return NVMeCLIVersion{}, fmt.Errorf("unexpected nvme version output %q", output)
+	}
+	var version NVMeCLIVersion
+	version.Major, _ = strconv.Atoi(string(match[1]))
+	version.Minor, _ = strconv.Atoi(string(match[2]))
+	if len(match[3]) > 0 {
+		version.Patch, _ = strconv.Atoi(string(match[3]))
+	}
+	if lib := libnvmeVersionRegexp.FindSubmatch(output); lib != nil {
+		version.LibNVMe = string(lib[1])
+	}
+	return version, nil
+}
+
+// CLIVersion returns the version of nvme-cli. It is probed on first use rather than by NewNVMe, so that
+// an Executor set after NewNVMe is the one asked and clients which never need it do not run nvme-cli.
+// The probe runs once: its result, a failure included, is kept for the life of the NVMe.
+func (nvme *NVMe) CLIVersion() (NVMeCLIVersion, error) {
+	nvme.cliVersionOnce.Do(func() {
+		nvme.cliVersion, nvme.cliVersionErr = nvme.probeCLIVersion()
+	})
+	return nvme.cliVersion, nvme.cliVersionErr
+}
+
+func (nvme *NVMe) probeCLIVersion() (NVMeCLIVersion, error) {
+	var err error
+	// falling back to --version for the builds without the version subcommand
+	for _, arg := range []string{"version", "--version"} {
+		var output []byte
+		output, err = nvme.output([]string{nvme.NVMeCommand, arg})
+		if err != nil {
+			continue
+		}
+		var version NVMeCLIVersion
+		version, err = parseCLIVersion(output)
+		if err != nil {
+			continue
+		}
+		nvme.log().Infof("nvme-cli version %s, libnvme version %s", version, version.LibNVMe)
+		return version, nil
+	}
+	nvme.log().Warnf("Failed to get the nvme-cli version, assuming every capability: %v", err)
+	return NVMeCLIVersion{}, fmt.Errorf("failed to get the nvme-cli version: %w", err)
+}
+
+// requireCapability returns an ErrUnsupportedCapability error when nvme-cli is known not to provide the capability.
+// When the version is unknown the capability is assumed, leaving nvme-cli to reject what it does not provide.
+func (nvme *NVMe) requireCapability(c Capability) error {
+	version, err := nvme.CLIVersion()
+	if err != nil {
+		// the probe failure is logged once by CLIVersion
+		return nil
+	}
+	if !version.Supports(c) {
+		return fmt.Errorf("%w: %s requires nvme-cli %s or later, found %s", ErrUnsupportedCapability, c, capabilities[c], version)
+	}
+	return nil
+}
+
+// jsonOutputSubcommands are the subcommands taking -o json in every nvme-cli release
+var jsonOutputSubcommands = map[string]bool{"list": true, "list-subsys": true}
+
+// jsonOutputArgs returns the options asking the subcommand for JSON output, none when nvme-cli is
+// not known to provide it and the plain output has to be parsed
+func (nvme *NVMe) jsonOutputArgs(subcommand string) []string {
+	if jsonOutputSubcommands[subcommand] {
+		return []string{"-o", "json"}
+	}
+	if version, err := nvme.CLIVersion(); err == nil && version.Supports(CapabilityOutputFormat) {
+		return []string{"-o", "json"}
+	}
+	return nil
+}
+
+// connectSecurityArgs returns the nvme connect options of the authentication and encryption of the target
+func (nvme *NVMe) connectSecurityArgs(target NVMeTarget) ([]string, error) {
+	var args []string
+	if target.DHCHAPSecret != "" {
+		if err := nvme.requireCapability(CapabilityDHCHAP); err != nil {
+			return nil, err
+		}
+		args = append(args, "--dhchap-secret="+target.DHCHAPSecret)
+	}
+	if target.TLS {
+		if err := nvme.requireCapability(CapabilityTLS); err != nil {
+			return nil, err
+		}
+		args = append(args, "--tls")
+	}
+	return args, nil
+}
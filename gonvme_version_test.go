@@ -0,0 +1,194 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package gonvme
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCLIVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		want    NVMeCLIVersion
+		wantErr bool
+	}{
+		{"1.16", "nvme version 1.16\n", NVMeCLIVersion{Major: 1, Minor: 16}, false},
+		{"2.8", "nvme version 2.8 (git 2.8)\nlibnvme version 1.8 (git 1.8)\n", NVMeCLIVersion{Major: 2, Minor: 8, LibNVMe: "1.8"}, false},
+		{"patch", "nvme version 2.11.1 (git 2.11.1)\nlibnvme version 1.11.1 (git 1.11.1)\n", NVMeCLIVersion{Major: 2, Minor: 11, Patch: 1, LibNVMe: "1.11.1"}, false},
+		{"garbage", "Usage: nvme <command> [<device>] [<args>]\n", NVMeCLIVersion{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCLIVersion([]byte(tt.output))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCLIVersionCapabilities(t *testing.T) {
+	v116 := NVMeCLIVersion{Major: 1, Minor: 16}
+	v28 := NVMeCLIVersion{Major: 2, Minor: 8}
+	v211 := NVMeCLIVersion{Major: 2, Minor: 11, Patch: 1}
+
+	assert.Equal(t, "1.16", v116.String())
+	assert.Equal(t, "2.11.1", v211.String())
+	assert.True(t, v211.AtLeast(v28))
+	assert.True(t, v28.AtLeast(v28))
+	assert.False(t, v116.AtLeast(v28))
+
+	assert.False(t, v116.Supports(CapabilityDHCHAP))
+	assert.False(t, v116.Supports(CapabilityTLS))
+	assert.False(t, v116.Supports(CapabilityOutputFormat))
+	assert.True(t, v28.Supports(CapabilityOutputFormat))
+	assert.True(t, v28.Supports(CapabilityDHCHAP))
+	assert.True(t, v28.Supports(CapabilityTLS))
+	assert.False(t, NVMeCLIVersion{Major: 2, Minor: 3}.Supports(CapabilityTLS))
+	assert.False(t, v211.Supports(Capability("unknown")))
+}
+
+func TestCLIVersion(t *testing.T) {
+	var commands []string
+	originalExecutor := defaultExecutor
+	defer func() { defaultExecutor = originalExecutor }()
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		commands = append(commands, strings.Join(args, " "))
+		if args[0] == "version" {
+			return mockCommand{outErr: &ExitError{Code: 1}}
+		}
+		return mockCommand{out: []byte("nvme version 2.8 (git 2.8)\nlibnvme version 1.8 (git 1.8)\n")}
+	})
+
+	nvme := NewNVMe(nil)
+	version, err := nvme.CLIVersion()
+	require.NoError(t, err)
+	assert.Equal(t, NVMeCLIVersion{Major: 2, Minor: 8, LibNVMe: "1.8"}, version)
+	assert.Equal(t, []string{"version", "--version"}, commands)
+
+	// the version is probed once
+	_, err = nvme.CLIVersion()
+	assert.NoError(t, err)
+	assert.Len(t, commands, 2)
+
+	defaultExecutor = mockExecutor(func(_ string, _ ...string) mockCommand {
+		return mockCommand{outErr: errors.New("exec: \"nvme\": executable file not found in $PATH")}
+	})
+	commands = nil
+	defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+		commands = append(commands, strings.Join(args, " "))
+		return mockCommand{outErr: errors.New("exec: \"nvme\": executable file not found in $PATH")}
+	})
+	nvme = NewNVMe(nil)
+	_, err = nvme.CLIVersion()
+	assert.ErrorContains(t, err, "executable file not found")
+
+	// so is a failure
+	_, err = nvme.CLIVersion()
+	assert.ErrorContains(t, err, "executable file not found")
+	assert.Len(t, commands, 2)
+}
+
+func TestConnectSecurityArgs(t *testing.T) {
+	target := NVMeTarget{
+		Portal:       "1.1.1.1",
+		TargetNqn:    "nqn.1988-11.com.dell.mock:00:e6e2d5b871f1403E169D0",
+		DHCHAPSecret: "DHHC-1:00:ia6zGodOr4SEG0Zzaw398rpY0wqipUWj4jWjUh4HWUz6aQ2n:",
+		TLS:          true,
+	}
+	tests := []struct {
+		name    string
+		version string
+		target  NVMeTarget
+		want    []string
+		wantErr error
+	}{
+		{"none", "nvme version 1.16\n", NVMeTarget{Portal: "1.1.1.1"}, nil, nil},
+		{"supported", "nvme version 2.8 (git 2.8)\n", target, []string{"--dhchap-secret=" + target.DHCHAPSecret, "--tls"}, nil},
+		{"no tls", "nvme version 2.3 (git 2.3)\n", target, nil, ErrUnsupportedCapability},
+		{"no dhchap", "nvme version 1.16\n", NVMeTarget{DHCHAPSecret: target.DHCHAPSecret}, nil, ErrUnsupportedCapability},
+		{"unknown version", "", target, []string{"--dhchap-secret=" + target.DHCHAPSecret, "--tls"}, nil},
+	}
+	originalExecutor := defaultExecutor
+	defer func() { defaultExecutor = originalExecutor }()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var connect []string
+			defaultExecutor = mockExecutor(func(_ string, args ...string) mockCommand {
+				switch args[0] {
+				case "version", "--version":
+					if tt.version == "" {
+						return mockCommand{outErr: &ExitError{Code: 1}}
+					}
+					return mockCommand{out: []byte(tt.version)}
+				case "connect":
+					connect = args
+				}
+				return mockCommand{}
+			})
+			nvme := NewNVMe(nil)
+			nvme.NVMeCommand = "nvme"
+
+			got, err := nvme.connectSecurityArgs(tt.target)
+			connectErr := nvme.NVMeTCPConnect(tt.target, false)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.ErrorIs(t, connectErr, tt.wantErr)
+				assert.Nil(t, connect)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NoError(t, connectErr)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, append([]string{}, tt.want...), connect[len(connect)-len(tt.want):])
+		})
+	}
+
+	nvme := NewNVMe(nil)
+	err := nvme.NVMeFCConnect(NVMeTarget{TLS: true}, false)
+	assert.ErrorContains(t, err, "TLS is only supported by NVMe/TCP")
+}
+
+func TestRecordingRedactsSecrets(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "nvme.jsonl")
+	recorder, err := NewRecordingExecutor(mockExecutor(func(_ string, _ ...string) mockCommand {
+		return mockCommand{}
+	}), file)
+	require.NoError(t, err)
+	_, _, err = recorder.Run("nvme", "connect", "-t", "tcp", "--dhchap-secret=DHHC-1:00:secret:")
+	require.NoError(t, err)
+	require.NoError(t, recorder.Close())
+
+	replay, err := NewReplayExecutor(file)
+	require.NoError(t, err)
+	for _, recordings := range replay.recordings {
+		assert.Equal(t, []string{"nvme", "connect", "-t", "tcp", "--dhchap-secret=REDACTED"}, recordings[0].Args)
+	}
+	// the recording is replayed whatever the secret
+	_, _, err = replay.Run("nvme", "connect", "-t", "tcp", "--dhchap-secret=DHHC-1:00:other:")
+	assert.NoError(t, err)
+}
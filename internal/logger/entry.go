@@ -0,0 +1,146 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Level is the severity of a message
+type Level int
+
+const (
+	// LevelDebug is the level of the messages useful to debug gonvme
+	LevelDebug Level = iota
+	// LevelInfo is the level of the changes made to the host
+	LevelInfo
+	// LevelWarn is the level of the unexpected conditions gonvme works around
+	LevelWarn
+	// LevelError is the level of the failures
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "level(" + strconv.Itoa(int(l)) + ")"
+}
+
+// Field is a key/value pair describing the context of a message
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Entry logs messages with fields through a Logger. Entries are immutable and safe for concurrent use.
+type Entry struct {
+	logger Logger
+	ctx    context.Context
+	fields []Field
+}
+
+// New returns an entry logging through the logger, the one set with SetLogger when nil
+func New(l Logger, fields ...Field) *Entry {
+	return &Entry{logger: l, ctx: context.Background(), fields: fields}
+}
+
+// With returns an entry logging the fields along with the fields of e
+func (e *Entry) With(fields ...Field) *Entry {
+	all := make([]Field, 0, len(e.fields)+len(fields))
+	all = append(all, e.fields...)
+	all = append(all, fields...)
+	return &Entry{logger: e.logger, ctx: e.ctx, fields: all}
+}
+
+// WithContext returns an entry passing the context to the logger
+func (e *Entry) WithContext(ctx context.Context) *Entry {
+	return &Entry{logger: e.logger, ctx: ctx, fields: e.fields}
+}
+
+// Debugf logs a debug message
+func (e *Entry) Debugf(format string, args ...interface{}) {
+	e.log(LevelDebug, format, args...)
+}
+
+// Infof logs an info message
+func (e *Entry) Infof(format string, args ...interface{}) {
+	e.log(LevelInfo, format, args...)
+}
+
+// Warnf logs a warning, as an info message with the loggers which are not a FieldLogger
+func (e *Entry) Warnf(format string, args ...interface{}) {
+	e.log(LevelWarn, format, args...)
+}
+
+// Errorf logs an error message
+func (e *Entry) Errorf(format string, args ...interface{}) {
+	e.log(LevelError, format, args...)
+}
+
+func (e *Entry) log(level Level, format string, args ...interface{}) {
+	l := e.logger
+	if l == nil {
+		l = logger
+	}
+	msg := fmt.Sprintf(format, args...)
+	if fl, ok := l.(FieldLogger); ok {
+		fl.Log(e.ctx, level, msg, e.fields)
+		return
+	}
+
+	// the message is passed as an argument so that the % of the fields are not taken as verbs
+	msg = formatFields(msg, e.fields)
+	switch level {
+	case LevelDebug:
+		l.Debug(e.ctx, "%s", msg)
+	case LevelError:
+		l.Error(e.ctx, "%s", msg)
+	default:
+		l.Info(e.ctx, "%s", msg)
+	}
+}
+
+// formatFields returns the message followed by the fields as key=value, the values with spaces quoted
+func formatFields(msg string, fields []Field) string {
+	if len(fields) == 0 {
+		return msg
+	}
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, f := range fields {
+		value := fmt.Sprint(f.Value)
+		if value == "" || strings.ContainsAny(value, " \t\n\"=") {
+			value = strconv.Quote(value)
+		}
+		b.WriteString(" ")
+		b.WriteString(f.Key)
+		b.WriteString("=")
+		b.WriteString(value)
+	}
+	return b.String()
+}
@@ -0,0 +1,78 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package logger
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingLogger keeps the messages of a Logger which is not a FieldLogger
+type recordingLogger struct {
+	messages []string
+}
+
+func (r *recordingLogger) Info(_ context.Context, format string, args ...interface{}) {
+	r.messages = append(r.messages, "info: "+fmt.Sprintf(format, args...))
+}
+
+func (r *recordingLogger) Debug(_ context.Context, format string, args ...interface{}) {
+	r.messages = append(r.messages, "debug: "+fmt.Sprintf(format, args...))
+}
+
+func (r *recordingLogger) Error(_ context.Context, format string, args ...interface{}) {
+	r.messages = append(r.messages, "error: "+fmt.Sprintf(format, args...))
+}
+
+func TestEntry(t *testing.T) {
+	l := &recordingLogger{}
+	base := New(l, Field{Key: "operation", Value: "connect"})
+	entry := base.With(Field{Key: "portal", Value: "1.1.1.1:4420"}, Field{Key: "nqn", Value: "nqn with space"})
+	entry.Infof("connected in %d%%", 100)
+	entry.Warnf("slow")
+	entry.Debugf("details")
+	entry.Errorf("failed")
+	base.Infof("done")
+
+	assert.Equal(t, []string{
+		`info: connected in 100% operation=connect portal=1.1.1.1:4420 nqn="nqn with space"`,
+		`info: slow operation=connect portal=1.1.1.1:4420 nqn="nqn with space"`,
+		`debug: details operation=connect portal=1.1.1.1:4420 nqn="nqn with space"`,
+		`error: failed operation=connect portal=1.1.1.1:4420 nqn="nqn with space"`,
+		`info: done operation=connect`,
+	}, l.messages)
+}
+
+func TestEntryPackageLogger(t *testing.T) {
+	oldLogger := logger
+	defer func() {
+		logger = oldLogger
+	}()
+	l := &recordingLogger{}
+	entry := New(nil, Field{Key: "device", Value: "/dev/nvme0n1"})
+	SetLogger(l)
+	entry.Infof("resized")
+	assert.Equal(t, []string{"info: resized device=/dev/nvme0n1"}, l.messages)
+}
+
+func TestLevelString(t *testing.T) {
+	assert.Equal(t, "debug", LevelDebug.String())
+	assert.Equal(t, "warn", LevelWarn.String())
+	assert.Equal(t, "level(7)", Level(7).String())
+}
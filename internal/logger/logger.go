@@ -20,12 +20,13 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 )
 
 var logger Logger
 
 func init() {
-	logger = &ConsoleLogger{}
+	logger = NewLogrusLogger(nil)
 }
 
 // SetLogger - set custom logger
@@ -40,9 +41,21 @@ type Logger interface {
 	Error(ctx context.Context, format string, args ...interface{})
 }
 
+// FieldLogger is a Logger taking the fields of the messages apart from the message.
+// The fields of the messages given to the other loggers are appended to the message.
+type FieldLogger interface {
+	Logger
+	Log(ctx context.Context, level Level, msg string, fields []Field)
+}
+
 // ConsoleLogger - placeholder for default logger
 type ConsoleLogger struct{}
 
+// Log - log using default logger, with the fields appended to the message
+func (dl *ConsoleLogger) Log(_ context.Context, level Level, msg string, fields []Field) {
+	log.Print(strings.ToUpper(level.String()) + ": " + formatFields(msg, fields))
+}
+
 // Info - log info using default logger
 func (dl *ConsoleLogger) Info(_ context.Context, format string, args ...interface{}) {
 	log.Print("INFO: " + fmt.Sprintf(format, args...))
@@ -0,0 +1,71 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package logger
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogrusLogger logs with logrus, the fields being logrus fields
+type LogrusLogger struct {
+	logger logrus.FieldLogger
+}
+
+// NewLogrusLogger returns a logger logging with l, the standard logrus logger when nil
+func NewLogrusLogger(l logrus.FieldLogger) *LogrusLogger {
+	if l == nil {
+		l = logrus.StandardLogger()
+	}
+	return &LogrusLogger{logger: l}
+}
+
+// Info - log info using logrus
+func (ll *LogrusLogger) Info(ctx context.Context, format string, args ...interface{}) {
+	ll.Log(ctx, LevelInfo, fmt.Sprintf(format, args...), nil)
+}
+
+// Debug - log debug using logrus
+func (ll *LogrusLogger) Debug(ctx context.Context, format string, args ...interface{}) {
+	ll.Log(ctx, LevelDebug, fmt.Sprintf(format, args...), nil)
+}
+
+// Error - log error using logrus
+func (ll *LogrusLogger) Error(ctx context.Context, format string, args ...interface{}) {
+	ll.Log(ctx, LevelError, fmt.Sprintf(format, args...), nil)
+}
+
+// Log - log the message with the fields using logrus
+func (ll *LogrusLogger) Log(ctx context.Context, level Level, msg string, fields []Field) {
+	logrusFields := make(logrus.Fields, len(fields))
+	for _, f := range fields {
+		logrusFields[f.Key] = f.Value
+	}
+	entry := ll.logger.WithFields(logrusFields).WithContext(ctx)
+	switch level {
+	case LevelDebug:
+		entry.Log(logrus.DebugLevel, msg)
+	case LevelWarn:
+		entry.Log(logrus.WarnLevel, msg)
+	case LevelError:
+		entry.Log(logrus.ErrorLevel, msg)
+	default:
+		entry.Log(logrus.InfoLevel, msg)
+	}
+}
@@ -0,0 +1,52 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogrusLogger(t *testing.T) {
+	var buf bytes.Buffer
+	base := logrus.New()
+	base.SetOutput(&buf)
+	base.SetFormatter(&logrus.JSONFormatter{})
+	base.SetLevel(logrus.InfoLevel)
+	l := NewLogrusLogger(base)
+	entry := New(l, Field{Key: "operation", Value: "connect"}, Field{Key: "nqn", Value: "nqn.1988-11.com.dell:powerstore:00:1"})
+	entry.Debugf("hidden")
+	entry.Errorf("connect failed")
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "error", record["level"])
+	assert.Equal(t, "connect failed", record["msg"])
+	assert.Equal(t, "connect", record["operation"])
+	assert.Equal(t, "nqn.1988-11.com.dell:powerstore:00:1", record["nqn"])
+
+	buf.Reset()
+	l.Info(context.Background(), "connected %s", "nvme0")
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "connected nvme0", record["msg"])
+	assert.NotNil(t, NewLogrusLogger(nil))
+}
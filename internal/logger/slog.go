@@ -0,0 +1,72 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// SlogLogger logs with log/slog, the fields being attributes
+type SlogLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogLogger returns a logger logging with l, the default slog logger when nil
+func NewSlogLogger(l *slog.Logger) *SlogLogger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &SlogLogger{logger: l}
+}
+
+// Info - log info using slog
+func (sl *SlogLogger) Info(ctx context.Context, format string, args ...interface{}) {
+	sl.Log(ctx, LevelInfo, fmt.Sprintf(format, args...), nil)
+}
+
+// Debug - log debug using slog
+func (sl *SlogLogger) Debug(ctx context.Context, format string, args ...interface{}) {
+	sl.Log(ctx, LevelDebug, fmt.Sprintf(format, args...), nil)
+}
+
+// Error - log error using slog
+func (sl *SlogLogger) Error(ctx context.Context, format string, args ...interface{}) {
+	sl.Log(ctx, LevelError, fmt.Sprintf(format, args...), nil)
+}
+
+// Log - log the message with the fields as attributes using slog
+func (sl *SlogLogger) Log(ctx context.Context, level Level, msg string, fields []Field) {
+	slogLevel := slog.LevelInfo
+	switch level {
+	case LevelDebug:
+		slogLevel = slog.LevelDebug
+	case LevelWarn:
+		slogLevel = slog.LevelWarn
+	case LevelError:
+		slogLevel = slog.LevelError
+	}
+	if !sl.logger.Enabled(ctx, slogLevel) {
+		return
+	}
+	attrs := make([]slog.Attr, 0, len(fields))
+	for _, f := range fields {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+	sl.logger.LogAttrs(ctx, slogLevel, msg, attrs...)
+}
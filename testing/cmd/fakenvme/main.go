@@ -0,0 +1,29 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Command fakenvme is the fake nvme-cli of the github.com/dell/gonvme/testing package.
+// Installed as chroot, it runs the fake nvme of a fake root.
+package main
+
+import (
+	"os"
+
+	"github.com/dell/gonvme/testing"
+)
+
+func main() {
+	os.Exit(testing.Main(os.Args, os.Stdout, os.Stderr))
+}
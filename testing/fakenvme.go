@@ -0,0 +1,687 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package testing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RootEnv is the environment variable holding the fake root of the fake nvme, / when unset.
+// The fake chroot sets it for the commands it runs.
+const RootEnv = "FAKENVME_ROOT"
+
+const (
+	// exit code of nvme-cli 1.x when the connection already exists, EALREADY
+	alreadyConnectedExitCode = 114
+	// exit code of nvme-cli 1.x list-subsys without subsystem, ENOTBLK
+	noObjsFoundExitCode = 21
+)
+
+var namespaceDeviceRegexp = regexp.MustCompile(`^nvme([0-9]+)n([0-9]+)$`)
+
+// Main runs the fake nvme, or the fake chroot when invoked under that name, and returns its exit code.
+// The fake chroot runs the command found under the new root with RootEnv set to it,
+// the fake nvme being the command of interest.
+func Main(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) > 0 && filepath.Base(args[0]) == "chroot" {
+		return chroot(args[1:], stdout, stderr)
+	}
+	root := os.Getenv(RootEnv)
+	if root == "" {
+		root = "/"
+	}
+	if len(args) < 2 {
+		fmt.Fprintln(stderr, "usage: nvme <command> [<device>] [<args>]")
+		return 1
+	}
+	return run(root, args[1], args[2:], stdout, stderr)
+}
+
+// chroot runs chroot <root> <command> [<args>] without changing the root of the process
+func chroot(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) < 2 {
+		fmt.Fprintln(stderr, "chroot: missing operand")
+		return 125
+	}
+	root, name := args[0], args[1]
+
+	var path string
+	candidates := []string{name}
+	if !filepath.IsAbs(name) {
+		candidates = []string{"/usr/sbin/" + name, "/sbin/" + name, "/usr/bin/" + name, "/bin/" + name}
+	}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(filepath.Join(root, candidate)); err == nil && !info.IsDir() {
+			path = filepath.Join(root, candidate)
+			break
+		}
+	}
+	if path == "" {
+		fmt.Fprintf(stderr, "chroot: failed to run command '%s': No such file or directory\n", name)
+		return 127
+	}
+
+	cmd := exec.Command(path, args[2:]...) // #nosec G204
+	cmd.Args[0] = name
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.Env = append(os.Environ(), RootEnv+"="+root)
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "chroot: failed to run command '%s': %v\n", name, err)
+		return 126
+	}
+	return 0
+}
+
+// run runs an nvme subcommand against the state of the root
+func run(root string, subcommand string, args []string, stdout io.Writer, stderr io.Writer) int {
+	unlock, err := lock(root)
+	if err != nil {
+		fmt.Fprintf(stderr, "fakenvme: %v\n", err)
+		return 1
+	}
+	defer unlock()
+
+	state, err := ReadState(root)
+	if err != nil {
+		fmt.Fprintf(stderr, "fakenvme: %v\n", err)
+		return 1
+	}
+	if failure, ok := state.Failures[subcommand]; ok {
+		fmt.Fprintln(stderr, failure.Stderr)
+		return failure.ExitCode
+	}
+
+	cli := &cli{root: root, state: state, flags: parseFlags(args), stdout: stdout, stderr: stderr}
+	switch subcommand {
+	case "version", "--version":
+		return cli.version()
+	case "discover":
+		return cli.discover()
+	case "connect":
+		return cli.connect()
+	case "disconnect":
+		return cli.disconnect()
+	case "list":
+		return cli.list()
+	case "list-subsys":
+		return cli.listSubsys()
+	case "list-ns":
+		return cli.listNS()
+	case "id-ns":
+		return cli.idNS()
+	case "ns-rescan":
+		return cli.nsRescan()
+	case "reset":
+		return cli.reset()
+	}
+	fmt.Fprintf(stderr, "unknown command: %s\n", subcommand)
+	return 1
+}
+
+// flags are the options and the positional arguments of a subcommand
+type flags struct {
+	values      map[string]string
+	positionals []string
+}
+
+// flagAliases maps the long options of nvme-cli to the short ones
+var flagAliases = map[string]string{
+	"--transport":         "-t",
+	"--traddr":            "-a",
+	"--trsvcid":           "-s",
+	"--host-traddr":       "-w",
+	"--nqn":               "-n",
+	"--device":            "-d",
+	"--output-format":     "-o",
+	"--duplicate-connect": "-D",
+	"--verbose":           "-v",
+	"--namespace-id":      "-n",
+}
+
+// valueFlags are the short options taking a value, the others being switches
+var valueFlags = map[string]bool{"-t": true, "-a": true, "-s": true, "-w": true, "-n": true, "-d": true, "-o": true}
+
+func parseFlags(args []string) flags {
+	f := flags{values: map[string]string{}}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			f.positionals = append(f.positionals, arg)
+			continue
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		if name == "--all" {
+			f.values["--all"] = "true"
+			continue
+		}
+		if alias, ok := flagAliases[name]; ok {
+			name = alias
+		}
+		switch {
+		case hasValue:
+			f.values[name] = value
+		case valueFlags[name] && i+1 < len(args):
+			f.values[name] = args[i+1]
+			i++
+		default:
+			f.values[name] = "true"
+		}
+	}
+	return f
+}
+
+func (f flags) get(name string) string {
+	return f.values[name]
+}
+
+func (f flags) set(name string) bool {
+	_, ok := f.values[name]
+	return ok
+}
+
+// cli runs a subcommand of the fake nvme
+type cli struct {
+	root   string
+	state  State
+	flags  flags
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func (c *cli) fail(code int, format string, args ...interface{}) int {
+	fmt.Fprintf(c.stderr, format+"\n", args...)
+	return code
+}
+
+// save writes the state and updates the fake sysfs
+func (c *cli) save() int {
+	if err := WriteState(c.root, c.state); err != nil {
+		return c.fail(1, "fakenvme: %v", err)
+	}
+	if err := syncSysfs(c.root, c.state, false); err != nil {
+		return c.fail(1, "fakenvme: %v", err)
+	}
+	return 0
+}
+
+func (c *cli) version() int {
+	major, _ := c.state.version()
+	version := c.state.Version
+	if version == "" {
+		version = Version211
+	}
+	fmt.Fprintf(c.stdout, "nvme version %s\n", version)
+	if major >= 2 {
+		fmt.Fprintf(c.stdout, "libnvme version 1.%s\n", strings.TrimPrefix(version, "2."))
+	}
+	return 0
+}
+
+func (c *cli) discover() int {
+	transport := c.flags.get("-t")
+	address := c.flags.get("-a")
+
+	var portal *Portal
+	for i := range c.state.Portals {
+		if c.state.Portals[i].Transport == transport && c.state.Portals[i].Address == address {
+			portal = &c.state.Portals[i]
+			break
+		}
+	}
+	if portal == nil {
+		return c.fail(1, "failed to add controller, error Input/output error")
+	}
+
+	// every port of the target exposing one of the subsystems of the portal is reported
+	type entry struct {
+		portID int
+		portal Portal
+		nqn    string
+	}
+	var entries []entry
+	for _, nqn := range portal.Subsystems {
+		for id, p := range c.state.Portals {
+			if p.Transport != transport {
+				continue
+			}
+			for _, exposed := range p.Subsystems {
+				if exposed == nqn {
+					entries = append(entries, entry{portID: id, portal: p, nqn: nqn})
+				}
+			}
+		}
+	}
+
+	fmt.Fprintf(c.stdout, "\nDiscovery Log Number of Records %d, Generation counter 2\n", len(entries))
+	for i, e := range entries {
+		fmt.Fprintf(c.stdout, "=====Discovery Log Entry %d======\n", i)
+		fmt.Fprintf(c.stdout, "trtype:  %s\n", transport)
+		if transport == "fc" {
+			fmt.Fprintf(c.stdout, "adrfam:  fibre-channel\n")
+		} else {
+			fmt.Fprintf(c.stdout, "adrfam:  ipv4\n")
+		}
+		fmt.Fprintf(c.stdout, "subtype: nvme subsystem\n")
+		fmt.Fprintf(c.stdout, "treq:    not specified\n")
+		fmt.Fprintf(c.stdout, "portid:  %d\n", e.portID)
+		if transport == "fc" {
+			fmt.Fprintf(c.stdout, "trsvcid: none\n")
+		} else {
+			fmt.Fprintf(c.stdout, "trsvcid: %s\n", portalTrSvcID(e.portal))
+		}
+		fmt.Fprintf(c.stdout, "subnqn:  %s\n", e.nqn)
+		fmt.Fprintf(c.stdout, "traddr:  %s\n", e.portal.Address)
+		if transport == "tcp" {
+			fmt.Fprintf(c.stdout, "sectype: none\n")
+		}
+	}
+	return 0
+}
+
+func portalTrSvcID(portal Portal) string {
+	if portal.TrSvcID == "" {
+		return "4420"
+	}
+	return portal.TrSvcID
+}
+
+func (c *cli) connect() int {
+	major, _ := c.state.version()
+	controller := Controller{
+		SubsystemNQN: c.flags.get("-n"),
+		Transport:    c.flags.get("-t"),
+		TrAddr:       c.flags.get("-a"),
+		HostTrAddr:   c.flags.get("-w"),
+		State:        "live",
+	}
+	if controller.Transport == "tcp" {
+		controller.TrSvcID = c.flags.get("-s")
+		if controller.TrSvcID == "" {
+			controller.TrSvcID = "4420"
+		}
+	}
+
+	exposed := false
+	for _, portal := range c.state.Portals {
+		if portal.Transport != controller.Transport || portal.Address != controller.TrAddr {
+			continue
+		}
+		if controller.Transport == "tcp" && portalTrSvcID(portal) != controller.TrSvcID {
+			continue
+		}
+		for _, nqn := range portal.Subsystems {
+			exposed = exposed || nqn == controller.SubsystemNQN
+		}
+	}
+	if !exposed {
+		if major < 2 {
+			return c.fail(1, "Failed to write to /dev/nvme-fabrics: Input/output error")
+		}
+		return c.fail(1, "could not add new controller: failed to write to nvme-fabrics device")
+	}
+
+	if !c.flags.set("-D") {
+		for _, existing := range c.state.Controllers {
+			if existing.SubsystemNQN == controller.SubsystemNQN && existing.Transport == controller.Transport &&
+				existing.TrAddr == controller.TrAddr && existing.TrSvcID == controller.TrSvcID &&
+				existing.HostTrAddr == controller.HostTrAddr {
+				if major < 2 {
+					return c.fail(alreadyConnectedExitCode, "Failed to write to /dev/nvme-fabrics: Operation already in progress")
+				}
+				return c.fail(1, "already connected")
+			}
+		}
+	}
+
+	controller.Name = c.state.nextControllerName()
+	c.state.Controllers = append(c.state.Controllers, controller)
+	return c.save()
+}
+
+func (c *cli) disconnect() int {
+	if nqn := c.flags.get("-n"); nqn != "" {
+		kept := c.state.Controllers[:0]
+		for _, controller := range c.state.Controllers {
+			if controller.SubsystemNQN != nqn {
+				kept = append(kept, controller)
+			}
+		}
+		removed := len(c.state.Controllers) - len(kept)
+		c.state.Controllers = kept
+		if code := c.save(); code != 0 {
+			return code
+		}
+		fmt.Fprintf(c.stdout, "NQN:%s disconnected %d controller(s)\n", nqn, removed)
+		return 0
+	}
+
+	device := c.flags.get("-d")
+	idx, err := c.state.controller(device)
+	if err != nil {
+		return c.fail(1, "Failed to disconnect by device name: %s", device)
+	}
+	c.state.Controllers = append(c.state.Controllers[:idx], c.state.Controllers[idx+1:]...)
+	return c.save()
+}
+
+// connectedSubsystem is a subsystem with at least one controller
+type connectedSubsystem struct {
+	instance    int
+	subsystem   Subsystem
+	controllers []Controller
+}
+
+func (c *cli) connectedSubsystems() []connectedSubsystem {
+	var connected []connectedSubsystem
+	for instance, subsystem := range c.state.Subsystems {
+		var controllers []Controller
+		for _, controller := range c.state.Controllers {
+			if controller.SubsystemNQN == subsystem.NQN {
+				controllers = append(controllers, controller)
+			}
+		}
+		if len(controllers) > 0 {
+			connected = append(connected, connectedSubsystem{instance: instance, subsystem: subsystem, controllers: controllers})
+		}
+	}
+	return connected
+}
+
+func (c *cli) printJSON(v interface{}) int {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return c.fail(1, "fakenvme: %v", err)
+	}
+	fmt.Fprintln(c.stdout, string(data))
+	return 0
+}
+
+// list prints nvme list -o json: the device list before nvme-cli 2.11, the host, subsystem and
+// namespace tree since then and with -v in nvme-cli 2.x, and the subsystem tree with -v in 1.x
+func (c *cli) list() int {
+	major, minor := c.state.version()
+	verbose := c.flags.set("-v")
+	subsystems := c.connectedSubsystems()
+
+	devices := []interface{}{}
+	switch {
+	case verbose && major < 2:
+		for _, s := range subsystems {
+			devices = append(devices, c.listSubsystem(s))
+		}
+	case verbose || major > 2 || (major == 2 && minor >= 11):
+		if len(subsystems) > 0 {
+			host := map[string]interface{}{"HostNQN": c.state.HostNQN, "HostID": c.state.HostID}
+			entries := []interface{}{}
+			for _, s := range subsystems {
+				entries = append(entries, c.listSubsystem(s))
+			}
+			host["Subsystems"] = entries
+			devices = append(devices, host)
+		}
+	default:
+		for _, s := range subsystems {
+			for _, ns := range s.subsystem.Namespaces {
+				device := map[string]interface{}{
+					"NameSpace":    ns.NSID,
+					"DevicePath":   "/dev/" + namespaceDevice(s.instance, ns.NSID),
+					"Firmware":     s.subsystem.Firmware,
+					"Index":        s.instance,
+					"ModelNumber":  s.subsystem.Model,
+					"SerialNumber": s.subsystem.Serial,
+					"UsedBytes":    0,
+					"MaximumLBA":   ns.Size / uint64(ns.blockSize()),
+					"PhysicalSize": ns.Size,
+					"SectorSize":   ns.blockSize(),
+				}
+				if major >= 2 {
+					device["GenericPath"] = fmt.Sprintf("/dev/ng%dn%d", s.instance, ns.NSID)
+				}
+				devices = append(devices, device)
+			}
+		}
+	}
+	return c.printJSON(map[string]interface{}{"Devices": devices})
+}
+
+// listSubsystem returns the nvme list entry of a subsystem, with its controllers and namespace heads
+func (c *cli) listSubsystem(s connectedSubsystem) map[string]interface{} {
+	controllers := []interface{}{}
+	for i, controller := range s.controllers {
+		paths := []interface{}{}
+		for _, ns := range s.subsystem.Namespaces {
+			paths = append(paths, map[string]interface{}{
+				"Path":     fmt.Sprintf("nvme%dc%dn%d", s.instance, controllerNumber(controller.Name), ns.NSID),
+				"ANAState": "optimized",
+			})
+		}
+		controllers = append(controllers, map[string]interface{}{
+			"Controller":   controller.Name,
+			"Cntlid":       i + 1,
+			"SerialNumber": s.subsystem.Serial,
+			"ModelNumber":  s.subsystem.Model,
+			"Firmware":     s.subsystem.Firmware,
+			"Transport":    controller.Transport,
+			"Address":      controller.address(),
+			"Slot":         "",
+			"Namespaces":   []interface{}{},
+			"Paths":        paths,
+		})
+	}
+
+	namespaces := []interface{}{}
+	for _, ns := range s.subsystem.Namespaces {
+		namespaces = append(namespaces, map[string]interface{}{
+			"NameSpace":    namespaceDevice(s.instance, ns.NSID),
+			"Generic":      fmt.Sprintf("ng%dn%d", s.instance, ns.NSID),
+			"NSID":         ns.NSID,
+			"UsedBytes":    0,
+			"MaximumLBA":   ns.Size / uint64(ns.blockSize()),
+			"PhysicalSize": ns.Size,
+			"SectorSize":   ns.blockSize(),
+		})
+	}
+	return map[string]interface{}{
+		"Subsystem":    fmt.Sprintf("nvme-subsys%d", s.instance),
+		"SubsystemNQN": s.subsystem.NQN,
+		"Controllers":  controllers,
+		"Namespaces":   namespaces,
+	}
+}
+
+func controllerNumber(name string) int {
+	n, _ := strconv.Atoi(strings.TrimPrefix(name, "nvme"))
+	return n
+}
+
+// listSubsys prints nvme list-subsys -o json: a single object in nvme-cli 1.x, a list of hosts in 2.x
+func (c *cli) listSubsys() int {
+	major, _ := c.state.version()
+	subsystems := c.connectedSubsystems()
+	if major < 2 && len(subsystems) == 0 {
+		return noObjsFoundExitCode
+	}
+
+	entries := []interface{}{}
+	for _, s := range subsystems {
+		paths := []interface{}{}
+		for _, controller := range s.controllers {
+			address := controller.address()
+			if controller.Transport == "fc" {
+				address = fmt.Sprintf("traddr=%s host_traddr=%s", controller.TrAddr, controller.HostTrAddr)
+			}
+			paths = append(paths, map[string]string{
+				"Name":      controller.Name,
+				"Transport": controller.Transport,
+				"Address":   address,
+				"State":     controller.State,
+			})
+		}
+		entry := map[string]interface{}{
+			"Name":  fmt.Sprintf("nvme-subsys%d", s.instance),
+			"NQN":   s.subsystem.NQN,
+			"Paths": paths,
+		}
+		if major >= 2 {
+			entry["IOPolicy"] = "numa"
+		}
+		entries = append(entries, entry)
+	}
+
+	if major < 2 {
+		return c.printJSON(map[string]interface{}{"Subsystems": entries})
+	}
+	return c.printJSON([]interface{}{map[string]interface{}{
+		"HostNQN":    c.state.HostNQN,
+		"HostID":     c.state.HostID,
+		"Subsystems": entries,
+	}})
+}
+
+// controllerSubsystem returns the subsystem of the controller device, /dev/nvme0
+func (c *cli) controllerSubsystem(device string) (*Subsystem, bool) {
+	idx, err := c.state.controller(device)
+	if err != nil {
+		return nil, false
+	}
+	_, subsystem, err := c.state.subsystem(c.state.Controllers[idx].SubsystemNQN)
+	return subsystem, err == nil
+}
+
+// listNS prints the namespaces of a controller, as JSON from nvme-cli 2.x only
+func (c *cli) listNS() int {
+	if len(c.flags.positionals) == 0 {
+		return c.fail(1, "list-ns: device required")
+	}
+	device := c.flags.positionals[0]
+	subsystem, ok := c.controllerSubsystem(device)
+	if !ok {
+		return c.fail(1, "Failed to open %s: No such file or directory", device)
+	}
+	start, _ := strconv.ParseUint(c.flags.get("-n"), 0, 32)
+	major, _ := c.state.version()
+	if major < 2 && c.flags.set("-o") {
+		return c.fail(1, "list-ns: unrecognized option '--output-format'")
+	}
+
+	var nsids []uint32
+	for _, ns := range subsystem.Namespaces {
+		if uint64(ns.NSID) > start {
+			nsids = append(nsids, ns.NSID)
+		}
+	}
+
+	if major < 2 {
+		for i, nsid := range nsids {
+			fmt.Fprintf(c.stdout, "[%4d]:%#x\n", i, nsid)
+		}
+		return 0
+	}
+	list := []interface{}{}
+	for _, nsid := range nsids {
+		list = append(list, map[string]uint32{"nsid": nsid})
+	}
+	return c.printJSON(map[string]interface{}{"nsid_list": list})
+}
+
+// namespace returns the connected namespace of the namespace device, /dev/nvme0n1
+func (c *cli) namespace(device string) (Namespace, bool) {
+	match := namespaceDeviceRegexp.FindStringSubmatch(filepath.Base(device))
+	if match == nil {
+		return Namespace{}, false
+	}
+	instance, _ := strconv.Atoi(match[1])
+	nsid, _ := strconv.ParseUint(match[2], 10, 32)
+	if instance >= len(c.state.Subsystems) || !c.state.connected(c.state.Subsystems[instance].NQN) {
+		return Namespace{}, false
+	}
+	for _, ns := range c.state.Subsystems[instance].Namespaces {
+		if uint64(ns.NSID) == nsid {
+			return ns, true
+		}
+	}
+	return Namespace{}, false
+}
+
+// idNS prints the Identify Namespace data of a namespace device
+func (c *cli) idNS() int {
+	if len(c.flags.positionals) == 0 {
+		return c.fail(1, "id-ns: device required")
+	}
+	device := c.flags.positionals[0]
+	ns, ok := c.namespace(device)
+	if !ok {
+		return c.fail(1, "Failed to open %s: No such file or directory", device)
+	}
+
+	blocks := ns.Size / uint64(ns.blockSize())
+	fmt.Fprintf(c.stdout, "NVME Identify Namespace %d:\n", ns.NSID)
+	fmt.Fprintf(c.stdout, "nsze    : %#x\n", blocks)
+	fmt.Fprintf(c.stdout, "ncap    : %#x\n", blocks)
+	fmt.Fprintf(c.stdout, "nuse    : %#x\n", 0)
+	fmt.Fprintf(c.stdout, "nsfeat  : 0xb\n")
+	fmt.Fprintf(c.stdout, "nlbaf   : 0\n")
+	fmt.Fprintf(c.stdout, "flbas   : 0\n")
+	fmt.Fprintf(c.stdout, "nmic    : 0x1\n")
+	fmt.Fprintf(c.stdout, "anagrpid: 1\n")
+	fmt.Fprintf(c.stdout, "nguid   : %s\n", ns.NGUID)
+	fmt.Fprintf(c.stdout, "eui64   : 0000000000000000\n")
+	fmt.Fprintf(c.stdout, "lbaf  0 : ms:0   lbads:%d  rp:0 (in use)\n", bits.TrailingZeros32(ns.blockSize()))
+	return 0
+}
+
+// nsRescan makes the block devices of the controller subsystem report the sizes of the state
+func (c *cli) nsRescan() int {
+	if len(c.flags.positionals) == 0 {
+		return c.fail(1, "ns-rescan: device required")
+	}
+	device := c.flags.positionals[0]
+	if _, ok := c.controllerSubsystem(device); !ok {
+		return c.fail(1, "Failed to open %s: No such file or directory", device)
+	}
+	if err := syncSysfs(c.root, c.state, true); err != nil {
+		return c.fail(1, "fakenvme: %v", err)
+	}
+	return 0
+}
+
+func (c *cli) reset() int {
+	if len(c.flags.positionals) == 0 {
+		return c.fail(1, "reset: device required")
+	}
+	device := c.flags.positionals[0]
+	if _, err := c.state.controller(device); err != nil {
+		return c.fail(1, "Failed to open %s: No such file or directory", device)
+	}
+	return 0
+}
@@ -0,0 +1,149 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package testing
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fakeNQN = "nqn.1988-11.com.dell:powerstore:00:e6e2d5b871f1403E169D0"
+
+// runFake runs the fake nvme in process against the root
+func runFake(t *testing.T, root string, args ...string) (string, string, int) {
+	t.Setenv(RootEnv, root)
+	var stdout, stderr bytes.Buffer
+	code := Main(append([]string{"nvme"}, args...), &stdout, &stderr)
+	return stdout.String(), stderr.String(), code
+}
+
+func fakeRoot(t *testing.T, version string) string {
+	root := t.TempDir()
+	state := State{
+		Version: version,
+		HostNQN: "nqn.2014-08.org.nvmexpress:uuid:host",
+		Subsystems: []Subsystem{
+			{NQN: fakeNQN, Namespaces: []Namespace{{NSID: 1, Size: 1 << 30}, {NSID: 3, Size: 1 << 30}}},
+		},
+		Portals: []Portal{{Transport: "tcp", Address: "10.0.0.1", Subsystems: []string{fakeNQN}}},
+		Controllers: []Controller{
+			{Name: "nvme0", SubsystemNQN: fakeNQN, Transport: "tcp", TrAddr: "10.0.0.1", TrSvcID: "4420", State: "live"},
+		},
+	}
+	require.NoError(t, WriteState(root, state))
+	require.NoError(t, syncSysfs(root, state, true))
+	return root
+}
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		version    string
+		list       string
+		listSubsys string
+		listNS     string
+	}{
+		{Version1, `"DevicePath": "/dev/nvme0n1"`, `"Subsystems": [`, "[   1]:0x3"},
+		{Version2, `"GenericPath": "/dev/ng0n1"`, `"HostNQN": "nqn.2014-08.org.nvmexpress:uuid:host"`, `"nsid": 3`},
+		{Version211, `"NameSpace": "nvme0n1"`, `"IOPolicy": "numa"`, `"nsid": 3`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			root := fakeRoot(t, tt.version)
+
+			out, _, code := runFake(t, root, "list", "-o", "json")
+			assert.Equal(t, 0, code)
+			assert.Contains(t, out, tt.list)
+
+			out, _, code = runFake(t, root, "list-subsys", "-o", "json")
+			assert.Equal(t, 0, code)
+			assert.Contains(t, out, tt.listSubsys)
+			assert.Contains(t, out, `"Address": "traddr=10.0.0.1,trsvcid=4420"`)
+
+			out, _, code = runFake(t, root, "list-ns", "/dev/nvme0", "-o", "json")
+			if tt.version == Version1 {
+				// nvme-cli 1.x list-ns has no output format
+				assert.Equal(t, 1, code)
+				out, _, code = runFake(t, root, "list-ns", "/dev/nvme0")
+			}
+			assert.Equal(t, 0, code)
+			assert.Contains(t, out, tt.listNS)
+
+			out, _, code = runFake(t, root, "version")
+			assert.Equal(t, 0, code)
+			assert.True(t, strings.HasPrefix(out, "nvme version "+tt.version))
+		})
+	}
+}
+
+func TestConnectDuplicate(t *testing.T) {
+	connect := []string{"connect", "-t", "tcp", "-n", fakeNQN, "-a", "10.0.0.1", "-s", "4420", "--ctrl-loss-tmo=-1"}
+
+	root := fakeRoot(t, Version1)
+	_, stderr, code := runFake(t, root, connect...)
+	assert.Equal(t, alreadyConnectedExitCode, code)
+	assert.Equal(t, "Failed to write to /dev/nvme-fabrics: Operation already in progress\n", stderr)
+
+	root = fakeRoot(t, Version211)
+	_, stderr, code = runFake(t, root, connect...)
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "already connected")
+
+	_, _, code = runFake(t, root, append(connect, "-D")...)
+	assert.Equal(t, 0, code)
+	state, err := ReadState(root)
+	require.NoError(t, err)
+	require.Len(t, state.Controllers, 2)
+	assert.Equal(t, "nvme1", state.Controllers[1].Name)
+	assert.FileExists(t, root+"/sys/class/nvme-subsystem/nvme-subsys0/nvme1/state")
+}
+
+func TestSyncSysfsKeepsWrites(t *testing.T) {
+	root := fakeRoot(t, Version211)
+	require.NoError(t, os.WriteFile(root+"/sys/class/nvme/nvme0/ctrl_loss_tmo", []byte("600\n"), 0o600))
+	require.NoError(t, os.WriteFile(root+"/sys/block/nvme0n1/queue/nr_requests", []byte("64\n"), 0o600))
+
+	state, err := ReadState(root)
+	require.NoError(t, err)
+	state.Subsystems[0].Namespaces = state.Subsystems[0].Namespaces[:1]
+	require.NoError(t, syncSysfs(root, state, false))
+
+	data, err := os.ReadFile(root + "/sys/class/nvme/nvme0/ctrl_loss_tmo")
+	require.NoError(t, err)
+	assert.Equal(t, "600\n", string(data))
+	data, err = os.ReadFile(root + "/sys/block/nvme0n1/queue/nr_requests")
+	require.NoError(t, err)
+	assert.Equal(t, "64\n", string(data))
+	assert.NoDirExists(t, root+"/sys/block/nvme0n3")
+	assert.NoFileExists(t, root+"/dev/nvme0n3")
+}
+
+func TestStateValidation(t *testing.T) {
+	root := t.TempDir()
+	assert.Error(t, WriteState(root, State{Version: "two"}))
+	assert.Error(t, WriteState(root, State{Subsystems: []Subsystem{{NQN: "a"}, {NQN: "a"}}}))
+	assert.Error(t, WriteState(root, State{Portals: []Portal{{Transport: "rdma", Address: "10.0.0.1"}}}))
+	assert.Error(t, WriteState(root, State{Portals: []Portal{{Transport: "tcp", Address: "10.0.0.1", Subsystems: []string{"a"}}}}))
+
+	_, stderr, code := runFake(t, root, "list")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, stderr, "fakenvme")
+}
@@ -0,0 +1,134 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package testing
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	gotesting "testing"
+
+	"github.com/dell/gonvme"
+)
+
+// BinaryEnv is the environment variable holding the path of a prebuilt fake nvme,
+// go build github.com/dell/gonvme/testing/cmd/fakenvme, built on first use otherwise
+const BinaryEnv = "FAKENVME_BINARY"
+
+var build struct {
+	once sync.Once
+	path string
+	err  error
+}
+
+// Harness is a fake root holding the fake nvme, its state and the fake /sys, /dev and /etc/nvme trees.
+// The gonvme.NVMe created with Options runs the fake nvme through a fake chroot placed first in PATH,
+// so a Harness cannot be used by parallel tests.
+type Harness struct {
+	t    gotesting.TB
+	root string
+}
+
+// NewHarness returns a harness whose fake nvme starts from the state
+func NewHarness(t gotesting.TB, state State) *Harness {
+	t.Helper()
+	binary, err := fakeBinary()
+	if err != nil {
+		t.Fatalf("building the fake nvme: %v", err)
+	}
+
+	h := &Harness{t: t, root: t.TempDir()}
+	bin := t.TempDir()
+	for _, link := range []string{filepath.Join(h.root, "usr", "sbin", "nvme"), filepath.Join(bin, "chroot")} {
+		if err := os.MkdirAll(filepath.Dir(link), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(binary, link); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	h.SetState(state)
+	return h
+}
+
+// fakeBinary returns the path of the fake nvme, building it once per test binary
+func fakeBinary() (string, error) {
+	if path := os.Getenv(BinaryEnv); path != "" {
+		return path, nil
+	}
+	build.once.Do(func() {
+		dir, err := os.MkdirTemp("", "fakenvme")
+		if err != nil {
+			build.err = err
+			return
+		}
+		path := filepath.Join(dir, "nvme")
+		output, err := exec.Command("go", "build", "-o", path, "github.com/dell/gonvme/testing/cmd/fakenvme").CombinedOutput() // #nosec G204
+		if err != nil {
+			build.err = fmt.Errorf("%w: %s", err, output)
+			return
+		}
+		build.path = path
+	})
+	return build.path, build.err
+}
+
+// Root returns the fake root directory
+func (h *Harness) Root() string {
+	return h.root
+}
+
+// Options returns the options of a gonvme.NVMe using the fake root, see gonvme.NewNVMe
+func (h *Harness) Options() map[string]string {
+	return map[string]string{gonvme.ChrootDirectory: h.root}
+}
+
+// State returns the current state of the fake nvme
+func (h *Harness) State() State {
+	h.t.Helper()
+	state, err := ReadState(h.root)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return state
+}
+
+// SetState replaces the state of the fake nvme. New namespaces and controllers appear in the fake sysfs
+// right away, while a namespace keeps its former size there until its controller is rescanned.
+func (h *Harness) SetState(state State) {
+	h.t.Helper()
+	unlock, err := lock(h.root)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	defer unlock()
+	if err := WriteState(h.root, state); err != nil {
+		h.t.Fatal(err)
+	}
+	if err := syncSysfs(h.root, state, false); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// Path returns the path of a file of the fake root, /sys/block/nvme0n1/size for instance
+func (h *Harness) Path(name string) string {
+	return filepath.Join(h.root, name)
+}
@@ -0,0 +1,202 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package testing_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dell/gonvme"
+	nvmetesting "github.com/dell/gonvme/testing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testNQN     = "nqn.1988-11.com.dell:powerstore:00:e6e2d5b871f1403E169D0"
+	testHostNQN = "nqn.2014-08.org.nvmexpress:uuid:3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	testHostID  = "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+)
+
+var testVersions = []string{nvmetesting.Version1, nvmetesting.Version2, nvmetesting.Version211}
+
+func testState(version string) nvmetesting.State {
+	return nvmetesting.State{
+		Version: version,
+		HostNQN: testHostNQN,
+		HostID:  testHostID,
+		Subsystems: []nvmetesting.Subsystem{
+			{
+				NQN:      testNQN,
+				Model:    "dellemc-powerstore",
+				Serial:   "FP08RZ2",
+				Firmware: "4.0.0.0",
+				Namespaces: []nvmetesting.Namespace{
+					{NSID: 1, NGUID: "507911ecda65a2498ccf0968009a5d07", Size: 8 << 30},
+					{NSID: 2, NGUID: "507911ecda65a2498ccf0968009a5d08", Size: 16 << 30, BlockSize: 4096},
+				},
+			},
+		},
+		Portals: []nvmetesting.Portal{
+			{Transport: "tcp", Address: "10.0.0.1", Subsystems: []string{testNQN}},
+			{Transport: "tcp", Address: "10.0.0.2", Subsystems: []string{testNQN}},
+		},
+	}
+}
+
+func TestEndToEnd(t *testing.T) {
+	for _, version := range testVersions {
+		t.Run(version, func(t *testing.T) {
+			h := nvmetesting.NewHarness(t, testState(version))
+			nvme := gonvme.NewNVMe(h.Options())
+			require.Equal(t, "/usr/sbin/nvme", nvme.NVMeCommand)
+			require.NoError(t, nvme.Available())
+			cliVersion, err := nvme.CLIVersion()
+			require.NoError(t, err)
+			assert.Equal(t, version, cliVersion.String())
+
+			targets, err := nvme.DiscoverNVMeTCPTargets("10.0.0.1", false)
+			require.NoError(t, err)
+			require.Len(t, targets, 2)
+			assert.Equal(t, testNQN, targets[0].TargetNqn)
+			assert.Equal(t, "10.0.0.1", targets[0].Portal)
+			assert.Equal(t, "10.0.0.2", targets[1].Portal)
+
+			sessions, err := nvme.GetSessions()
+			require.NoError(t, err)
+			assert.Empty(t, sessions)
+
+			for _, target := range targets {
+				require.NoError(t, nvme.NVMeTCPConnect(target, false))
+			}
+			// connecting again is not a failure, whatever the nvme-cli version
+			require.NoError(t, nvme.NVMeTCPConnect(targets[0], false))
+
+			sessions, err = nvme.GetSessions()
+			require.NoError(t, err)
+			require.Len(t, sessions, 2)
+			assert.Equal(t, "nvme0", sessions[0].Name)
+			assert.Equal(t, "10.0.0.1:4420", sessions[0].Portal)
+			assert.Equal(t, gonvme.NVMESessionStateLive, sessions[0].NVMESessionState)
+
+			devices, err := nvme.ListNVMeDeviceAndNamespace()
+			require.NoError(t, err)
+			assert.Equal(t, []gonvme.DevicePathAndNamespace{
+				{DevicePath: "/dev/nvme0n1", Namespace: "1"},
+				{DevicePath: "/dev/nvme0n2", Namespace: "2"},
+			}, devices)
+
+			inventory, err := nvme.GetInventory()
+			require.NoError(t, err)
+			require.Len(t, inventory.AllNamespaces(), 2)
+
+			nguid, namespace, err := nvme.GetNVMeDeviceData("/dev/nvme0n2")
+			require.NoError(t, err)
+			assert.Equal(t, "507911ecda65a2498ccf0968009a5d08", nguid)
+			assert.Equal(t, "2", namespace)
+
+			namespaces, err := nvme.ListNamespaces([]string{"nvme0", "nvme1"}, gonvme.ListNamespacesOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, []uint32{1, 2}, namespaces[1].NSIDs)
+
+			controller, err := nvme.GetController("nvme1")
+			require.NoError(t, err)
+			assert.Equal(t, testNQN, controller.SubsysNQN)
+			assert.Equal(t, "10.0.0.2", controller.TrAddr)
+
+			require.NoError(t, nvme.DisconnectController("nvme1"))
+			require.NoError(t, nvme.NVMeDisconnect(targets[0]))
+			sessions, err = nvme.GetSessions()
+			require.NoError(t, err)
+			assert.Empty(t, sessions)
+			_, err = os.Stat(h.Path("/sys/class/nvme/nvme0"))
+			assert.ErrorIs(t, err, os.ErrNotExist)
+		})
+	}
+}
+
+func TestRefreshNamespaceCapacity(t *testing.T) {
+	state := testState(nvmetesting.Version211)
+	state.Controllers = []nvmetesting.Controller{
+		{Name: "nvme0", SubsystemNQN: testNQN, Transport: "tcp", TrAddr: "10.0.0.1", TrSvcID: "4420", State: "live"},
+		{Name: "nvme1", SubsystemNQN: testNQN, Transport: "tcp", TrAddr: "10.0.0.2", TrSvcID: "4420", State: "live"},
+	}
+	h := nvmetesting.NewHarness(t, state)
+	nvme := gonvme.NewNVMe(h.Options())
+
+	// the array grows the volume, the host only sees it after a rescan
+	state.Subsystems[0].Namespaces[0].Size = 12 << 30
+	h.SetState(state)
+	size, err := os.ReadFile(h.Path("/sys/block/nvme0n1/size"))
+	require.NoError(t, err)
+	assert.Equal(t, "16777216", strings.TrimSpace(string(size)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	capacity, err := nvme.RefreshNamespaceCapacity(ctx, "/dev/nvme0n1")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(8<<30), capacity.OldSize)
+	assert.Equal(t, uint64(12<<30), capacity.NewSize)
+	assert.Equal(t, uint64(12<<30), capacity.IdentifySize)
+}
+
+func TestFailures(t *testing.T) {
+	state := testState(nvmetesting.Version2)
+	state.Failures = map[string]nvmetesting.Failure{
+		"connect": {ExitCode: 5, Stderr: "could not add new controller: failed to write to nvme-fabrics device"},
+	}
+	h := nvmetesting.NewHarness(t, state)
+	nvme := gonvme.NewNVMe(h.Options())
+
+	err := nvme.NVMeTCPConnect(gonvme.NVMeTarget{TargetNqn: testNQN, Portal: "10.0.0.1"}, false)
+	assert.ErrorContains(t, err, "failed to write to nvme-fabrics device")
+
+	// a subsystem which is not exposed by the portal cannot be connected either
+	state.Failures = nil
+	h.SetState(state)
+	err = nvme.NVMeTCPConnect(gonvme.NVMeTarget{TargetNqn: "nqn.unknown", Portal: "10.0.0.1"}, false)
+	assert.Error(t, err)
+	_, err = nvme.DiscoverNVMeTCPTargets("10.0.0.9", false)
+	assert.Error(t, err)
+	assert.Empty(t, h.State().Controllers)
+}
+
+func TestNVMeFCConnect(t *testing.T) {
+	state := testState(nvmetesting.Version211)
+	state.Portals = []nvmetesting.Portal{
+		{Transport: "fc", Address: "nn-0x58ccf09800000001:pn-0x58ccf09800000002", Subsystems: []string{testNQN}},
+	}
+	h := nvmetesting.NewHarness(t, state)
+	nvme := gonvme.NewNVMe(h.Options())
+
+	target := gonvme.NVMeTarget{
+		TargetNqn: testNQN,
+		Portal:    "nn-0x58ccf09800000001:pn-0x58ccf09800000002",
+		HostAdr:   "nn-0x20000090fa000001:pn-0x10000090fa000001",
+	}
+	require.NoError(t, nvme.NVMeFCConnect(target, false))
+
+	sessions, err := nvme.GetSessions()
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, gonvme.NVMETransportNameFC, sessions[0].NVMETransportName)
+	assert.Equal(t, target.Portal, sessions[0].Portal)
+	assert.Equal(t, target.HostAdr, sessions[0].HostAddress)
+}
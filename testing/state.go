@@ -0,0 +1,272 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// Package testing provides a fake nvme-cli executable and a fake /sys, /dev and /etc/nvme tree,
+// so that the real gonvme.NVMe can be exercised end-to-end without NVMe hardware.
+// See NewHarness.
+package testing
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// StatePath is the state file of the fake nvme, relative to the fake root
+const StatePath = "/var/lib/fakenvme/state.json"
+
+// nvme-cli versions whose output formats the fake nvme emulates
+const (
+	// Version1 is nvme-cli 1.x: list-subsys prints a single object, list-ns a plain list
+	Version1 = "1.16"
+	// Version2 is nvme-cli 2.0 to 2.10: nvme list prints the legacy device list
+	Version2 = "2.8"
+	// Version211 is nvme-cli 2.11 and later: nvme list prints hosts, subsystems and namespaces
+	Version211 = "2.11"
+)
+
+// State is the host and storage seen by the fake nvme, stored as JSON in StatePath
+type State struct {
+	// Version is the emulated nvme-cli version, Version211 when empty
+	Version string `json:"version"`
+	HostNQN string `json:"hostNQN"`
+	HostID  string `json:"hostID"`
+	// Subsystems are exposed by the targets; the index of a subsystem is its instance, N of /dev/nvmeNn1
+	Subsystems []Subsystem `json:"subsystems"`
+	// Portals are the discovery and connection addresses of the targets
+	Portals []Portal `json:"portals"`
+	// Controllers are the connections of the host, added by nvme connect
+	Controllers []Controller `json:"controllers"`
+	// Failures make a subcommand, "connect" for instance, fail
+	Failures map[string]Failure `json:"failures,omitempty"`
+}
+
+// Subsystem is an NVMe subsystem of a target
+type Subsystem struct {
+	NQN        string      `json:"nqn"`
+	Model      string      `json:"model"`
+	Serial     string      `json:"serial"`
+	Firmware   string      `json:"firmware"`
+	Namespaces []Namespace `json:"namespaces"`
+}
+
+// Namespace is a namespace of a subsystem
+type Namespace struct {
+	NSID  uint32 `json:"nsid"`
+	NGUID string `json:"nguid"`
+	// Size is in bytes; a rescan is needed for the block device to see a new size
+	Size uint64 `json:"size"`
+	// BlockSize is 512 when zero
+	BlockSize uint32 `json:"blockSize,omitempty"`
+}
+
+// Portal is a target port exposing subsystems
+type Portal struct {
+	// Transport is tcp or fc
+	Transport string `json:"transport"`
+	// Address is an IP address for tcp and nn-0x...:pn-0x... for fc
+	Address string `json:"address"`
+	// TrSvcID is the TCP port, 4420 when empty
+	TrSvcID    string   `json:"trsvcid,omitempty"`
+	Subsystems []string `json:"subsystems"`
+}
+
+// Controller is a connection of the host to a subsystem through a portal
+type Controller struct {
+	Name         string `json:"name"`
+	SubsystemNQN string `json:"subsystemNQN"`
+	Transport    string `json:"transport"`
+	TrAddr       string `json:"traddr"`
+	TrSvcID      string `json:"trsvcid,omitempty"`
+	HostTrAddr   string `json:"hostTraddr,omitempty"`
+	State        string `json:"state"`
+}
+
+// Failure is the exit code and the standard error of a failing subcommand
+type Failure struct {
+	ExitCode int    `json:"exitCode"`
+	Stderr   string `json:"stderr"`
+}
+
+// ReadState reads the state of the fake nvme of the root
+func ReadState(root string) (State, error) {
+	data, err := os.ReadFile(filepath.Clean(filepath.Join(root, StatePath)))
+	if err != nil {
+		return State{}, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return State{}, fmt.Errorf("invalid fake nvme state: %w", err)
+	}
+	return state, nil
+}
+
+// WriteState writes the state of the fake nvme of the root
+func WriteState(root string, state State) error {
+	if err := state.validate(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(root, StatePath)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+func (s State) validate() error {
+	switch s.Version {
+	case "", Version1, Version2, Version211:
+	default:
+		if _, _, err := parseVersion(s.Version); err != nil {
+			return err
+		}
+	}
+	seen := map[string]bool{}
+	for _, subsystem := range s.Subsystems {
+		if subsystem.NQN == "" || seen[subsystem.NQN] {
+			return fmt.Errorf("missing or duplicate subsystem NQN %q", subsystem.NQN)
+		}
+		seen[subsystem.NQN] = true
+	}
+	for _, portal := range s.Portals {
+		if portal.Transport != "tcp" && portal.Transport != "fc" {
+			return fmt.Errorf("portal %s: unsupported transport %q", portal.Address, portal.Transport)
+		}
+		for _, nqn := range portal.Subsystems {
+			if !seen[nqn] {
+				return fmt.Errorf("portal %s exposes unknown subsystem %s", portal.Address, nqn)
+			}
+		}
+	}
+	for _, controller := range s.Controllers {
+		if !seen[controller.SubsystemNQN] {
+			return fmt.Errorf("controller %s connected to unknown subsystem %s", controller.Name, controller.SubsystemNQN)
+		}
+	}
+	return nil
+}
+
+// version returns the major and minor of the emulated nvme-cli version
+func (s State) version() (int, int) {
+	if s.Version == "" {
+		return parseVersionOrDefault(Version211)
+	}
+	return parseVersionOrDefault(s.Version)
+}
+
+func parseVersionOrDefault(version string) (int, int) {
+	major, minor, err := parseVersion(version)
+	if err != nil {
+		return 2, 11
+	}
+	return major, minor
+}
+
+func parseVersion(version string) (int, int, error) {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("invalid nvme-cli version %q", version)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid nvme-cli version %q", version)
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid nvme-cli version %q", version)
+	}
+	return major, minor, nil
+}
+
+// subsystem returns the instance and the subsystem of the NQN
+func (s State) subsystem(nqn string) (int, *Subsystem, error) {
+	for i := range s.Subsystems {
+		if s.Subsystems[i].NQN == nqn {
+			return i, &s.Subsystems[i], nil
+		}
+	}
+	return 0, nil, fmt.Errorf("unknown subsystem %s", nqn)
+}
+
+// controller returns the index of the controller, given as nvme0 or /dev/nvme0
+func (s State) controller(name string) (int, error) {
+	name = filepath.Base(name)
+	for i, controller := range s.Controllers {
+		if controller.Name == name {
+			return i, nil
+		}
+	}
+	return 0, errors.New("no such controller " + name)
+}
+
+// nextControllerName returns the lowest free nvmeN
+func (s State) nextControllerName() string {
+	used := map[string]bool{}
+	for _, controller := range s.Controllers {
+		used[controller.Name] = true
+	}
+	for i := 0; ; i++ {
+		if name := fmt.Sprintf("nvme%d", i); !used[name] {
+			return name
+		}
+	}
+}
+
+// connected reports whether the subsystem has at least one controller
+func (s State) connected(nqn string) bool {
+	for _, controller := range s.Controllers {
+		if controller.SubsystemNQN == nqn {
+			return true
+		}
+	}
+	return false
+}
+
+func (n Namespace) blockSize() uint32 {
+	if n.BlockSize == 0 {
+		return 512
+	}
+	return n.BlockSize
+}
+
+// address returns the sysfs address of the controller
+func (c Controller) address() string {
+	if c.Transport == "fc" {
+		return fmt.Sprintf("traddr=%s,host_traddr=%s", c.TrAddr, c.HostTrAddr)
+	}
+	address := fmt.Sprintf("traddr=%s,trsvcid=%s", c.TrAddr, c.TrSvcID)
+	if c.HostTrAddr != "" {
+		address += ",src_addr=" + c.HostTrAddr
+	}
+	return address
+}
+
+// namespaceDevice returns the name of the multipath namespace device, nvme0n1
+func namespaceDevice(instance int, nsid uint32) string {
+	return fmt.Sprintf("nvme%dn%d", instance, nsid)
+}
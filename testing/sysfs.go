@@ -0,0 +1,212 @@
+/*
+ *
+ * Copyright © 2025 Dell Inc. or its subsidiaries. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *      http://www.apache.org/licenses/LICENSE-2.0
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package testing
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+)
+
+// the major of the fake namespace block devices, from the range reserved for local use
+// so that they never match a mount of the host
+const blockMajor = 240
+
+var (
+	controllerNameRegexp = regexp.MustCompile(`^nvme[0-9]+$`)
+	subsystemNameRegexp  = regexp.MustCompile(`^nvme-subsys[0-9]+$`)
+)
+
+// syncSysfs makes the fake /sys, /dev and /etc/nvme trees of the root match the state.
+// Entries are added and removed rather than rewritten, so attributes written by the code
+// under test survive; the sizes of existing block devices are only updated when resize is set.
+func syncSysfs(root string, state State, resize bool) error {
+	if err := writeFile(root, "/etc/nvme/hostnqn", state.HostNQN+"\n", true); err != nil {
+		return err
+	}
+	if err := writeFile(root, "/etc/nvme/hostid", state.HostID+"\n", true); err != nil {
+		return err
+	}
+	if err := writeFile(root, "/dev/nvme-fabrics", "", false); err != nil {
+		return err
+	}
+
+	controllers := map[string]bool{}
+	subsystems := map[string]bool{}
+	namespaces := map[string]bool{}
+
+	for _, controller := range state.Controllers {
+		controllers[controller.Name] = true
+		instance, subsystem, err := state.subsystem(controller.SubsystemNQN)
+		if err != nil {
+			return err
+		}
+		if err := syncController(root, controller, subsystem, cntlid(state, controller)); err != nil {
+			return err
+		}
+		if err := writeFile(root, "/dev/"+controller.Name, "", false); err != nil {
+			return err
+		}
+
+		subsystemDir := fmt.Sprintf("/sys/class/nvme-subsystem/nvme-subsys%d", instance)
+		subsystems[filepath.Base(subsystemDir)] = true
+		if err := writeFile(root, subsystemDir+"/subsysnqn", subsystem.NQN+"\n", true); err != nil {
+			return err
+		}
+		link := filepath.Join(root, subsystemDir, controller.Name)
+		if _, err := os.Lstat(link); errors.Is(err, os.ErrNotExist) {
+			if err := os.Symlink(filepath.Join("..", "..", "nvme", controller.Name), link); err != nil {
+				return err
+			}
+		}
+
+		for _, ns := range subsystem.Namespaces {
+			name := namespaceDevice(instance, ns.NSID)
+			namespaces[name] = true
+			if err := os.MkdirAll(filepath.Join(root, subsystemDir, name), 0o755); err != nil {
+				return err
+			}
+			if err := syncNamespace(root, name, instance, ns, resize); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := removeStale(filepath.Join(root, "/sys/class/nvme"), controllerNameRegexp, controllers); err != nil {
+		return err
+	}
+	if err := removeStale(filepath.Join(root, "/dev"), controllerNameRegexp, controllers); err != nil {
+		return err
+	}
+	if err := removeStale(filepath.Join(root, "/sys/class/nvme-subsystem"), subsystemNameRegexp, subsystems); err != nil {
+		return err
+	}
+	for name := range subsystems {
+		dir := filepath.Join(root, "/sys/class/nvme-subsystem", name)
+		if err := removeStale(dir, controllerNameRegexp, controllers); err != nil {
+			return err
+		}
+		if err := removeStale(dir, namespaceDeviceRegexp, namespaces); err != nil {
+			return err
+		}
+	}
+	if err := removeStale(filepath.Join(root, "/sys/block"), namespaceDeviceRegexp, namespaces); err != nil {
+		return err
+	}
+	return removeStale(filepath.Join(root, "/dev"), namespaceDeviceRegexp, namespaces)
+}
+
+// cntlid returns the controller ID of the controller, its rank among the controllers of its subsystem
+func cntlid(state State, controller Controller) int {
+	id := 0
+	for _, c := range state.Controllers {
+		if c.SubsystemNQN == controller.SubsystemNQN {
+			id++
+		}
+		if c.Name == controller.Name {
+			break
+		}
+	}
+	return id
+}
+
+// syncController adds the /sys/class/nvme directory of the controller
+func syncController(root string, controller Controller, subsystem *Subsystem, id int) error {
+	dir := "/sys/class/nvme/" + controller.Name + "/"
+	attributes := map[string]string{
+		"transport":         controller.Transport,
+		"address":           controller.address(),
+		"subsysnqn":         subsystem.NQN,
+		"model":             subsystem.Model,
+		"serial":            subsystem.Serial,
+		"firmware_rev":      subsystem.Firmware,
+		"cntlid":            strconv.Itoa(id),
+		"queue_count":       "9",
+		"sqsize":            "127",
+		"kato":              "5",
+		"reconnect_delay":   "10",
+		"ctrl_loss_tmo":     "off",
+		"fast_io_fail_tmo":  "off",
+		"numa_node":         "-1",
+		"reset_controller":  "",
+		"delete_controller": "",
+	}
+	for attr, value := range attributes {
+		if err := writeFile(root, dir+attr, value+"\n", false); err != nil {
+			return err
+		}
+	}
+	return writeFile(root, dir+"state", controller.State+"\n", true)
+}
+
+// syncNamespace adds the /sys/block and /dev entries of the namespace device
+func syncNamespace(root string, name string, instance int, ns Namespace, resize bool) error {
+	dir := "/sys/block/" + name + "/"
+	attributes := map[string]string{
+		"dev":                 fmt.Sprintf("%d:%d", blockMajor, instance<<8|int(ns.NSID)),
+		"queue/io_timeout":    "30000",
+		"queue/nr_requests":   "128",
+		"queue/scheduler":     "[none] mq-deadline",
+		"queue/read_ahead_kb": "128",
+		"queue/rotational":    "0",
+	}
+	for attr, value := range attributes {
+		if err := writeFile(root, dir+attr, value+"\n", false); err != nil {
+			return err
+		}
+	}
+	if err := writeFile(root, dir+"size", strconv.FormatUint(ns.Size/512, 10)+"\n", resize); err != nil {
+		return err
+	}
+	return writeFile(root, "/dev/"+name, "", false)
+}
+
+// writeFile writes the file of the root, leaving an existing one alone unless overwrite is set
+func writeFile(root string, name string, content string, overwrite bool) error {
+	file := filepath.Join(root, name)
+	if !overwrite {
+		if _, err := os.Stat(file); err == nil {
+			return nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(content), 0o644) // #nosec G306
+}
+
+// removeStale removes the entries of dir matching the pattern which are not kept
+func removeStale(dir string, pattern *regexp.Regexp, kept map[string]bool) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if pattern.MatchString(entry.Name()) && !kept[entry.Name()] {
+			if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}